@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bufio"
+	"cfn-init/internal/adopt"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var adoptCmd = &cobra.Command{
+	Use:   "adopt [path]",
+	Short: "Create a cfn-project from an existing repository",
+	Long:  "Scans an existing repository for templates, parameters, tags and GitSync deployment files, classifying them by content. Environments are inferred from file and folder names such as params/prod.json or tags-staging.yaml. The proposed layout is shown and, after confirmation, the project is created with templates referenced as stacks and environment files copied; files that share a name, such as params/prod.json and tags/prod.json, get a numeric suffix. If a step fails, the new cfn-project folder is removed.",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		root := "."
+		if len(args) > 0 {
+			root = args[0]
+		}
+		projectName, _ := cmd.Flags().GetString("name")
+		extraEnvironments, _ := cmd.Flags().GetStringSlice("environments")
+		assumeYes, _ := cmd.Flags().GetBool("yes")
+
+		plan, err := adopt.Scan(root, projectName, extraEnvironments)
+		if err != nil {
+			return err
+		}
+
+		printAdoptPlan(plan)
+		if len(plan.Stacks) == 0 && len(plan.Environments) == 0 {
+			fmt.Println("\nNothing to adopt")
+			return nil
+		}
+
+		if !assumeYes {
+			scanner := bufio.NewScanner(os.Stdin)
+			fmt.Print("\nCreate this project? (y/n): ")
+			scanner.Scan()
+			response := strings.ToLower(strings.TrimSpace(scanner.Text()))
+			if response != "y" && response != "yes" {
+				fmt.Println("Aborted")
+				return nil
+			}
+		}
+
+		if err := adopt.Apply(plan); err != nil {
+			return err
+		}
+		fmt.Printf("\n✓ Adopt complete! Project '%s' created\n", plan.ProjectName)
+		return nil
+	},
+}
+
+func printAdoptPlan(plan *adopt.Plan) {
+	fmt.Printf("Proposed project '%s':\n", plan.ProjectName)
+
+	fmt.Println("\nStacks:")
+	if len(plan.Stacks) == 0 {
+		fmt.Println("  (none)")
+	}
+	for _, s := range plan.Stacks {
+		fmt.Printf("  %s -> %s\n", s.Name, s.Template)
+	}
+
+	fmt.Println("\nEnvironments:")
+	if len(plan.Environments) == 0 {
+		fmt.Println("  (none)")
+	}
+	for _, env := range plan.Environments {
+		fmt.Printf("  %s (profile: %s)\n", env.Name, env.Profile)
+		for _, file := range env.ParametersFiles {
+			fmt.Printf("    parameters: %s\n", file)
+		}
+		for _, file := range env.TagsFiles {
+			fmt.Printf("    tags:       %s\n", file)
+		}
+		for _, file := range env.GitSyncFiles {
+			fmt.Printf("    gitsync:    %s\n", file)
+		}
+	}
+
+	if len(plan.Unassigned) > 0 {
+		fmt.Println("\nFiles without an inferred environment (not adopted):")
+		for _, file := range plan.Unassigned {
+			fmt.Printf("  %s (%s)\n", file.Path, file.Kind)
+		}
+	}
+}
+
+func init() {
+	adoptCmd.Flags().String("name", "", "Project name (defaults to the repository folder name)")
+	adoptCmd.Flags().StringSlice("environments", nil, "Additional environment names to recognise in file names")
+	adoptCmd.Flags().BoolP("yes", "y", false, "Create the project without asking for confirmation")
+}
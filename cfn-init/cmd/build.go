@@ -0,0 +1,81 @@
+package main
+
+import (
+	"cfn-init/internal/build"
+	"cfn-init/internal/config"
+	"cfn-init/internal/encryption"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/spf13/cobra"
+)
+
+var buildCmd = &cobra.Command{
+	Use:   "build",
+	Short: "Render deployable artifacts for each environment",
+	Long:  "Resolves each environment's defaults, inherited values and ${...} references and writes build/<env>/ with the AWS CLI parameters file, the tags file, the GitSync deployment file and a manifest of SHA-256 hashes of every input and output. Without --env every environment is built. Identical inputs produce byte-identical output. Encrypted values are decrypted with the project's key file, and builds holding them are readable only by you.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		envNames, _ := cmd.Flags().GetStringSlice("env")
+		templatePath, _ := cmd.Flags().GetString("template")
+		outputDir, _ := cmd.Flags().GetString("output-dir")
+
+		key, err := optionalKey(cmd)
+		if err != nil {
+			return err
+		}
+
+		if len(envNames) == 0 {
+			configFile, err := config.ReadConfigFile(".")
+			if err != nil {
+				return fmt.Errorf("failed to read project config: %w", err)
+			}
+			for name := range configFile.Environments {
+				envNames = append(envNames, name)
+			}
+			sort.Strings(envNames)
+		}
+		if len(envNames) == 0 {
+			fmt.Println("No environments to build")
+			return nil
+		}
+
+		for _, envName := range envNames {
+			manifest, err := build.Environment(envName, templatePath, outputDir, key)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("✓ Built environment '%s' in %s (%d files from %d inputs)\n",
+				envName, filepath.Join(outputDir, envName), len(manifest.Outputs), len(manifest.Inputs))
+		}
+		return nil
+	},
+}
+
+// optionalKey loads the key file from --key-file, or the project's default key
+// file when it exists. Builds and syncs without encrypted values need no key.
+func optionalKey(cmd *cobra.Command) ([]byte, error) {
+	path, _ := cmd.Flags().GetString("key-file")
+	if path == "" {
+		configFile, err := config.ReadConfigFile(".")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read project config: %w", err)
+		}
+		if path, err = encryption.DefaultKeyPath(configFile.Project.Name); err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return nil, nil
+		}
+	}
+	return encryption.LoadKey(path)
+}
+
+func init() {
+	buildCmd.Flags().StringSlice("env", nil, "Environments to build (default all)")
+	buildCmd.Flags().String("template", "", "Path to the CloudFormation template for projects without stacks")
+	buildCmd.Flags().String("output-dir", build.DefaultOutputDir, "Directory to write the build to")
+	buildCmd.Flags().String("key-file", "", "Key file to decrypt encrypted values with (default $"+encryption.KeyFileEnv+" or ~/.cfn-init/keys/<project>.key)")
+}
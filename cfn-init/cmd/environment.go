@@ -1,10 +1,18 @@
 package main
 
 import (
+	"bufio"
 	"cfn-init/internal"
+	"cfn-init/internal/config"
 	"cfn-init/internal/environment"
+	"cfn-init/internal/parameters"
+	"cfn-init/internal/policy"
+	"cfn-init/internal/template"
 	"encoding/json"
 	"fmt"
+	"os"
+	"sort"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
@@ -42,7 +50,7 @@ var updateEnvCmd = &cobra.Command{
 	Short: "Update an existing environment",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		var newName, newProfile *string
+		var newName, newProfile, newRegion, newExtends *string
 
 		if cmd.Flags().Changed("name") {
 			name, _ := cmd.Flags().GetString("name")
@@ -52,8 +60,16 @@ var updateEnvCmd = &cobra.Command{
 			profile, _ := cmd.Flags().GetString("profile")
 			newProfile = &profile
 		}
+		if cmd.Flags().Changed("region") {
+			region, _ := cmd.Flags().GetString("region")
+			newRegion = &region
+		}
+		if cmd.Flags().Changed("extends") {
+			extends, _ := cmd.Flags().GetString("extends")
+			newExtends = &extends
+		}
 
-		return environment.UpdateEnvironment(args[0], newName, newProfile)
+		return environment.UpdateEnvironment(args[0], newName, newProfile, newRegion, newExtends)
 	},
 }
 
@@ -62,7 +78,9 @@ var removeEnvCmd = &cobra.Command{
 	Short: "Remove an environment",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return environment.RemoveEnvironment(args[0])
+		force, _ := cmd.Flags().GetBool("force")
+
+		return environment.RemoveEnvironment(args[0], force)
 	},
 }
 
@@ -89,16 +107,310 @@ var listEnvCmd = &cobra.Command{
 	},
 }
 
+var showEnvCmd = &cobra.Command{
+	Use:   "show <env-name>",
+	Short: "Show an environment's settings and inheritance chain",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		details, err := environment.ShowEnvironment(args[0])
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Environment: %s\n", details.Name)
+		fmt.Printf("  Profile: %s%s\n", valueOrNone(details.Profile), inheritedSuffix(details.Name, details.ProfileFrom))
+		fmt.Printf("  Region: %s%s\n", valueOrNone(details.Region), inheritedSuffix(details.Name, details.RegionFrom))
+		if details.SourceOfTruth != "" {
+			fmt.Printf("  Source of truth: %s\n", details.SourceOfTruth)
+		}
+		fmt.Printf("  Inheritance: %s\n", strings.Join(details.Chain, " -> "))
+		if len(details.Extenders) > 0 {
+			fmt.Printf("  Extended by: %s\n", strings.Join(details.Extenders, ", "))
+		}
+		return nil
+	},
+}
+
 var addEnvironmentFilesCmd = &cobra.Command{
-	Use:   "add-environment-files <env-name>",
+	Use:   "add-environment-files <env-name> [files...]",
 	Short: "Add files to environment folder",
-	Args:  cobra.ExactArgs(1),
+	Long:  "Adds parameters, tags and GitSync files given with their flags, or the files given as arguments as files of the --category category, such as stack-policy for a stack policy checked against the stack's template, or the one given with --template. Projects declare their own categories under fileCategories in cfn-config.json.",
+	Args:  cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		paramFiles, _ := cmd.Flags().GetStringSlice("parameters-files")
 		tagFiles, _ := cmd.Flags().GetStringSlice("tags-files")
 		gitSyncFiles, _ := cmd.Flags().GetStringSlice("gitsync-files")
+		category, _ := cmd.Flags().GetString("category")
+		stackName, _ := cmd.Flags().GetString("stack")
+		allowSecrets, _ := cmd.Flags().GetBool("allow-secrets")
+		mode, _ := cmd.Flags().GetString("mode")
+		onConflict, _ := cmd.Flags().GetString("on-conflict")
+		templatePath, _ := cmd.Flags().GetString("template")
+
+		files := args[1:]
+		if (category == "") != (len(files) == 0) {
+			return fmt.Errorf("give the files to add as arguments together with --category")
+		}
+		if category != "" && len(paramFiles)+len(tagFiles)+len(gitSyncFiles) > 0 {
+			return fmt.Errorf("--category cannot be combined with --parameters-files, --tags-files or --gitsync-files")
+		}
+
+		scanned := append(append([]string{}, paramFiles...), gitSyncFiles...)
+		if category == environment.CategoryParameters || category == environment.CategoryGitSync {
+			scanned = files
+		}
+		findings, err := environment.ScanFilesForSecrets(stackName, scanned)
+		if err != nil {
+			return err
+		}
+		if len(findings) > 0 {
+			printSecretFindings(findings)
+			if !allowSecrets {
+				return &environment.SecretsError{Findings: findings}
+			}
+		}
+
+		opts := environment.FileOptions{Stack: stackName, Mode: mode, OnConflict: onConflict, Template: templatePath}
+		if onConflict == "" {
+			if isTerminal(os.Stdin) {
+				opts.Prompt = promptCollision(bufio.NewScanner(os.Stdin))
+			} else {
+				opts.OnConflict = environment.OnConflictFail
+			}
+		}
+
+		var added []environment.AddedFile
+		if category != "" {
+			added, err = environment.AddCategoryFiles(args[0], category, files, opts)
+		} else {
+			added, err = environment.AddFilesWithOptions(args[0], paramFiles, tagFiles, gitSyncFiles, opts)
+		}
+		if err != nil {
+			return err
+		}
+		for _, file := range added {
+			switch file.Status {
+			case environment.FileSkipped:
+				fmt.Printf("- Skipped %s\n", file.Source)
+			case environment.FileAdded:
+				fmt.Printf("✓ Added %s as %s\n", file.Source, file.Path)
+			default:
+				fmt.Printf("✓ Added %s as %s (%s)\n", file.Source, file.Path, file.Status)
+			}
+			if file.Warning != "" {
+				fmt.Printf("⚠ %s\n", file.Warning)
+			}
+		}
+		return nil
+	},
+}
+
+// promptCollision asks how to resolve each file name collision.
+func promptCollision(scanner *bufio.Scanner) func(environment.FileCollision) (string, error) {
+	return func(c environment.FileCollision) (string, error) {
+		for {
+			if c.Existing {
+				fmt.Printf("%s already exists.\n", c.With)
+			} else {
+				fmt.Printf("%s and %s are both named %s.\n", c.With, c.Source, c.Path)
+			}
+			fmt.Printf("Add %s: [o]verwrite, [r]ename, [s]kip or [f]ail? ", c.Source)
+			if !scanner.Scan() {
+				return environment.OnConflictFail, nil
+			}
+			switch strings.ToLower(strings.TrimSpace(scanner.Text())) {
+			case "o", "overwrite":
+				return environment.OnConflictOverwrite, nil
+			case "r", "rename":
+				return environment.OnConflictRename, nil
+			case "s", "skip":
+				return environment.OnConflictSkip, nil
+			case "f", "fail":
+				return environment.OnConflictFail, nil
+			}
+		}
+	}
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+var resourcesEnvCmd = &cobra.Command{
+	Use:   "resources <env-name>",
+	Short: "List the resources and outputs an environment would deploy",
+	Long:  "Evaluates the template's Conditions with the environment's parameters and lists which resources and outputs would exist. Use --diff to compare against another environment.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		templatePath, _ := cmd.Flags().GetString("template")
+		stackName, _ := cmd.Flags().GetString("stack")
+
+		inventory, err := environment.BuildInventory(args[0], stackName, templatePath)
+		if err != nil {
+			return err
+		}
+
+		otherEnv, _ := cmd.Flags().GetString("diff")
+		if otherEnv == "" {
+			printInventory(inventory)
+			return nil
+		}
+
+		other, err := environment.BuildInventory(otherEnv, stackName, templatePath)
+		if err != nil {
+			return err
+		}
+		printInventoryDiff(environment.DiffInventories(inventory, other))
+		return nil
+	},
+}
 
-		return environment.AddFiles(args[0], paramFiles, tagFiles, gitSyncFiles)
+var valuesEnvCmd = &cobra.Command{
+	Use:   "values <env-name>",
+	Short: "Show an environment's effective parameters and tags",
+	Long:  "Layers the project defaults, the environment's files and the stack's files, expands ${...} references, and shows each effective value with the file that set it.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		stackName, _ := cmd.Flags().GetString("stack")
+
+		values, err := environment.RenderValues(args[0], stackName)
+		if err != nil {
+			return err
+		}
+		printResolvedValues("Parameters", values.Parameters)
+		printResolvedValues("Tags", values.Tags)
+		return nil
+	},
+}
+
+var paramsEnvCmd = &cobra.Command{
+	Use:   "params",
+	Short: "Work with an environment's merged parameters",
+}
+
+var resolveParamsCmd = &cobra.Command{
+	Use:   "resolve <env-name>",
+	Short: "Print an environment's merged parameters",
+	Long:  "Merges the environment's parameters files in their recorded order, where the last file wins, and prints the result. Keys that files of the same folder set to different values are reported as warnings, or as errors when a forbidParameterConflicts policy with error severity applies.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		stackName, _ := cmd.Flags().GetString("stack")
+		format, _ := cmd.Flags().GetString("format")
+
+		values, err := environment.RenderValues(args[0], stackName)
+		if err != nil {
+			return err
+		}
+		configFile, err := config.ReadConfigFile(".")
+		if err != nil {
+			return err
+		}
+
+		severity := policy.ConflictSeverity(configFile, args[0])
+		for _, c := range values.Conflicts {
+			symbol := "⚠"
+			if severity == policy.SeverityError {
+				symbol = "✗"
+			}
+			fmt.Fprintf(os.Stderr, "%s parameter '%s' is set differently by %s; using '%s'\n",
+				symbol, c.Parameter, strings.Join(c.Sources, ", "), c.Sources[len(c.Sources)-1])
+		}
+		if severity == policy.SeverityError && len(values.Conflicts) > 0 {
+			return fmt.Errorf("environment '%s' has %d conflicting parameter(s)", args[0], len(values.Conflicts))
+		}
+
+		data, err := parameters.Marshal(values.ParameterValues(), parameters.Format(format))
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(data)
+		return err
+	},
+}
+
+var validateEnvCmd = &cobra.Command{
+	Use:   "validate <env-name>",
+	Short: "Validate an environment's tags and GitSync files",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		problems, err := environment.Validate(args[0])
+		if err != nil {
+			return err
+		}
+		if len(problems) == 0 {
+			fmt.Printf("✓ Environment '%s' is valid\n", args[0])
+			return nil
+		}
+		for _, problem := range problems {
+			fmt.Printf("✗ %v\n", problem)
+		}
+		return fmt.Errorf("environment '%s' has %d invalid file(s)", args[0], len(problems))
+	},
+}
+
+var gitSyncEnvCmd = &cobra.Command{
+	Use:   "gitsync",
+	Short: "Manage GitSync deployment files",
+}
+
+var gitSyncGenerateCmd = &cobra.Command{
+	Use:   "generate <env-name>",
+	Short: "Generate a GitSync deployment file from parameters and tags files",
+	Long:  "Merges the environment's parameters and tags files into a single GitSync deployment file in the environment folder. Use --check in CI to fail when the file has drifted from its sources.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		templatePath, _ := cmd.Flags().GetString("template")
+		stackName, _ := cmd.Flags().GetString("stack")
+		fileName, _ := cmd.Flags().GetString("file")
+		check, _ := cmd.Flags().GetBool("check")
+
+		if check {
+			drift, err := environment.CheckGitSync(args[0], stackName, templatePath, fileName)
+			if err != nil {
+				return err
+			}
+			if len(drift) > 0 {
+				fmt.Printf("✗ %s is out of date:\n", fileName)
+				for _, d := range drift {
+					fmt.Printf("  %s\n", d)
+				}
+				return fmt.Errorf("GitSync file '%s' has drifted from its sources", fileName)
+			}
+			fmt.Printf("✓ %s is up to date\n", fileName)
+			return nil
+		}
+
+		deployment, err := environment.GenerateGitSync(args[0], stackName, templatePath, fileName)
+		if err != nil {
+			return err
+		}
+		path, err := environment.WriteGitSync(args[0], stackName, fileName, deployment)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✓ Generated %s (%d parameters, %d tags)\n", path, len(deployment.Parameters), len(deployment.Tags))
+		return nil
+	},
+}
+
+var gitSyncSplitCmd = &cobra.Command{
+	Use:   "split <env-name> <file>",
+	Short: "Split a GitSync deployment file into parameters and tags files",
+	Long:  "Writes AWS CLI parameters.json and tags.json files next to a GitSync deployment file and records the deployment file as the environment's source of truth. The file is relative to the environment folder.",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		force, _ := cmd.Flags().GetBool("force")
+
+		written, err := environment.SplitGitSync(args[0], args[1], force)
+		if err != nil {
+			return err
+		}
+		for _, path := range written {
+			fmt.Printf("✓ Wrote %s\n", path)
+		}
+		fmt.Printf("✓ Recorded %s as the source of truth for '%s'\n", args[1], args[0])
+		return nil
 	},
 }
 
@@ -124,19 +436,135 @@ var addMultipleEnvCmd = &cobra.Command{
 	},
 }
 
+func valueOrNone(value string) string {
+	if value == "" {
+		return "none"
+	}
+	return value
+}
+
+func inheritedSuffix(envName, from string) string {
+	if from == "" || from == envName {
+		return ""
+	}
+	return fmt.Sprintf(" (inherited from %s)", from)
+}
+
+func printResolvedValues(title string, values map[string]environment.ResolvedValue) {
+	if len(values) == 0 {
+		fmt.Printf("%s: none\n", title)
+		return
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fmt.Printf("%s:\n", title)
+	for _, key := range keys {
+		fmt.Printf("  %s = %s (%s)\n", key, values[key].Value, values[key].Source)
+	}
+}
+
+func printInventory(inventory *environment.Inventory) {
+	fmt.Printf("Resources in environment '%s':\n", inventory.Environment)
+	for _, entry := range inventory.Resources {
+		fmt.Printf("  %s %s (%s)%s\n", statusSymbol(entry.Status), entry.Name, entry.Type, conditionSuffix(entry))
+	}
+
+	if len(inventory.Outputs) > 0 {
+		fmt.Println("Outputs:")
+		for _, entry := range inventory.Outputs {
+			fmt.Printf("  %s %s%s\n", statusSymbol(entry.Status), entry.Name, conditionSuffix(entry))
+		}
+	}
+}
+
+func printInventoryDiff(diff *environment.InventoryDiff) {
+	if len(diff.Resources) == 0 && len(diff.Outputs) == 0 {
+		fmt.Printf("Environments '%s' and '%s' deploy the same resources and outputs\n", diff.Left, diff.Right)
+		return
+	}
+
+	fmt.Printf("Differences between '%s' and '%s':\n", diff.Left, diff.Right)
+	for _, entry := range diff.Resources {
+		fmt.Printf("  %s: %s=%s %s=%s\n", entry.Name, diff.Left, entry.Left, diff.Right, entry.Right)
+	}
+	for _, entry := range diff.Outputs {
+		fmt.Printf("  Output %s: %s=%s %s=%s\n", entry.Name, diff.Left, entry.Left, diff.Right, entry.Right)
+	}
+}
+
+func statusSymbol(status template.Truth) string {
+	switch status {
+	case template.True:
+		return "+"
+	case template.False:
+		return "-"
+	default:
+		return "?"
+	}
+}
+
+func conditionSuffix(entry environment.InventoryEntry) string {
+	if entry.Condition == "" {
+		return ""
+	}
+	return fmt.Sprintf(" [%s=%s]", entry.Condition, entry.Status)
+}
+
 func init() {
 	addEnvCmd.Flags().String("environments", "", "JSON configuration for environments")
-	
+
 	updateEnvCmd.Flags().String("name", "", "New environment name")
 	updateEnvCmd.Flags().String("profile", "", "New AWS profile")
+	updateEnvCmd.Flags().String("region", "", "New AWS region")
+	updateEnvCmd.Flags().String("extends", "", "Environment to inherit settings, parameters and tags from (empty to stop inheriting)")
+
+	removeEnvCmd.Flags().Bool("force", false, "Remove the environment even if other environments extend it")
 
 	addEnvironmentFilesCmd.Flags().StringSlice("parameters-files", nil, "Parameters files to copy to environments folder")
 	addEnvironmentFilesCmd.Flags().StringSlice("tags-files", nil, "Tags files to copy to environments folder")
 	addEnvironmentFilesCmd.Flags().StringSlice("gitsync-files", nil, "GitSync files to copy to environments folder")
+	addEnvironmentFilesCmd.Flags().String("category", "", "Category of the files given as arguments: parameters, tags, gitsync, stack-policy or one declared in cfn-config.json")
+	addEnvironmentFilesCmd.Flags().String("stack", "", "Stack to scope the files to")
+	addEnvironmentFilesCmd.Flags().Bool("allow-secrets", false, "Add files even if they appear to contain plaintext secrets")
+	addEnvironmentFilesCmd.Flags().String("mode", environment.ModeCopy, "How to add the files: copy, symlink, or reference to read them in place")
+	addEnvironmentFilesCmd.Flags().String("template", "", "Template to check stack policies against when the stack or GitSync file names none")
+	addEnvironmentFilesCmd.Flags().String("on-conflict", "", "How to handle files whose names are taken: fail, overwrite, rename or skip (default: ask in a terminal, otherwise fail)")
+
+	resourcesEnvCmd.Flags().String("template", "", "Path to the CloudFormation template")
+	resourcesEnvCmd.Flags().String("stack", "", "Stack whose template and parameters to use")
+	resourcesEnvCmd.Flags().String("diff", "", "Another environment to compare resources against")
+
+	valuesEnvCmd.Flags().String("stack", "", "Include the stack's scoped files")
+
+	resolveParamsCmd.Flags().String("stack", "", "Include the stack's scoped files")
+	resolveParamsCmd.Flags().String("format", string(parameters.FormatCLI), "Output format (cli, map, gitsync)")
+
+	gitSyncGenerateCmd.Flags().String("template", "", "Path to the CloudFormation template, relative to the repository root")
+	gitSyncGenerateCmd.Flags().String("stack", "", "Stack whose template and scoped files to use")
+	gitSyncGenerateCmd.Flags().String("file", environment.DefaultGitSyncFile, "Name of the deployment file")
+	gitSyncGenerateCmd.Flags().Bool("check", false, "Fail if the existing deployment file differs from its sources")
+
+	gitSyncSplitCmd.Flags().Bool("force", false, "Replace existing parameters and tags files")
 
 	environmentCmd.AddCommand(addEnvCmd)
 	environmentCmd.AddCommand(updateEnvCmd)
 	environmentCmd.AddCommand(removeEnvCmd)
 	environmentCmd.AddCommand(listEnvCmd)
+	environmentCmd.AddCommand(showEnvCmd)
 	environmentCmd.AddCommand(addEnvironmentFilesCmd)
+	environmentCmd.AddCommand(resourcesEnvCmd)
+	environmentCmd.AddCommand(validateEnvCmd)
+	environmentCmd.AddCommand(valuesEnvCmd)
+	environmentCmd.AddCommand(paramsEnvCmd)
+	environmentCmd.AddCommand(gitSyncEnvCmd)
+	environmentCmd.AddCommand(secretsEnvCmd)
+	environmentCmd.AddCommand(filesEnvCmd)
+	gitSyncEnvCmd.AddCommand(gitSyncGenerateCmd)
+	gitSyncEnvCmd.AddCommand(gitSyncSplitCmd)
+	paramsEnvCmd.AddCommand(resolveParamsCmd)
 }
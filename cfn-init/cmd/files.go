@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bufio"
+	"cfn-init/internal/encryption"
+	"cfn-init/internal/environment"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var filesEnvCmd = &cobra.Command{
+	Use:   "files",
+	Short: "List, remove, check, resync and categorize environment files",
+	Long:  "Environment files record their category in cfn-config.json. Files added from other paths also remember their source and content hash, so copies that drifted from their originals can be found and updated",
+}
+
+var statusFilesCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Report copied files whose sources changed or are missing",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		envName, _ := cmd.Flags().GetString("env")
+
+		statuses, err := environment.FilesStatus(envName)
+		if err != nil {
+			return err
+		}
+		if len(statuses) == 0 {
+			fmt.Println("No tracked files")
+			return nil
+		}
+
+		drifted := 0
+		for _, s := range statuses {
+			symbol := "✓"
+			if s.Status != environment.FileCurrent {
+				symbol = "✗"
+				drifted++
+			}
+			fmt.Printf("%s %s: %s <- %s (%s, %s)\n", symbol, s.Environment, s.Path, s.Source, s.Mode, s.Status)
+		}
+		if drifted > 0 {
+			fmt.Printf("\n%d of %d files differ from their sources\n", drifted, len(statuses))
+		}
+		return nil
+	},
+}
+
+var syncFilesCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Copy updated sources into the environment folders",
+	Long:  "Shows the diff of every copied file whose source changed and, after confirmation, copies the sources over the copies. Copies edited since they were added are only overwritten with --force. Updated sources are validated and scanned for plaintext secrets like files being added, and values encrypted in a copy are encrypted again with the key file.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		envName, _ := cmd.Flags().GetString("env")
+		force, _ := cmd.Flags().GetBool("force")
+		assumeYes, _ := cmd.Flags().GetBool("yes")
+		allowSecrets, _ := cmd.Flags().GetBool("allow-secrets")
+
+		key, err := optionalKey(cmd)
+		if err != nil {
+			return err
+		}
+
+		changes, err := environment.SyncFiles(envName, force, allowSecrets, key, func(pending []environment.FileChange) bool {
+			for _, change := range pending {
+				fmt.Printf("%s: %s (%s)\n", change.Environment, change.Path, change.Status)
+				fmt.Print(change.Diff)
+				fmt.Println()
+			}
+			if assumeYes {
+				return true
+			}
+			scanner := bufio.NewScanner(os.Stdin)
+			fmt.Printf("Sync %d file(s)? (y/n): ", len(pending))
+			scanner.Scan()
+			response := strings.ToLower(strings.TrimSpace(scanner.Text()))
+			return response == "y" || response == "yes"
+		})
+		var secretsErr *environment.SecretsError
+		if errors.As(err, &secretsErr) {
+			printSecretFindings(secretsErr.Findings)
+		}
+		if err != nil {
+			return err
+		}
+		if len(changes) == 0 {
+			fmt.Println("No files synced")
+			return nil
+		}
+		for _, change := range changes {
+			fmt.Printf("✓ Synced %s: %s from %s\n", change.Environment, change.Path, change.Source)
+		}
+		return nil
+	},
+}
+
+var migrateFilesCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Record the category of files added before categories were recorded",
+	Long:  "Classifies every environment file without a recorded category by its content and records it as parameters, tags, gitsync or stack-policy in cfn-config.json. Files that match no category are reported and left unrecorded.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		envName, _ := cmd.Flags().GetString("env")
+
+		migrated, err := environment.MigrateFiles(envName)
+		if err != nil {
+			return err
+		}
+		if len(migrated) == 0 {
+			fmt.Println("No files to migrate")
+			return nil
+		}
+		for _, file := range migrated {
+			if file.Category == "" {
+				fmt.Printf("✗ %s: %s has no known category, left unrecorded\n", file.Environment, file.Path)
+				continue
+			}
+			fmt.Printf("✓ %s: %s is a %s file\n", file.Environment, file.Path, file.Category)
+		}
+		return nil
+	},
+}
+
+var listFilesCmd = &cobra.Command{
+	Use:   "list <env-name>",
+	Short: "List an environment's files with their category, size, hash and source",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		files, err := environment.ListFiles(args[0])
+		if err != nil {
+			return err
+		}
+		if len(files) == 0 {
+			fmt.Printf("Environment '%s' has no files\n", args[0])
+			return nil
+		}
+
+		fmt.Printf("Files of environment '%s':\n", args[0])
+		for _, file := range files {
+			category := file.Category
+			if category == "" {
+				category = "unknown"
+			}
+			details := fmt.Sprintf("%s, missing", category)
+			if !file.Missing {
+				details = fmt.Sprintf("%s, %d bytes, sha256 %s", category, file.Size, file.SHA256[:12])
+			}
+			if file.Source != "" {
+				details += fmt.Sprintf(", %s from %s", file.Mode, file.Source)
+			}
+			fmt.Printf("  %s (%s)\n", file.Path, details)
+		}
+		return nil
+	},
+}
+
+var removeFilesCmd = &cobra.Command{
+	Use:   "remove <env-name> <file...>",
+	Short: "Remove files from an environment and from cfn-config.json",
+	Long:  "Deletes files from the environment folder, given relative to it, together with their entries in cfn-config.json. Files that other entries still point to, such as the source of truth, a file added from them or a GitSync template-file-path, are not removed.",
+	Args:  cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		removed, err := environment.RemoveFiles(args[0], args[1:])
+		if err != nil {
+			return err
+		}
+		for _, file := range removed {
+			fmt.Printf("✓ Removed %s from environment '%s'\n", file, args[0])
+		}
+		return nil
+	},
+}
+
+func init() {
+	statusFilesCmd.Flags().String("env", "", "Only check this environment")
+	syncFilesCmd.Flags().String("env", "", "Only sync this environment")
+	syncFilesCmd.Flags().Bool("force", false, "Also overwrite copies that were edited locally")
+	syncFilesCmd.Flags().BoolP("yes", "y", false, "Sync without asking for confirmation")
+	syncFilesCmd.Flags().Bool("allow-secrets", false, "Sync sources even if they appear to contain plaintext secrets")
+	syncFilesCmd.Flags().String("key-file", "", "Key file to encrypt again the values encrypted in copies (default $"+encryption.KeyFileEnv+" or ~/.cfn-init/keys/<project>.key)")
+	migrateFilesCmd.Flags().String("env", "", "Only migrate this environment")
+
+	filesEnvCmd.AddCommand(listFilesCmd)
+	filesEnvCmd.AddCommand(removeFilesCmd)
+	filesEnvCmd.AddCommand(statusFilesCmd)
+	filesEnvCmd.AddCommand(syncFilesCmd)
+	filesEnvCmd.AddCommand(migrateFilesCmd)
+}
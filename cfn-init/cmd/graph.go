@@ -0,0 +1,36 @@
+package main
+
+import (
+	"cfn-init/internal/diagram"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var graphCmd = &cobra.Command{
+	Use:   "graph",
+	Short: "Export the project graph as Graphviz DOT or Mermaid",
+	Long:  "Prints the project's stacks, environments, environment files and cross-stack export/import edges as a Graphviz DOT or Mermaid diagram.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		envName, _ := cmd.Flags().GetString("env")
+
+		d, err := diagram.Build(envName)
+		if err != nil {
+			return err
+		}
+
+		output, err := diagram.Render(d, format)
+		if err != nil {
+			return err
+		}
+		fmt.Print(output)
+		return nil
+	},
+}
+
+func init() {
+	graphCmd.Flags().String("format", diagram.FormatMermaid, "Output format (dot or mermaid)")
+	graphCmd.Flags().String("env", "", "Environment to resolve cross-stack export and import names for")
+}
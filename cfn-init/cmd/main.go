@@ -17,8 +17,15 @@ var rootCmd = &cobra.Command{
 }
 
 func init() {
+	rootCmd.AddCommand(buildCmd)
 	rootCmd.AddCommand(CreateCmd)
+	rootCmd.AddCommand(adoptCmd)
 	rootCmd.AddCommand(environmentCmd)
+	rootCmd.AddCommand(graphCmd)
+	rootCmd.AddCommand(policyCmd)
+	rootCmd.AddCommand(renderCmd)
+	rootCmd.AddCommand(scanCmd)
+	rootCmd.AddCommand(stackCmd)
 	rootCmd.AddCommand(versionCmd)
 }
 
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"cfn-init/internal/config"
+	"cfn-init/internal/policy"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var policyCmd = &cobra.Command{
+	Use:   "policy",
+	Short: "Check the project's policies",
+	Long:  "Evaluate the rules in the policies section of cfn-config.json against the project's environments",
+}
+
+var checkPolicyCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Check every environment against the project's policies",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		envName, _ := cmd.Flags().GetString("env")
+
+		configFile, err := config.ReadConfigFile(".")
+		if err != nil {
+			return fmt.Errorf("failed to read project config: %w", err)
+		}
+		if len(configFile.Policies) == 0 {
+			fmt.Println("No policies defined")
+			return nil
+		}
+
+		violations, err := policy.Evaluate(configFile, envName)
+		if err != nil {
+			return err
+		}
+		if len(violations) == 0 {
+			fmt.Printf("✓ %d policies passed\n", len(configFile.Policies))
+			return nil
+		}
+
+		printViolations(violations)
+		if policy.HasErrors(violations) {
+			return fmt.Errorf("policy check failed")
+		}
+		return nil
+	},
+}
+
+// withPolicies runs a command that changes the project under policy.Guard,
+// so changes introducing policy errors are rolled back.
+func withPolicies(run func(cmd *cobra.Command, args []string) error) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		warnings, err := policy.Guard(func() error {
+			return run(cmd, args)
+		})
+		printViolations(warnings)
+		return err
+	}
+}
+
+func printViolations(violations []policy.Violation) {
+	for _, v := range violations {
+		symbol := "✗"
+		if v.Severity == policy.SeverityWarning {
+			symbol = "⚠"
+		}
+		fmt.Printf("%s %s\n", symbol, v)
+	}
+}
+
+func init() {
+	// Commands that change the project are checked against its policies
+	for _, cmd := range []*cobra.Command{
+		addEnvCmd, addMultipleEnvCmd, updateEnvCmd, removeEnvCmd, addEnvironmentFilesCmd,
+		gitSyncGenerateCmd, gitSyncSplitCmd, encryptSecretsCmd, decryptSecretsCmd, editSecretsCmd,
+		syncFilesCmd, removeFilesCmd, migrateFilesCmd,
+		addStackCmd, removeStackCmd, migrateStackCmd,
+	} {
+		cmd.RunE = withPolicies(cmd.RunE)
+	}
+
+	checkPolicyCmd.Flags().String("env", "", "Only check this environment")
+
+	policyCmd.AddCommand(checkPolicyCmd)
+}
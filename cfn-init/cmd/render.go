@@ -0,0 +1,73 @@
+package main
+
+import (
+	"cfn-init/internal/environment"
+	"cfn-init/internal/permissions"
+	"cfn-init/internal/render"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var renderCmd = &cobra.Command{
+	Use:   "render <env-name>",
+	Short: "Render a fully resolved template preview for an environment",
+	Long:  "Substitutes the environment's parameter values into the template, resolves mappings, Fn::If, Fn::Sub and Fn::Join where possible and removes resources whose conditions are false. Values only known at deploy time are kept as intrinsic functions.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		templatePath, _ := cmd.Flags().GetString("template")
+		stackName, _ := cmd.Flags().GetString("stack")
+		format, _ := cmd.Flags().GetString("format")
+		outputDir, _ := cmd.Flags().GetString("output-dir")
+
+		tmpl, err := environment.LoadTemplate(stackName, templatePath)
+		if err != nil {
+			return err
+		}
+
+		evaluator, err := environment.NewEvaluator(args[0], stackName, tmpl)
+		if err != nil {
+			return err
+		}
+
+		body, err := render.Render(tmpl, evaluator)
+		if err != nil {
+			return err
+		}
+
+		data, err := render.Marshal(body, format)
+		if err != nil {
+			return err
+		}
+
+		if outputDir == "" {
+			_, err := os.Stdout.Write(data)
+			return err
+		}
+
+		envDir := filepath.Join(outputDir, args[0])
+		if err := os.MkdirAll(envDir, permissions.ProjectDir); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+		baseName := stackName
+		if baseName == "" {
+			baseName = strings.TrimSuffix(filepath.Base(tmpl.Path), filepath.Ext(tmpl.Path))
+		}
+		outputFile := filepath.Join(envDir, baseName+"."+format)
+		if err := os.WriteFile(outputFile, data, permissions.ConfigFile); err != nil {
+			return fmt.Errorf("failed to write rendered template: %w", err)
+		}
+		fmt.Printf("✓ Rendered template written to %s\n", outputFile)
+		return nil
+	},
+}
+
+func init() {
+	renderCmd.Flags().String("template", "", "Path to the CloudFormation template")
+	renderCmd.Flags().String("stack", "", "Stack whose template and parameters to use")
+	renderCmd.Flags().String("format", render.FormatYAML, "Output format (yaml or json)")
+	renderCmd.Flags().String("output-dir", "", "Directory to write rendered templates to instead of stdout")
+}
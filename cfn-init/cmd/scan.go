@@ -0,0 +1,68 @@
+package main
+
+import (
+	"cfn-init/internal/environment"
+	"cfn-init/internal/secrets"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var scanCmd = &cobra.Command{
+	Use:   "scan",
+	Short: "Scan the project for problems",
+}
+
+var scanSecretsCmd = &cobra.Command{
+	Use:   "secrets",
+	Short: "Find plaintext secrets in environment parameters files",
+	Long:  "Reports parameter values that are NoEcho in the template, have sensitive names or look like generated secrets, and suggests dynamic references to replace them.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		envName, _ := cmd.Flags().GetString("env")
+
+		envNames := []string{envName}
+		if envName == "" {
+			envs, err := environment.ListEnvironments()
+			if err != nil {
+				return err
+			}
+			envNames = envNames[:0]
+			for name := range envs {
+				envNames = append(envNames, name)
+			}
+			sort.Strings(envNames)
+		}
+
+		var findings []secrets.Finding
+		for _, name := range envNames {
+			envFindings, err := environment.ScanSecrets(name)
+			if err != nil {
+				return err
+			}
+			findings = append(findings, envFindings...)
+		}
+
+		if len(findings) == 0 {
+			fmt.Println("✓ No plaintext secrets found")
+			return nil
+		}
+		printSecretFindings(findings)
+		return fmt.Errorf("found %d possible plaintext secret(s)", len(findings))
+	},
+}
+
+func printSecretFindings(findings []secrets.Finding) {
+	for _, finding := range findings {
+		fmt.Printf("⚠ %s: %s (%s)\n", finding.File, finding.Parameter, strings.Join(finding.Reasons, ", "))
+		fmt.Printf("    use %s\n", finding.Suggestion())
+	}
+}
+
+func init() {
+	scanSecretsCmd.Flags().String("env", "", "Only scan this environment")
+
+	scanCmd.AddCommand(scanSecretsCmd)
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"cfn-init/internal/config"
+	"cfn-init/internal/encryption"
+	"cfn-init/internal/environment"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var secretsEnvCmd = &cobra.Command{
+	Use:   "secrets",
+	Short: "Encrypt and decrypt parameter values",
+	Long:  "Encrypt individual parameter values in environment files with AES-256-GCM and a local key file. Values are stored as ENC[AES256_GCM,...] and are decrypted by these commands and by build; they cannot be decrypted with sops.",
+}
+
+var encryptSecretsCmd = &cobra.Command{
+	Use:   "encrypt <env-name>",
+	Short: "Encrypt parameter values in place",
+	Long:  "Encrypts the named parameters, or the parameters that look like secrets when none are named. A key file is created on first use.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		names, _ := cmd.Flags().GetStringSlice("parameter")
+
+		key, err := secretsKey(cmd, true)
+		if err != nil {
+			return err
+		}
+		encrypted, err := environment.EncryptParameters(args[0], key, names)
+		if err != nil {
+			return err
+		}
+		if len(encrypted) == 0 {
+			fmt.Println("No values to encrypt")
+			return nil
+		}
+		printSecretChanges("Encrypted", encrypted)
+		return nil
+	},
+}
+
+var decryptSecretsCmd = &cobra.Command{
+	Use:   "decrypt <env-name>",
+	Short: "Decrypt parameter values in place",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key, err := secretsKey(cmd, false)
+		if err != nil {
+			return err
+		}
+		decrypted, err := environment.DecryptParameters(args[0], key)
+		if err != nil {
+			return err
+		}
+		if len(decrypted) == 0 {
+			fmt.Println("No encrypted values found")
+			return nil
+		}
+		printSecretChanges("Decrypted", decrypted)
+		return nil
+	},
+}
+
+var editSecretsCmd = &cobra.Command{
+	Use:   "edit <env-name> [file]",
+	Short: "Edit a parameters file with its values decrypted",
+	Long:  "Opens a decrypted copy of a parameters file in $VISUAL or $EDITOR and encrypts the previously encrypted values again when the editor exits.",
+	Args:  cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file := ""
+		if len(args) == 2 {
+			file = args[1]
+		}
+
+		key, err := secretsKey(cmd, false)
+		if err != nil {
+			return err
+		}
+		if err := environment.EditParameters(args[0], file, key, runEditor); err != nil {
+			return err
+		}
+		fmt.Println("✓ Saved parameters file")
+		return nil
+	},
+}
+
+var renderSecretsCmd = &cobra.Command{
+	Use:   "render <env-name>",
+	Short: "Write decrypted environment files to a private temporary directory",
+	Long:  "Copies the environment's files, including its stack folders, the files it references in place and the project's defaults, with every value decrypted into a new temporary directory readable only by you, and prints its path. Remove the directory when done.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key, err := secretsKey(cmd, false)
+		if err != nil {
+			return err
+		}
+		buildDir, err := environment.RenderDecrypted(args[0], key)
+		if err != nil {
+			return err
+		}
+		fmt.Println(buildDir)
+		return nil
+	},
+}
+
+// secretsKey loads the key file from --key-file or the project's default
+// location, creating a new key when create is set and none exists.
+func secretsKey(cmd *cobra.Command, create bool) ([]byte, error) {
+	path, _ := cmd.Flags().GetString("key-file")
+	if path == "" {
+		configFile, err := config.ReadConfigFile(".")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read project config: %w", err)
+		}
+		if path, err = encryption.DefaultKeyPath(configFile.Project.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := os.Stat(path); os.IsNotExist(err) && create {
+		key, err := encryption.GenerateKey(path)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Printf("✓ Created key file %s (back it up; values cannot be decrypted without it)\n", path)
+		return key, nil
+	}
+	return encryption.LoadKey(path)
+}
+
+func runEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	fields := strings.Fields(editor)
+	editorCmd := exec.Command(fields[0], append(fields[1:], path)...)
+	editorCmd.Stdin = os.Stdin
+	editorCmd.Stdout = os.Stdout
+	editorCmd.Stderr = os.Stderr
+	if err := editorCmd.Run(); err != nil {
+		return fmt.Errorf("editor failed: %w", err)
+	}
+	return nil
+}
+
+func printSecretChanges(action string, changes map[string][]string) {
+	files := make([]string, 0, len(changes))
+	for file := range changes {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		fmt.Printf("✓ %s %s in %s\n", action, strings.Join(changes[file], ", "), file)
+	}
+}
+
+func init() {
+	secretsEnvCmd.PersistentFlags().String("key-file", "", "Key file to use (default $"+encryption.KeyFileEnv+" or ~/.cfn-init/keys/<project>.key)")
+	encryptSecretsCmd.Flags().StringSlice("parameter", nil, "Parameters to encrypt (default: values that look like secrets)")
+
+	secretsEnvCmd.AddCommand(encryptSecretsCmd)
+	secretsEnvCmd.AddCommand(decryptSecretsCmd)
+	secretsEnvCmd.AddCommand(editSecretsCmd)
+	secretsEnvCmd.AddCommand(renderSecretsCmd)
+}
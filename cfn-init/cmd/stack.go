@@ -0,0 +1,184 @@
+package main
+
+import (
+	"cfn-init/internal/environment"
+	"cfn-init/internal/stack"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var stackCmd = &cobra.Command{
+	Use:   "stack",
+	Short: "Manage the CloudFormation stacks of a project",
+	Long:  "Add, remove, and list the stacks deployed to each environment of the project",
+}
+
+var addStackCmd = &cobra.Command{
+	Use:   "add <stack-name>",
+	Short: "Add a stack",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		templatePath, _ := cmd.Flags().GetString("template")
+		pattern, _ := cmd.Flags().GetString("stack-name-pattern")
+
+		if err := stack.Add(args[0], templatePath, pattern); err != nil {
+			return err
+		}
+		fmt.Printf("✓ Added stack '%s'\n", args[0])
+		return nil
+	},
+}
+
+var listStackCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all stacks",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		stacks, err := stack.List()
+		if err != nil {
+			return err
+		}
+
+		if len(stacks) == 0 {
+			fmt.Println("No stacks found")
+			return nil
+		}
+
+		fmt.Println("Stacks:")
+		for _, s := range stacks {
+			pattern := s.StackNamePattern
+			if pattern == "" {
+				pattern = "default"
+			}
+			fmt.Printf("  %s -> %s (stack name: %s)\n", s.Name, s.Template, pattern)
+		}
+		return nil
+	},
+}
+
+var removeStackCmd = &cobra.Command{
+	Use:   "remove <stack-name>",
+	Short: "Remove a stack and its environment-scoped files",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return stack.Remove(args[0])
+	},
+}
+
+var migrateStackCmd = &cobra.Command{
+	Use:   "migrate <stack-name>",
+	Short: "Move files from flat environment folders into a stack's folders",
+	Long:  "Moves the files stored directly in environments/<env>/ into environments/<env>/<stack>/ for every environment, converting a single-stack project layout to a per-stack one.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		moved, err := stack.Migrate(args[0])
+		if err != nil {
+			return err
+		}
+
+		if len(moved) == 0 {
+			fmt.Println("No files to migrate")
+			return nil
+		}
+
+		envNames := make([]string, 0, len(moved))
+		for envName := range moved {
+			envNames = append(envNames, envName)
+		}
+		sort.Strings(envNames)
+		for _, envName := range envNames {
+			for _, file := range moved[envName] {
+				fmt.Printf("  %s: %s -> %s/%s\n", envName, file, args[0], file)
+			}
+		}
+		fmt.Printf("✓ Migrated files into stack '%s'\n", args[0])
+		return nil
+	},
+}
+
+var orderStackCmd = &cobra.Command{
+	Use:   "order",
+	Short: "Print the order in which stacks must be deployed",
+	Long:  "Scans stack templates for exports, Fn::ImportValue references and local nested stack templates, and prints a deployment order in which each stack follows the stacks it imports from. Without --env every environment is checked.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		envName, _ := cmd.Flags().GetString("env")
+
+		envNames := []string{envName}
+		if envName == "" {
+			envs, err := environment.ListEnvironments()
+			if err != nil {
+				return err
+			}
+			envNames = []string{""}
+			if len(envs) > 0 {
+				envNames = make([]string, 0, len(envs))
+				for name := range envs {
+					envNames = append(envNames, name)
+				}
+				sort.Strings(envNames)
+			}
+		}
+
+		for _, name := range envNames {
+			graph, err := stack.BuildGraph(name)
+			if err != nil {
+				return err
+			}
+			if err := printDeploymentOrder(graph); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+func printDeploymentOrder(graph *stack.Graph) error {
+	order, err := graph.Order()
+	if err != nil {
+		if graph.Environment != "" {
+			return fmt.Errorf("environment '%s': %w", graph.Environment, err)
+		}
+		return err
+	}
+
+	if graph.Environment != "" {
+		fmt.Printf("Deployment order for environment '%s':\n", graph.Environment)
+	} else {
+		fmt.Println("Deployment order:")
+	}
+	for i, name := range order {
+		if dependencies := graph.Dependencies[name]; len(dependencies) > 0 {
+			fmt.Printf("  %d. %s (imports from %s)\n", i+1, name, strings.Join(dependencies, ", "))
+		} else {
+			fmt.Printf("  %d. %s\n", i+1, name)
+		}
+	}
+
+	for _, imp := range graph.Dangling {
+		fmt.Printf("  ⚠ %s imports '%s', which no stack in the project exports\n", imp.Stack, imp.Name)
+	}
+	for _, export := range graph.DuplicateExports {
+		fmt.Printf("  ⚠ %s exports '%s', which another stack also exports\n", export.Stack, export.Name)
+	}
+	for _, path := range graph.MissingTemplates {
+		fmt.Printf("  ⚠ nested stack template not found: %s\n", path)
+	}
+	return nil
+}
+
+func init() {
+	addStackCmd.Flags().String("template", "", "Path to the stack's CloudFormation template")
+	orderStackCmd.Flags().String("env", "", "Environment to resolve export and import names for")
+
+	addStackCmd.Flags().String("stack-name-pattern", "", "Deployed stack name pattern using {project}, {stack} and {env} (default \"{project}-{stack}-{env}\")")
+
+	stackCmd.AddCommand(addStackCmd)
+	stackCmd.AddCommand(listStackCmd)
+	stackCmd.AddCommand(removeStackCmd)
+	stackCmd.AddCommand(migrateStackCmd)
+	stackCmd.AddCommand(orderStackCmd)
+}
@@ -0,0 +1,300 @@
+package adopt
+
+import (
+	"cfn-init/internal"
+	"cfn-init/internal/bootstrap"
+	"cfn-init/internal/classify"
+	"cfn-init/internal/document"
+	"cfn-init/internal/environment"
+	"cfn-init/internal/stack"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// maxScanFileSize skips files too large to be hand-written project files.
+const maxScanFileSize = 5 * 1024 * 1024
+
+// KnownEnvironments are environment names recognised in file and folder names.
+var KnownEnvironments = []string{
+	"dev", "development", "test", "qa", "int", "integration", "uat",
+	"stage", "staging", "stg", "preprod", "perf", "sandbox", "prod", "production",
+}
+
+var (
+	scanExtensions = map[string]bool{
+		".json":     true,
+		".yaml":     true,
+		".yml":      true,
+		".template": true,
+	}
+	skippedDirs = map[string]bool{
+		".git":                 true,
+		"node_modules":         true,
+		"vendor":               true,
+		environment.ProjectDir: true,
+	}
+	nameSeparators    = regexp.MustCompile(`[-_.]`)
+	invalidStackChars = regexp.MustCompile(`[^A-Za-z0-9-]+`)
+)
+
+// File is a file found while scanning, with its detected role.
+type File struct {
+	Path        string
+	Kind        classify.Kind
+	Environment string
+}
+
+// PlannedStack is a stack the adopted project will reference.
+type PlannedStack struct {
+	Name     string
+	Template string
+}
+
+// PlannedEnvironment is an environment the adopted project will contain.
+type PlannedEnvironment struct {
+	Name            string
+	Profile         string
+	ParametersFiles []string
+	TagsFiles       []string
+	GitSyncFiles    []string
+}
+
+// Plan is the proposed cfn-project layout for an existing repository.
+type Plan struct {
+	Root         string
+	ProjectName  string
+	Stacks       []PlannedStack
+	Environments []PlannedEnvironment
+	// Unassigned lists environment files whose environment could not be inferred.
+	Unassigned []File
+}
+
+// Scan walks a repository and proposes a project layout. Files are
+// classified by content, and environments are inferred from file and folder
+// names such as params/prod.json or tags-staging.yaml.
+func Scan(root, projectName string, extraEnvironments []string) (*Plan, error) {
+	if _, err := os.Stat(filepath.Join(root, environment.ProjectDir)); err == nil {
+		return nil, fmt.Errorf("cfn-project directory already exists at %s", filepath.Join(root, environment.ProjectDir))
+	}
+
+	envNames := make(map[string]bool)
+	for _, name := range append(append([]string{}, KnownEnvironments...), extraEnvironments...) {
+		envNames[strings.ToLower(name)] = true
+	}
+
+	files, err := scanFiles(root, envNames)
+	if err != nil {
+		return nil, err
+	}
+
+	if projectName == "" {
+		absRoot, err := filepath.Abs(root)
+		if err != nil {
+			return nil, err
+		}
+		projectName = filepath.Base(absRoot)
+	}
+	plan := &Plan{Root: root, ProjectName: projectName}
+
+	nested := nestedTemplates(root, files)
+	environments := make(map[string]*PlannedEnvironment)
+	usedStackNames := make(map[string]bool)
+
+	for _, file := range files {
+		switch file.Kind {
+		case classify.KindTemplate:
+			if nested[file.Path] {
+				continue
+			}
+			name := uniqueStackName(file.Path, usedStackNames)
+			plan.Stacks = append(plan.Stacks, PlannedStack{Name: name, Template: file.Path})
+		case classify.KindParameters, classify.KindTags, classify.KindGitSync:
+			if file.Environment == "" {
+				plan.Unassigned = append(plan.Unassigned, file)
+				continue
+			}
+			env, ok := environments[file.Environment]
+			if !ok {
+				env = &PlannedEnvironment{Name: file.Environment, Profile: file.Environment}
+				environments[file.Environment] = env
+			}
+			switch file.Kind {
+			case classify.KindParameters:
+				env.ParametersFiles = append(env.ParametersFiles, file.Path)
+			case classify.KindTags:
+				env.TagsFiles = append(env.TagsFiles, file.Path)
+			default:
+				env.GitSyncFiles = append(env.GitSyncFiles, file.Path)
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(environments) {
+		plan.Environments = append(plan.Environments, *environments[name])
+	}
+	return plan, nil
+}
+
+// Apply creates the project described by the plan. Templates are referenced
+// from their current location and environment files are copied into the
+// project, renaming files that share a name, such as params/prod.json and
+// tags/prod.json, with a numeric suffix. When a step fails, the new project
+// folder is removed so adopt can be run again.
+func Apply(plan *Plan) (err error) {
+	projectDir := filepath.Join(plan.Root, environment.ProjectDir)
+	if _, statErr := os.Stat(projectDir); statErr == nil {
+		return fmt.Errorf("cfn-project directory already exists at %s", projectDir)
+	}
+	defer func() {
+		if err != nil {
+			os.RemoveAll(projectDir)
+		}
+	}()
+	if err := bootstrap.Init(plan.ProjectName, plan.Root); err != nil {
+		return err
+	}
+
+	originalDir, _ := os.Getwd()
+	if err := os.Chdir(plan.Root); err != nil {
+		return fmt.Errorf("failed to change directory: %w", err)
+	}
+	defer os.Chdir(originalDir)
+
+	for _, s := range plan.Stacks {
+		if err := stack.Add(s.Name, s.Template, ""); err != nil {
+			return fmt.Errorf("failed to add stack '%s': %w", s.Name, err)
+		}
+		fmt.Printf("✓ Added stack '%s' (%s)\n", s.Name, s.Template)
+	}
+
+	if len(plan.Environments) == 0 {
+		return nil
+	}
+	envs := make([]internal.EnvironmentConfig, 0, len(plan.Environments))
+	for _, env := range plan.Environments {
+		envs = append(envs, internal.EnvironmentConfig{
+			Name:            env.Name,
+			AwsProfile:      env.Profile,
+			ParametersFiles: env.ParametersFiles,
+			TagsFiles:       env.TagsFiles,
+			GitSyncFiles:    env.GitSyncFiles,
+			OnConflict:      environment.OnConflictRename,
+		})
+	}
+	return environment.AddEnvironments(envs)
+}
+
+func scanFiles(root string, envNames map[string]bool) ([]File, error) {
+	var files []File
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != root && (skippedDirs[entry.Name()] || strings.HasPrefix(entry.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !scanExtensions[strings.ToLower(filepath.Ext(path))] {
+			return nil
+		}
+		if info, err := entry.Info(); err != nil || info.Size() > maxScanFileSize {
+			return nil
+		}
+
+		kind, err := classify.File(path)
+		if err != nil || kind == classify.KindUnknown {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		files = append(files, File{Path: rel, Kind: kind, Environment: inferEnvironment(rel, envNames)})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", root, err)
+	}
+	return files, nil
+}
+
+// inferEnvironment finds an environment name in a file's name, such as
+// "prod" in params-prod.json, or in one of its parent folders.
+func inferEnvironment(relPath string, envNames map[string]bool) string {
+	base := strings.TrimSuffix(filepath.Base(relPath), filepath.Ext(relPath))
+	tokens := nameSeparators.Split(strings.ToLower(base), -1)
+	for i := len(tokens) - 1; i >= 0; i-- {
+		if envNames[tokens[i]] {
+			return tokens[i]
+		}
+	}
+
+	dirs := strings.Split(filepath.ToSlash(filepath.Dir(relPath)), "/")
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if name := strings.ToLower(dirs[i]); envNames[name] {
+			return name
+		}
+	}
+	return ""
+}
+
+// nestedTemplates returns the templates referenced by another template as a
+// local nested stack, which are deployed with their parent rather than alone.
+func nestedTemplates(root string, files []File) map[string]bool {
+	nested := make(map[string]bool)
+	for _, file := range files {
+		if file.Kind != classify.KindTemplate {
+			continue
+		}
+		doc, err := document.ReadFile(filepath.Join(root, filepath.FromSlash(file.Path)))
+		if err != nil {
+			continue
+		}
+		body, _ := doc.(map[string]any)
+		resources, _ := body["Resources"].(map[string]any)
+		for _, raw := range resources {
+			resource, _ := raw.(map[string]any)
+			if resource["Type"] != "AWS::CloudFormation::Stack" {
+				continue
+			}
+			properties, _ := resource["Properties"].(map[string]any)
+			if url, ok := properties["TemplateURL"].(string); ok && !strings.Contains(url, "://") {
+				nested[filepath.ToSlash(filepath.Join(filepath.Dir(file.Path), url))] = true
+			}
+		}
+	}
+	return nested
+}
+
+func uniqueStackName(templatePath string, used map[string]bool) string {
+	base := strings.TrimSuffix(filepath.Base(templatePath), filepath.Ext(templatePath))
+	name := strings.Trim(invalidStackChars.ReplaceAllString(base, "-"), "-")
+	if name == "" || !((name[0] >= 'a' && name[0] <= 'z') || (name[0] >= 'A' && name[0] <= 'Z')) {
+		name = "stack-" + name
+	}
+
+	candidate := name
+	for i := 2; used[candidate]; i++ {
+		candidate = fmt.Sprintf("%s-%d", name, i)
+	}
+	used[candidate] = true
+	return candidate
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
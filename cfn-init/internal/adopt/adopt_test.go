@@ -0,0 +1,136 @@
+package adopt
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cfn-init/internal/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeRepoFile(t *testing.T, root, path, content string) {
+	t.Helper()
+	fullPath := filepath.Join(root, filepath.FromSlash(path))
+	assert.NoError(t, os.MkdirAll(filepath.Dir(fullPath), 0755))
+	assert.NoError(t, os.WriteFile(fullPath, []byte(content), 0644))
+}
+
+func setupRepo(t *testing.T) string {
+	root := t.TempDir()
+	writeRepoFile(t, root, "templates/app.yaml", "Resources:\n  Child:\n    Type: AWS::CloudFormation::Stack\n    Properties:\n      TemplateURL: nested/child.yaml\n")
+	writeRepoFile(t, root, "templates/nested/child.yaml", "Resources:\n  Bucket:\n    Type: AWS::S3::Bucket\n")
+	writeRepoFile(t, root, "params/prod.json", `[{"ParameterKey": "Env", "ParameterValue": "prod"}]`)
+	writeRepoFile(t, root, "tags-staging.json", `[{"Key": "Team", "Value": "core"}]`)
+	writeRepoFile(t, root, "deploy-prod.yaml", "template-file-path: templates/app.yaml\n")
+	writeRepoFile(t, root, "shared.json", `{"Env": "any"}`)
+	writeRepoFile(t, root, "node_modules/pkg/prod.json", `[{"ParameterKey": "Ignored", "ParameterValue": "x"}]`)
+	writeRepoFile(t, root, "package.json", `{"name": "repo", "dependencies": {}}`)
+	return root
+}
+
+func TestScan(t *testing.T) {
+	root := setupRepo(t)
+
+	plan, err := Scan(root, "my-project", nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "my-project", plan.ProjectName)
+	assert.Equal(t, []PlannedStack{{Name: "app", Template: "templates/app.yaml"}}, plan.Stacks)
+	assert.Equal(t, []PlannedEnvironment{
+		{Name: "prod", Profile: "prod", ParametersFiles: []string{"params/prod.json"}, GitSyncFiles: []string{"deploy-prod.yaml"}},
+		{Name: "staging", Profile: "staging", TagsFiles: []string{"tags-staging.json"}},
+	}, plan.Environments)
+	assert.Len(t, plan.Unassigned, 1)
+	assert.Equal(t, "shared.json", plan.Unassigned[0].Path)
+}
+
+func TestScan_ExtraEnvironments(t *testing.T) {
+	root := t.TempDir()
+	writeRepoFile(t, root, "params.blue.json", `[{"ParameterKey": "Env", "ParameterValue": "blue"}]`)
+
+	plan, err := Scan(root, "", []string{"blue"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Base(root), plan.ProjectName)
+	assert.Equal(t, "blue", plan.Environments[0].Name)
+}
+
+func TestScan_ProjectExists(t *testing.T) {
+	root := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "cfn-project"), 0755))
+
+	_, err := Scan(root, "", nil)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "already exists")
+}
+
+func TestApply(t *testing.T) {
+	root := setupRepo(t)
+	plan, err := Scan(root, "my-project", nil)
+	assert.NoError(t, err)
+
+	err = Apply(plan)
+
+	assert.NoError(t, err)
+	projectConfig, err := config.ReadConfigFile(root)
+	assert.NoError(t, err)
+	assert.Equal(t, "templates/app.yaml", projectConfig.Stacks["app"].Template)
+	assert.Contains(t, projectConfig.Environments, "prod")
+	assert.FileExists(t, filepath.Join(root, "cfn-project", "environments", "prod", "prod.json"))
+	assert.FileExists(t, filepath.Join(root, "cfn-project", "environments", "staging", "tags-staging.json"))
+}
+
+func TestApply_SameFileNames(t *testing.T) {
+	root := t.TempDir()
+	writeRepoFile(t, root, "params/prod.json", `[{"ParameterKey": "Env", "ParameterValue": "prod"}]`)
+	writeRepoFile(t, root, "tags/prod.json", `[{"Key": "Team", "Value": "core"}]`)
+	plan, err := Scan(root, "my-project", nil)
+	assert.NoError(t, err)
+
+	err = Apply(plan)
+
+	assert.NoError(t, err)
+	envDir := filepath.Join(root, "cfn-project", "environments", "prod")
+	assert.FileExists(t, filepath.Join(envDir, "prod.json"))
+	assert.FileExists(t, filepath.Join(envDir, "prod-2.json"))
+	projectConfig, err := config.ReadConfigFile(root)
+	assert.NoError(t, err)
+	assert.Equal(t, []config.TrackedFile{
+		{Path: "prod.json", Category: "parameters", Source: "params/prod.json"},
+		{Path: "prod-2.json", Category: "tags", Source: "tags/prod.json"},
+	}, clearHashes(projectConfig.Environments["prod"].Files))
+}
+
+func TestApply_FailureRemovesProject(t *testing.T) {
+	root := t.TempDir()
+	writeRepoFile(t, root, "params/prod.json", `[{"ParameterKey": "Env", "ParameterValue": "prod"}]`)
+	writeRepoFile(t, root, "deploy-prod.yaml", "template-file-path: missing.yaml\n")
+	plan, err := Scan(root, "my-project", nil)
+	assert.NoError(t, err)
+
+	err = Apply(plan)
+
+	assert.Error(t, err)
+	assert.NoDirExists(t, filepath.Join(root, "cfn-project"))
+	_, err = Scan(root, "my-project", nil)
+	assert.NoError(t, err)
+}
+
+func clearHashes(files []config.TrackedFile) []config.TrackedFile {
+	for i := range files {
+		files[i].SHA256 = ""
+	}
+	return files
+}
+
+func TestInferEnvironment(t *testing.T) {
+	envNames := map[string]bool{"prod": true, "dev": true}
+
+	assert.Equal(t, "prod", inferEnvironment("params-prod.json", envNames))
+	assert.Equal(t, "prod", inferEnvironment("params/prod.json", envNames))
+	assert.Equal(t, "dev", inferEnvironment("dev/params.json", envNames))
+	assert.Equal(t, "", inferEnvironment("params/production-like.json", envNames))
+}
@@ -22,6 +22,11 @@ func Init(projectName, basePath string) error {
 	}
 	fmt.Printf("✓ Created %s\n", projectDir)
 
+	// Files in defaults/ apply to every environment
+	if err := os.MkdirAll(filepath.Join(projectDir, "defaults"), permissions.ProjectDir); err != nil {
+		return fmt.Errorf("failed to create defaults directory: %w", err)
+	}
+
 	projectConfig := generateInitialConfig(projectName)
 
 	if err := config.WriteConfigFile(basePath, projectConfig); err != nil {
@@ -16,6 +16,7 @@ func TestInit_Success(t *testing.T) {
 
 	projectDir := filepath.Join(tempDir, "cfn-project")
 	assert.DirExists(t, projectDir)
+	assert.DirExists(t, filepath.Join(projectDir, "defaults"))
 
 	configFile := filepath.Join(projectDir, "cfn-config.json")
 	assert.FileExists(t, configFile)
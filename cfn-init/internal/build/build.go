@@ -0,0 +1,188 @@
+package build
+
+import (
+	"cfn-init/internal/config"
+	"cfn-init/internal/environment"
+	"cfn-init/internal/gitsync"
+	"cfn-init/internal/parameters"
+	"cfn-init/internal/permissions"
+	"cfn-init/internal/tags"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Build output names.
+const (
+	DefaultOutputDir = "build"
+	ParametersFile   = "parameters.json"
+	TagsFile         = "tags.json"
+	ManifestFile     = "manifest.json"
+)
+
+// FileHash records the SHA-256 of a build input or output.
+type FileHash struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+}
+
+// Manifest describes one environment's build. Input paths are relative to
+// the repository root and output paths to the environment's build folder.
+// It holds no timestamps so that identical inputs give identical manifests.
+type Manifest struct {
+	Environment string     `json:"environment"`
+	Inputs      []FileHash `json:"inputs"`
+	Outputs     []FileHash `json:"outputs"`
+}
+
+// Environment renders an environment's parameters, tags and GitSync
+// deployment file into outputDir/<env>, replacing any previous build, and
+// writes a manifest of the inputs and outputs. With stacks in the project,
+// each stack is built into its own subfolder. templatePath is only needed
+// for projects without stacks whose environments have no GitSync file
+// naming their template. Encrypted values are decrypted with key, and the
+// build fails when there is none. The previous build is only replaced once
+// the new one has been written in full.
+func Environment(envName, templatePath, outputDir string, key []byte) (*Manifest, error) {
+	configFile, err := config.ReadConfigFile(".")
+	if err != nil {
+		return nil, err
+	}
+	if _, exists := configFile.Environments[envName]; !exists {
+		return nil, fmt.Errorf("environment '%s' not found", envName)
+	}
+
+	inputs := map[string]bool{filepath.Join(environment.ProjectDir, environment.ConfigFile): true}
+	outputs := make(map[string][]byte)
+	secret := false
+
+	scopes := make([]string, 0, len(configFile.Stacks))
+	for name := range configFile.Stacks {
+		scopes = append(scopes, name)
+	}
+	sort.Strings(scopes)
+	if len(scopes) == 0 {
+		scopes = []string{""}
+	}
+
+	for _, stackName := range scopes {
+		values, decrypted, err := environment.RenderPlainValues(envName, stackName, key)
+		if err != nil {
+			return nil, err
+		}
+		secret = secret || len(decrypted) > 0
+		deployment, settingsPath, err := environment.RenderGitSync(envName, stackName, templatePath, values)
+		if err != nil {
+			return nil, fmt.Errorf("environment '%s': %w", envName, err)
+		}
+
+		for _, file := range values.Files {
+			inputs[filepath.FromSlash(file)] = true
+		}
+		if settingsPath != "" {
+			inputs[settingsPath] = true
+		}
+		inputs[filepath.FromSlash(deployment.TemplateFilePath)] = true
+
+		params, err := parameters.MarshalCLI(values.ParameterValues())
+		if err != nil {
+			return nil, err
+		}
+		tagData, err := tags.Marshal(tags.FromMap(values.TagValues()))
+		if err != nil {
+			return nil, err
+		}
+		deploymentData, err := gitsync.Marshal(deployment, environment.DefaultGitSyncFile)
+		if err != nil {
+			return nil, err
+		}
+		outputs[filepath.Join(stackName, ParametersFile)] = params
+		outputs[filepath.Join(stackName, TagsFile)] = tagData
+		outputs[filepath.Join(stackName, environment.DefaultGitSyncFile)] = deploymentData
+	}
+
+	manifest := &Manifest{Environment: envName, Inputs: []FileHash{}, Outputs: []FileHash{}}
+	for _, path := range sortedKeys(inputs) {
+		hash, err := hashFile(path)
+		if err != nil {
+			return nil, err
+		}
+		manifest.Inputs = append(manifest.Inputs, FileHash{Path: filepath.ToSlash(path), SHA256: hash})
+	}
+	for _, name := range sortedKeys(outputs) {
+		sum := sha256.Sum256(outputs[name])
+		manifest.Outputs = append(manifest.Outputs, FileHash{Path: filepath.ToSlash(name), SHA256: hex.EncodeToString(sum[:])})
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	outputs[ManifestFile] = append(data, '\n')
+
+	if err := writeBuild(filepath.Join(outputDir, envName), outputs, secret); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// writeBuild writes the outputs into a temporary folder next to envDir and
+// renames it into place, so a failed build leaves the previous one intact.
+// Builds holding decrypted values are readable only by the current user.
+func writeBuild(envDir string, outputs map[string][]byte, secret bool) error {
+	dirMode, fileMode := permissions.ProjectDir, permissions.ConfigFile
+	if secret {
+		dirMode, fileMode = permissions.SecretDir, permissions.SecretFile
+	}
+
+	if err := os.MkdirAll(filepath.Dir(envDir), permissions.ProjectDir); err != nil {
+		return fmt.Errorf("failed to create build directory: %w", err)
+	}
+	tempDir, err := os.MkdirTemp(filepath.Dir(envDir), "."+filepath.Base(envDir)+"-")
+	if err != nil {
+		return fmt.Errorf("failed to create build directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+	if err := os.Chmod(tempDir, dirMode); err != nil {
+		return fmt.Errorf("failed to create build directory: %w", err)
+	}
+
+	for _, name := range sortedKeys(outputs) {
+		path := filepath.Join(tempDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), dirMode); err != nil {
+			return fmt.Errorf("failed to create build directory: %w", err)
+		}
+		if err := os.WriteFile(path, outputs[name], fileMode); err != nil {
+			return fmt.Errorf("failed to write '%s': %w", filepath.Join(envDir, name), err)
+		}
+	}
+
+	if err := os.RemoveAll(envDir); err != nil {
+		return fmt.Errorf("failed to clean build directory: %w", err)
+	}
+	if err := os.Rename(tempDir, envDir); err != nil {
+		return fmt.Errorf("failed to replace build directory: %w", err)
+	}
+	return nil
+}
+
+func hashFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read build input: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,128 @@
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cfn-init/internal"
+	"cfn-init/internal/bootstrap"
+	"cfn-init/internal/encryption"
+	"cfn-init/internal/environment"
+	"cfn-init/internal/permissions"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupTestProject(t *testing.T) {
+	tempDir := t.TempDir()
+	err := bootstrap.Init("test-project", tempDir)
+	assert.NoError(t, err)
+
+	originalDir, _ := os.Getwd()
+	err = os.Chdir(tempDir)
+	assert.NoError(t, err)
+
+	t.Cleanup(func() {
+		os.Chdir(originalDir)
+	})
+
+	err = environment.AddEnvironments([]internal.EnvironmentConfig{{Name: "dev", AwsProfile: "dev-profile", Region: "us-east-1"}})
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile("app.yaml", []byte("Resources: {}\n"), 0644))
+
+	envDir := filepath.Join(environment.ProjectDir, environment.EnvironmentsDir, "dev")
+	assert.NoError(t, os.WriteFile(filepath.Join(envDir, "params.json"), []byte(`{"Bucket": "${project.name}-${env.name}"}`), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(envDir, "tags.json"), []byte(`{"team": "core"}`), 0644))
+}
+
+func TestEnvironment_WritesArtifacts(t *testing.T) {
+	setupTestProject(t)
+
+	manifest, err := Environment("dev", "app.yaml", DefaultOutputDir, nil)
+
+	assert.NoError(t, err)
+	params, _ := os.ReadFile(filepath.Join("build", "dev", ParametersFile))
+	assert.JSONEq(t, `[{"ParameterKey": "Bucket", "ParameterValue": "test-project-dev"}]`, string(params))
+	tagData, _ := os.ReadFile(filepath.Join("build", "dev", TagsFile))
+	assert.JSONEq(t, `[{"Key": "team", "Value": "core"}]`, string(tagData))
+	deployment, _ := os.ReadFile(filepath.Join("build", "dev", environment.DefaultGitSyncFile))
+	assert.Equal(t, "template-file-path: app.yaml\nparameters:\n  Bucket: test-project-dev\ntags:\n  team: core\n", string(deployment))
+
+	var inputs []string
+	for _, input := range manifest.Inputs {
+		inputs = append(inputs, input.Path)
+	}
+	assert.Equal(t, []string{
+		"app.yaml",
+		"cfn-project/cfn-config.json",
+		"cfn-project/environments/dev/params.json",
+		"cfn-project/environments/dev/tags.json",
+	}, inputs)
+	assert.Len(t, manifest.Outputs, 3)
+	assert.Equal(t, "gitsync-deployment.yaml", manifest.Outputs[0].Path)
+	assert.FileExists(t, filepath.Join("build", "dev", ManifestFile))
+}
+
+func TestEnvironment_Deterministic(t *testing.T) {
+	setupTestProject(t)
+
+	_, err := Environment("dev", "app.yaml", DefaultOutputDir, nil)
+	assert.NoError(t, err)
+	first := readDir(t, filepath.Join("build", "dev"))
+
+	assert.NoError(t, os.WriteFile(filepath.Join("build", "dev", "stale.json"), []byte("{}"), 0644))
+	_, err = Environment("dev", "app.yaml", DefaultOutputDir, nil)
+	assert.NoError(t, err)
+
+	assert.Equal(t, first, readDir(t, filepath.Join("build", "dev")))
+}
+
+func TestEnvironment_RequiresTemplate(t *testing.T) {
+	setupTestProject(t)
+
+	_, err := Environment("dev", "", DefaultOutputDir, nil)
+	assert.EqualError(t, err, "environment 'dev': template path or stack name is required")
+
+	_, err = Environment("missing", "app.yaml", DefaultOutputDir, nil)
+	assert.EqualError(t, err, "environment 'missing' not found")
+}
+
+func TestEnvironment_Encrypted(t *testing.T) {
+	setupTestProject(t)
+	key := make([]byte, 32)
+	envDir := filepath.Join(environment.ProjectDir, environment.EnvironmentsDir, "dev")
+	_, err := Environment("dev", "app.yaml", DefaultOutputDir, nil)
+	assert.NoError(t, err)
+	previous := readDir(t, filepath.Join("build", "dev"))
+
+	password, err := encryption.Encrypt(key, "Password", "hunter2")
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(envDir, "secrets.json"), []byte(`{"Password": "`+password+`"}`), 0644))
+
+	// A failed build leaves the previous one in place
+	_, err = Environment("dev", "app.yaml", DefaultOutputDir, nil)
+	assert.EqualError(t, err, "parameter 'Password' in environments/dev/secrets.json is encrypted and no key file was found to decrypt it")
+	assert.Equal(t, previous, readDir(t, filepath.Join("build", "dev")))
+
+	_, err = Environment("dev", "app.yaml", DefaultOutputDir, key)
+	assert.NoError(t, err)
+	params, _ := os.ReadFile(filepath.Join("build", "dev", ParametersFile))
+	assert.JSONEq(t, `[{"ParameterKey": "Bucket", "ParameterValue": "test-project-dev"}, {"ParameterKey": "Password", "ParameterValue": "hunter2"}]`, string(params))
+	info, err := os.Stat(filepath.Join("build", "dev", ParametersFile))
+	assert.NoError(t, err)
+	assert.Equal(t, permissions.SecretFile, info.Mode().Perm())
+}
+
+func readDir(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files := make(map[string]string)
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	for _, entry := range entries {
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		assert.NoError(t, err)
+		files[entry.Name()] = string(data)
+	}
+	return files
+}
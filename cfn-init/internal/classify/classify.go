@@ -0,0 +1,170 @@
+package classify
+
+import (
+	"cfn-init/internal/document"
+	"cfn-init/internal/gitsync"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// Kind is the role of a file within a CloudFormation project.
+type Kind string
+
+const (
+	KindTemplate    Kind = "template"
+	KindParameters  Kind = "parameters"
+	KindTags        Kind = "tags"
+	KindGitSync     Kind = "gitsync"
+	KindStackPolicy Kind = "stack-policy"
+	KindUnknown     Kind = "unknown"
+)
+
+// File reads a file and classifies it by its content.
+func File(path string) (Kind, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return KindUnknown, err
+	}
+	return Content(data, filepath.Base(path)), nil
+}
+
+// Content classifies file content as a template, parameters, tags, GitSync
+// deployment or stack policy file. Flat key/value maps are used for both parameters and tags,
+// so a tag or tags word in the file name decides between the two.
+func Content(data []byte, fileName string) Kind {
+	doc, err := document.Decode(data)
+	if err != nil {
+		return KindUnknown
+	}
+
+	switch content := doc.(type) {
+	case []any:
+		return classifyList(content)
+	case map[string]any:
+		return classifyObject(content, fileName)
+	default:
+		return KindUnknown
+	}
+}
+
+func classifyList(entries []any) Kind {
+	if len(entries) == 0 {
+		return KindUnknown
+	}
+
+	var kind Kind
+	for _, raw := range entries {
+		entry, ok := raw.(map[string]any)
+		if !ok {
+			return KindUnknown
+		}
+		var entryKind Kind
+		switch {
+		case has(entry, "ParameterKey"):
+			entryKind = KindParameters
+		case has(entry, "Key") && has(entry, "Value"):
+			entryKind = KindTags
+		default:
+			return KindUnknown
+		}
+		if kind != "" && kind != entryKind {
+			return KindUnknown
+		}
+		kind = entryKind
+	}
+	return kind
+}
+
+func classifyObject(content map[string]any, fileName string) Kind {
+	if resources, ok := content["Resources"].(map[string]any); ok && isResourcesSection(resources) {
+		return KindTemplate
+	}
+	if has(content, "AWSTemplateFormatVersion") {
+		return KindTemplate
+	}
+
+	for _, key := range gitsync.Keys {
+		if has(content, key) {
+			return KindGitSync
+		}
+	}
+	if isStackPolicy(content) {
+		return KindStackPolicy
+	}
+
+	if len(content) == 0 {
+		return KindUnknown
+	}
+	for _, value := range content {
+		switch value.(type) {
+		case string, bool, int, int64, float64, document.Number, []any:
+		default:
+			return KindUnknown
+		}
+	}
+	if namesTags(fileName) {
+		return KindTags
+	}
+	return KindParameters
+}
+
+// namesTags reports whether a word of the file name, split at punctuation
+// and camelCase boundaries, is tag or tags, so names such as tags-prod.yaml
+// or prodTags.json match but stage.json or vintage.yaml do not.
+func namesTags(fileName string) bool {
+	name := strings.TrimSuffix(fileName, filepath.Ext(fileName))
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		start := 0
+		runes := []rune(word)
+		for i := 1; i <= len(runes); i++ {
+			if i < len(runes) && !(unicode.IsUpper(runes[i]) && unicode.IsLower(runes[i-1])) {
+				continue
+			}
+			part := strings.ToLower(string(runes[start:i]))
+			if part == "tag" || part == "tags" {
+				return true
+			}
+			start = i
+		}
+	}
+	return false
+}
+
+func isResourcesSection(resources map[string]any) bool {
+	for _, raw := range resources {
+		resource, ok := raw.(map[string]any)
+		if !ok {
+			return false
+		}
+		if _, ok := resource["Type"].(string); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// isStackPolicy reports whether content is a Statement list of policy
+// statements, which would otherwise pass for a flat parameters map.
+func isStackPolicy(content map[string]any) bool {
+	statements, ok := content["Statement"].([]any)
+	if !ok || len(statements) == 0 {
+		return false
+	}
+	for _, raw := range statements {
+		statement, ok := raw.(map[string]any)
+		if !ok || !has(statement, "Effect") {
+			return false
+		}
+	}
+	return true
+}
+
+func has(object map[string]any, key string) bool {
+	_, ok := object[key]
+	return ok
+}
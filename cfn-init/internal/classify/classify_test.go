@@ -0,0 +1,37 @@
+package classify
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContent(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileName string
+		content  string
+		expected Kind
+	}{
+		{"template", "stack.yaml", "Resources:\n  Bucket:\n    Type: AWS::S3::Bucket\n", KindTemplate},
+		{"template with version only", "stack.json", `{"AWSTemplateFormatVersion": "2010-09-09"}`, KindTemplate},
+		{"cli parameters", "prod.json", `[{"ParameterKey": "Env", "ParameterValue": "prod"}]`, KindParameters},
+		{"tag list", "prod.json", `[{"Key": "Team", "Value": "core"}]`, KindTags},
+		{"gitsync", "deploy.yaml", "template-file-path: stack.yaml\nparameters:\n  Env: prod\n", KindGitSync},
+		{"parameter map", "params-prod.yaml", "Env: prod\n", KindParameters},
+		{"tag map", "tags-prod.yaml", "Team: core\n", KindTags},
+		{"camel case tag map", "prodTags.json", `{"Team": "core"}`, KindTags},
+		{"singular tag map", "prod.tag.yaml", "Team: core\n", KindTags},
+		{"tag inside a word", "stage.yaml", "Env: stage\n", KindParameters},
+		{"tag inside a word", "vintage-settings.json", `{"Env": "prod"}`, KindParameters},
+		{"stack policy", "policy.json", `{"Statement": [{"Effect": "Deny", "Action": "Update:Replace", "Principal": "*", "Resource": "*"}]}`, KindStackPolicy},
+		{"nested object", "config.json", `{"settings": {"a": 1}}`, KindUnknown},
+		{"mixed list", "mixed.json", `[{"ParameterKey": "A"}, {"Key": "B", "Value": "c"}]`, KindUnknown},
+		{"invalid", "broken.json", `{"unterminated`, KindUnknown},
+		{"scalar", "value.yaml", "just text", KindUnknown},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, Content([]byte(test.content), test.fileName), test.name)
+	}
+}
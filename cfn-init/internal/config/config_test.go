@@ -74,3 +74,53 @@ func TestWriteConfigFile_InvalidPath(t *testing.T) {
 	err := WriteConfigFile("/nonexistent/path", config)
 	assert.Error(t, err)
 }
+
+func TestStackName(t *testing.T) {
+	stack := Stack{Name: "network"}
+	assert.Equal(t, "app-network-prod", stack.StackName("app", "prod"))
+
+	stack.StackNamePattern = "{env}-{project}-net"
+	assert.Equal(t, "prod-app-net", stack.StackName("app", "prod"))
+}
+
+func TestResolveEnvironment(t *testing.T) {
+	cfg := &ProjectConfig{Environments: map[string]Environment{
+		"prod":    {Name: "prod", Profile: "prod-profile", Region: "us-east-1"},
+		"prod-eu": {Name: "prod-eu", Region: "eu-west-1", Extends: "prod"},
+		"prod-de": {Name: "prod-de", Extends: "prod-eu"},
+	}}
+
+	chain, err := cfg.InheritanceChain("prod-de")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"prod-de", "prod-eu", "prod"}, chain)
+
+	env, err := cfg.ResolveEnvironment("prod-de")
+	assert.NoError(t, err)
+	assert.Equal(t, "prod-profile", env.Profile)
+	assert.Equal(t, "eu-west-1", env.Region)
+
+	assert.Equal(t, []string{"prod-eu"}, cfg.Extenders("prod"))
+}
+
+func TestInheritanceChain_Cycle(t *testing.T) {
+	cfg := &ProjectConfig{Environments: map[string]Environment{
+		"a": {Name: "a", Extends: "b"},
+		"b": {Name: "b", Extends: "a"},
+	}}
+
+	_, err := cfg.InheritanceChain("a")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "environment inheritance cycle: a -> b -> a")
+}
+
+func TestInheritanceChain_UnknownParent(t *testing.T) {
+	cfg := &ProjectConfig{Environments: map[string]Environment{
+		"a": {Name: "a", Extends: "missing"},
+	}}
+
+	_, err := cfg.InheritanceChain("a")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "extends unknown environment 'missing'")
+}
@@ -7,6 +7,32 @@ type ProjectConfig struct {
 	Version      string                 `json:"version"`
 	Project      ProjectInfo            `json:"project"`
 	Environments map[string]Environment `json:"environments"`
+	Stacks       map[string]Stack       `json:"stacks,omitempty"`
+	Policies     []Policy               `json:"policies,omitempty"`
+	Defaults     *Defaults              `json:"defaults,omitempty"`
+	// AllowedEnvVars lists the OS environment variables that environment
+	// files may reference as ${os.NAME}.
+	AllowedEnvVars []string `json:"allowedEnvVars,omitempty"`
+	// MaxFileSize is the largest file in bytes that may be copied into an
+	// environment. Zero uses the default of 10 MiB.
+	MaxFileSize int64 `json:"maxFileSize,omitempty"`
+	// FileCategories declares project-specific kinds of environment files
+	// by name, next to the built-in parameters, tags, gitsync and
+	// stack-policy.
+	FileCategories map[string]FileCategory `json:"fileCategories,omitempty"`
+}
+
+// FileCategory is a project-specific kind of environment file.
+type FileCategory struct {
+	// Extensions lists the allowed file extensions, such as .json. Empty
+	// allows .json, .yaml and .yml.
+	Extensions []string `json:"extensions,omitempty"`
+	// Subfolder is the folder, inside the environment or stack folder, that
+	// files of the category are added to.
+	Subfolder string `json:"subfolder,omitempty"`
+	// Schema is a JSON Schema, relative to the repository root, that files
+	// of the category must match.
+	Schema string `json:"schema,omitempty"`
 }
 
 // ProjectInfo contains basic metadata about the CloudFormation project.
@@ -19,4 +45,76 @@ type ProjectInfo struct {
 type Environment struct {
 	Name    string `json:"name"`
 	Profile string `json:"profile"`
+	Region  string `json:"region,omitempty"`
+	// SourceOfTruth is the environment file, relative to the environment
+	// folder, that the environment's other files are derived from.
+	SourceOfTruth string `json:"sourceOfTruth,omitempty"`
+	// Extends names an environment whose profile, region, parameters and
+	// tags this environment inherits unless it sets its own.
+	Extends string `json:"extends,omitempty"`
+	// Files records the category of the environment's files and where
+	// added files came from, in the order they were added. Parameters files
+	// are merged in this order: later files win.
+	Files []TrackedFile `json:"files,omitempty"`
+}
+
+// TrackedFile records the role of a file in an environment folder and, for
+// files added from elsewhere, its original so that a copy can be checked and
+// resynced.
+type TrackedFile struct {
+	// Path is the file, relative to the environment folder.
+	Path string `json:"path"`
+	// Category is the file's role: parameters, tags, gitsync, stack-policy
+	// or one of the project's file categories.
+	Category string `json:"category,omitempty"`
+	// Source is the original, relative to the repository root when inside
+	// it. Files created in the environment folder have none.
+	Source string `json:"source,omitempty"`
+	// SHA256 is the hash of the content at the last copy or sync. Only
+	// copies have one.
+	SHA256 string `json:"sha256,omitempty"`
+	// CopySHA256 is the hash of the copy after the tool last rewrote it,
+	// for example to encrypt values, when it differs from the source.
+	CopySHA256 string `json:"copySha256,omitempty"`
+	// Mode is how the file was added: copy (the default), symlink, or
+	// reference for files read from their source without a copy.
+	Mode string `json:"mode,omitempty"`
+}
+
+// Stack represents a CloudFormation stack deployed to every environment of the project.
+type Stack struct {
+	Name             string `json:"name"`
+	Template         string `json:"template"`
+	StackNamePattern string `json:"stackNamePattern,omitempty"`
+}
+
+// Defaults holds parameter and tag values that apply to every environment
+// unless an environment file overrides them.
+type Defaults struct {
+	Parameters map[string]string `json:"parameters,omitempty"`
+	Tags       map[string]string `json:"tags,omitempty"`
+}
+
+// Policy is a project-wide rule checked against the environments it applies to.
+// Every rule field that is set must hold for the policy to pass.
+type Policy struct {
+	Name     string `json:"name"`
+	Severity string `json:"severity,omitempty"`
+	// Environments are glob patterns of the environment names the policy
+	// applies to. An empty list applies the policy to every environment.
+	Environments []string `json:"environments,omitempty"`
+	// RequireTags lists tag keys every environment must set.
+	RequireTags []string `json:"requireTags,omitempty"`
+	// RequireParameters lists parameters every environment must set.
+	RequireParameters []string `json:"requireParameters,omitempty"`
+	// ForbidLiteralParameters lists glob patterns of parameter names whose
+	// values must be dynamic references or encrypted rather than literal values.
+	ForbidLiteralParameters []string `json:"forbidLiteralParameters,omitempty"`
+	// DistinctProfiles requires the environments to use different AWS profiles.
+	DistinctProfiles bool `json:"distinctProfiles,omitempty"`
+	// AllowedRegions lists the regions the environments may deploy to.
+	AllowedRegions []string `json:"allowedRegions,omitempty"`
+	// ForbidParameterConflicts reports parameters files of the same
+	// environment that set one parameter to different values.
+	ForbidParameterConflicts bool `json:"forbidParameterConflicts,omitempty"`
 }
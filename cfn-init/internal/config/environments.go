@@ -0,0 +1,65 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// InheritanceChain returns the environment followed by the environments it
+// extends, nearest first. It fails when an environment extends an unknown
+// environment or the chain contains a cycle.
+func (c *ProjectConfig) InheritanceChain(name string) ([]string, error) {
+	if _, exists := c.Environments[name]; !exists {
+		return nil, fmt.Errorf("environment '%s' not found", name)
+	}
+
+	chain := []string{name}
+	seen := map[string]bool{name: true}
+	for current := c.Environments[name]; current.Extends != ""; {
+		parent := current.Extends
+		if seen[parent] {
+			return nil, fmt.Errorf("environment inheritance cycle: %s -> %s", strings.Join(chain, " -> "), parent)
+		}
+		next, exists := c.Environments[parent]
+		if !exists {
+			return nil, fmt.Errorf("environment '%s' extends unknown environment '%s'", chain[len(chain)-1], parent)
+		}
+		chain = append(chain, parent)
+		seen[parent] = true
+		current = next
+	}
+	return chain, nil
+}
+
+// ResolveEnvironment returns an environment with the profile and region it
+// inherits from the environments it extends filled in.
+func (c *ProjectConfig) ResolveEnvironment(name string) (Environment, error) {
+	chain, err := c.InheritanceChain(name)
+	if err != nil {
+		return Environment{}, err
+	}
+
+	env := c.Environments[name]
+	for _, ancestor := range chain[1:] {
+		if env.Profile == "" {
+			env.Profile = c.Environments[ancestor].Profile
+		}
+		if env.Region == "" {
+			env.Region = c.Environments[ancestor].Region
+		}
+	}
+	return env, nil
+}
+
+// Extenders returns the environments that directly extend name, sorted.
+func (c *ProjectConfig) Extenders(name string) []string {
+	var names []string
+	for envName, env := range c.Environments {
+		if env.Extends == name {
+			names = append(names, envName)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,20 @@
+package config
+
+import "strings"
+
+// DefaultStackNamePattern is used when a stack does not declare its own pattern.
+const DefaultStackNamePattern = "{project}-{stack}-{env}"
+
+// StackName expands the stack's name pattern for a project and environment.
+func (s Stack) StackName(projectName, envName string) string {
+	pattern := s.StackNamePattern
+	if pattern == "" {
+		pattern = DefaultStackNamePattern
+	}
+	replacer := strings.NewReplacer(
+		"{project}", projectName,
+		"{stack}", s.Name,
+		"{env}", envName,
+	)
+	return replacer.Replace(pattern)
+}
@@ -0,0 +1,222 @@
+package diagram
+
+import (
+	"cfn-init/internal/config"
+	"cfn-init/internal/environment"
+	"cfn-init/internal/stack"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Output formats supported by Render.
+const (
+	FormatDOT     = "dot"
+	FormatMermaid = "mermaid"
+)
+
+// NodeKind distinguishes the kinds of nodes in a project diagram.
+type NodeKind string
+
+const (
+	NodeEnvironment NodeKind = "environment"
+	NodeStack       NodeKind = "stack"
+	NodeFile        NodeKind = "file"
+)
+
+// Node is a single element of the project diagram.
+type Node struct {
+	ID    string
+	Label string
+	Kind  NodeKind
+}
+
+// Edge connects two nodes, with an optional label.
+type Edge struct {
+	From  string
+	To    string
+	Label string
+}
+
+// Diagram holds the project's environments, stacks and files and how they relate.
+type Diagram struct {
+	Nodes []Node
+	Edges []Edge
+}
+
+// Build collects the project's stacks, environments, environment files and
+// cross-stack import edges. Import edges are resolved for envName when given.
+func Build(envName string) (*Diagram, error) {
+	if _, err := os.Stat(filepath.Join(environment.ProjectDir, environment.ConfigFile)); os.IsNotExist(err) {
+		return nil, fmt.Errorf("project directory not found")
+	}
+	configFile, err := config.ReadConfigFile(".")
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Diagram{}
+	envNames := sortedKeys(configFile.Environments)
+	stackNames := sortedKeys(configFile.Stacks)
+
+	for _, name := range stackNames {
+		d.Nodes = append(d.Nodes, Node{ID: nodeID("stack", name), Label: name, Kind: NodeStack})
+	}
+
+	for _, name := range envNames {
+		envID := nodeID("env", name)
+		d.Nodes = append(d.Nodes, Node{ID: envID, Label: name, Kind: NodeEnvironment})
+		for _, stackName := range stackNames {
+			d.Edges = append(d.Edges, Edge{From: nodeID("stack", stackName), To: envID})
+		}
+
+		files, err := environmentFiles(name)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			fileID := nodeID("file", name, file)
+			d.Nodes = append(d.Nodes, Node{ID: fileID, Label: file, Kind: NodeFile})
+			d.Edges = append(d.Edges, Edge{From: envID, To: fileID})
+		}
+	}
+
+	if len(stackNames) > 0 {
+		graph, err := stack.BuildGraph(envName)
+		if err != nil {
+			return nil, err
+		}
+		// Merge the imports between the same pair of stacks into one edge
+		importEdges := make(map[string]int)
+		for _, imp := range graph.Imports {
+			if imp.Provider == "" || imp.Provider == imp.Stack {
+				continue
+			}
+			key := imp.Stack + "->" + imp.Provider
+			if i, ok := importEdges[key]; ok {
+				d.Edges[i].Label += ", " + imp.Name
+				continue
+			}
+			importEdges[key] = len(d.Edges)
+			d.Edges = append(d.Edges, Edge{
+				From:  nodeID("stack", imp.Stack),
+				To:    nodeID("stack", imp.Provider),
+				Label: imp.Name,
+			})
+		}
+	}
+
+	return d, nil
+}
+
+// Render writes the diagram in Graphviz DOT or Mermaid flowchart syntax.
+func Render(d *Diagram, format string) (string, error) {
+	switch format {
+	case FormatDOT:
+		return renderDOT(d), nil
+	case FormatMermaid:
+		return renderMermaid(d), nil
+	default:
+		return "", fmt.Errorf("unsupported format '%s' (only dot, mermaid allowed)", format)
+	}
+}
+
+func renderDOT(d *Diagram) string {
+	shapes := map[NodeKind]string{
+		NodeEnvironment: "ellipse",
+		NodeStack:       "box3d",
+		NodeFile:        "note",
+	}
+
+	var b strings.Builder
+	b.WriteString("digraph project {\n")
+	b.WriteString("  rankdir=LR;\n")
+	for _, node := range d.Nodes {
+		fmt.Fprintf(&b, "  %s [label=%q, shape=%s];\n", node.ID, node.Label, shapes[node.Kind])
+	}
+	for _, edge := range d.Edges {
+		if edge.Label != "" {
+			fmt.Fprintf(&b, "  %s -> %s [label=%q, style=dashed];\n", edge.From, edge.To, edge.Label)
+		} else {
+			fmt.Fprintf(&b, "  %s -> %s;\n", edge.From, edge.To)
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func renderMermaid(d *Diagram) string {
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for _, node := range d.Nodes {
+		label := mermaidLabel(node.Label)
+		switch node.Kind {
+		case NodeEnvironment:
+			fmt.Fprintf(&b, "  %s([%s])\n", node.ID, label)
+		case NodeStack:
+			fmt.Fprintf(&b, "  %s[[%s]]\n", node.ID, label)
+		default:
+			fmt.Fprintf(&b, "  %s[/%s/]\n", node.ID, label)
+		}
+	}
+	for _, edge := range d.Edges {
+		if edge.Label != "" {
+			fmt.Fprintf(&b, "  %s -.->|%s| %s\n", edge.From, mermaidLabel(edge.Label), edge.To)
+		} else {
+			fmt.Fprintf(&b, "  %s --> %s\n", edge.From, edge.To)
+		}
+	}
+	return b.String()
+}
+
+// environmentFiles lists an environment's files, including stack subfolders
+// and files referenced in place, as slash-separated paths relative to the
+// environment folder.
+func environmentFiles(envName string) ([]string, error) {
+	listed, err := environment.ListFiles(envName)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0, len(listed))
+	for _, file := range listed {
+		files = append(files, file.Path)
+	}
+	return files, nil
+}
+
+// nodeID builds an identifier that Mermaid and DOT accept unquoted. Bytes
+// other than letters and digits are escaped as _ and two hex digits and
+// parts are joined with __, so distinct names such as a-b and a_b never
+// share an identifier.
+func nodeID(kind string, parts ...string) string {
+	escaped := make([]string, len(parts))
+	for i, part := range parts {
+		var b strings.Builder
+		for j := 0; j < len(part); j++ {
+			c := part[j]
+			if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' {
+				b.WriteByte(c)
+			} else {
+				fmt.Fprintf(&b, "_%02x", c)
+			}
+		}
+		escaped[i] = b.String()
+	}
+	return kind + "_" + strings.Join(escaped, "__")
+}
+
+// mermaidLabel quotes a label so characters such as slashes and dots are kept verbatim.
+func mermaidLabel(label string) string {
+	return `"` + strings.ReplaceAll(label, `"`, "#quot;") + `"`
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,114 @@
+package diagram
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cfn-init/internal"
+	"cfn-init/internal/bootstrap"
+	"cfn-init/internal/environment"
+	"cfn-init/internal/stack"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupTestProject(t *testing.T) string {
+	tempDir := t.TempDir()
+	err := bootstrap.Init("test-project", tempDir)
+	assert.NoError(t, err)
+
+	originalDir, _ := os.Getwd()
+	err = os.Chdir(tempDir)
+	assert.NoError(t, err)
+
+	t.Cleanup(func() {
+		os.Chdir(originalDir)
+	})
+
+	return filepath.Join(tempDir, "cfn-project")
+}
+
+func setupDiagramProject(t *testing.T) {
+	projectDir := setupTestProject(t)
+
+	network := "Resources: {}\nOutputs:\n  VpcId:\n    Value: vpc-1\n    Export:\n      Name: shared-vpc\n"
+	app := "Resources:\n  Queue:\n    Type: AWS::SQS::Queue\n    Properties:\n      VpcId: !ImportValue shared-vpc\n"
+	assert.NoError(t, os.WriteFile("network.yaml", []byte(network), 0644))
+	assert.NoError(t, os.WriteFile("app.yaml", []byte(app), 0644))
+	assert.NoError(t, stack.Add("network", "network.yaml", ""))
+	assert.NoError(t, stack.Add("app", "app.yaml", ""))
+
+	err := environment.AddEnvironments([]internal.EnvironmentConfig{{Name: "prod", AwsProfile: "prod-profile"}})
+	assert.NoError(t, err)
+	paramsPath := filepath.Join(projectDir, "environments", "prod", "params.json")
+	assert.NoError(t, os.WriteFile(paramsPath, []byte("{}"), 0644))
+}
+
+func TestBuild(t *testing.T) {
+	setupDiagramProject(t)
+
+	d, err := Build("")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Node{
+		{ID: "stack_app", Label: "app", Kind: NodeStack},
+		{ID: "stack_network", Label: "network", Kind: NodeStack},
+		{ID: "env_prod", Label: "prod", Kind: NodeEnvironment},
+		{ID: "file_prod__params_2ejson", Label: "params.json", Kind: NodeFile},
+	}, d.Nodes)
+	assert.Contains(t, d.Edges, Edge{From: "env_prod", To: "file_prod__params_2ejson"})
+	assert.Contains(t, d.Edges, Edge{From: "stack_network", To: "env_prod"})
+	assert.Contains(t, d.Edges, Edge{From: "stack_app", To: "stack_network", Label: "shared-vpc"})
+}
+
+func TestRender_DOT(t *testing.T) {
+	d := &Diagram{
+		Nodes: []Node{{ID: "stack_app", Label: "app", Kind: NodeStack}, {ID: "env_prod", Label: "prod", Kind: NodeEnvironment}},
+		Edges: []Edge{{From: "stack_app", To: "env_prod"}},
+	}
+
+	output, err := Render(d, FormatDOT)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "digraph project {\n  rankdir=LR;\n  stack_app [label=\"app\", shape=box3d];\n  env_prod [label=\"prod\", shape=ellipse];\n  stack_app -> env_prod;\n}\n", output)
+}
+
+func TestRender_Mermaid(t *testing.T) {
+	d := &Diagram{
+		Nodes: []Node{{ID: "file_prod_a_json", Label: "a.json", Kind: NodeFile}},
+		Edges: []Edge{{From: "stack_app", To: "stack_network", Label: "shared-vpc"}},
+	}
+
+	output, err := Render(d, FormatMermaid)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "flowchart LR\n  file_prod_a_json[/\"a.json\"/]\n  stack_app -.->|\"shared-vpc\"| stack_network\n", output)
+}
+
+func TestRender_UnsupportedFormat(t *testing.T) {
+	_, err := Render(&Diagram{}, "png")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported format")
+}
+
+func TestNodeID_Distinct(t *testing.T) {
+	assert.Equal(t, "file_prod__params_2ejson", nodeID("file", "prod", "params.json"))
+	assert.NotEqual(t, nodeID("stack", "a-b"), nodeID("stack", "a_b"))
+	assert.NotEqual(t, nodeID("file", "a", "b_c"), nodeID("file", "a_b", "c"))
+	assert.NotEqual(t, nodeID("file", "é"), nodeID("file", "\xc3a9"))
+}
+
+func TestBuild_ReferencedFiles(t *testing.T) {
+	setupDiagramProject(t)
+	assert.NoError(t, os.WriteFile("tags.json", []byte(`{"Team": "core"}`), 0644))
+	_, err := environment.AddFilesWithOptions("prod", nil, []string{"tags.json"}, nil, environment.FileOptions{Mode: environment.ModeReference})
+	assert.NoError(t, err)
+
+	d, err := Build("")
+
+	assert.NoError(t, err)
+	assert.Contains(t, d.Nodes, Node{ID: "file_prod__tags_2ejson", Label: "tags.json", Kind: NodeFile})
+	assert.Contains(t, d.Edges, Edge{From: "env_prod", To: "file_prod__tags_2ejson"})
+}
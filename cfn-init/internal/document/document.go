@@ -0,0 +1,203 @@
+package document
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// shortFormTags maps CloudFormation YAML short-form tags to their full function names.
+var shortFormTags = map[string]string{
+	"!Ref":         "Ref",
+	"!Condition":   "Condition",
+	"!And":         "Fn::And",
+	"!Base64":      "Fn::Base64",
+	"!Cidr":        "Fn::Cidr",
+	"!Equals":      "Fn::Equals",
+	"!FindInMap":   "Fn::FindInMap",
+	"!GetAtt":      "Fn::GetAtt",
+	"!GetAZs":      "Fn::GetAZs",
+	"!If":          "Fn::If",
+	"!ImportValue": "Fn::ImportValue",
+	"!Join":        "Fn::Join",
+	"!Not":         "Fn::Not",
+	"!Or":          "Fn::Or",
+	"!Select":      "Fn::Select",
+	"!Split":       "Fn::Split",
+	"!Sub":         "Fn::Sub",
+	"!Transform":   "Fn::Transform",
+}
+
+// Number is a number kept as it is written, so that values such as 1.10 or
+// integers too large for an int64 are passed on unchanged.
+type Number string
+
+// Float64 returns the number as a float64.
+func (n Number) Float64() (float64, bool) {
+	value, err := strconv.ParseFloat(string(n), 64)
+	return value, err == nil
+}
+
+// MarshalJSON writes the number as written, or as a string when it is only
+// valid in YAML, such as 0x1F or .inf.
+func (n Number) MarshalJSON() ([]byte, error) {
+	if json.Valid([]byte(n)) {
+		return []byte(n), nil
+	}
+	return json.Marshal(string(n))
+}
+
+// MarshalYAML writes the number as written.
+func (n Number) MarshalYAML() (any, error) {
+	tag := "!!float"
+	if _, err := strconv.ParseInt(strings.ReplaceAll(string(n), "_", ""), 0, 64); err == nil {
+		tag = "!!int"
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: string(n)}, nil
+}
+
+// ReadFile loads a JSON or YAML document from disk.
+func ReadFile(path string) (any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return doc, nil
+}
+
+// Decode parses JSON or YAML content into generic Go values, expanding
+// CloudFormation short-form tags such as !Ref into their long form. Numbers
+// are decoded as a Number and scalars other than booleans and null, such as
+// dates, as the string they are written as.
+func Decode(data []byte) (any, error) {
+	if IsJSON(data) {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		var doc any
+		if err := decoder.Decode(&doc); err != nil {
+			return nil, err
+		}
+		if _, err := decoder.Token(); err != io.EOF {
+			return nil, fmt.Errorf("invalid character after top-level value")
+		}
+		return jsonNumbers(doc), nil
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	if len(root.Content) == 0 {
+		return nil, nil
+	}
+	return convertNode(root.Content[0])
+}
+
+// IsJSON reports whether the content looks like a JSON document.
+func IsJSON(data []byte) bool {
+	trimmed := bytes.TrimSpace(data)
+	return len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[')
+}
+
+func convertNode(node *yaml.Node) (any, error) {
+	if fn, ok := shortFormTags[node.Tag]; ok {
+		value, err := convertTagged(node, fn)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{fn: value}, nil
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+		return convertNode(node.Content[0])
+	case yaml.AliasNode:
+		return convertNode(node.Alias)
+	case yaml.MappingNode:
+		result := make(map[string]any, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			value, err := convertNode(node.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			result[node.Content[i].Value] = value
+		}
+		return result, nil
+	case yaml.SequenceNode:
+		result := make([]any, 0, len(node.Content))
+		for _, child := range node.Content {
+			value, err := convertNode(child)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, value)
+		}
+		return result, nil
+	default:
+		return convertScalar(node)
+	}
+}
+
+// convertScalar keeps a scalar as written, decoding only booleans and null.
+func convertScalar(node *yaml.Node) (any, error) {
+	switch node.ShortTag() {
+	case "!!null":
+		return nil, nil
+	case "!!bool":
+		var value bool
+		if err := node.Decode(&value); err != nil {
+			return nil, err
+		}
+		return value, nil
+	case "!!int", "!!float":
+		return Number(node.Value), nil
+	default:
+		return node.Value, nil
+	}
+}
+
+// jsonNumbers replaces the json.Number values of a decoded JSON document with Number.
+func jsonNumbers(value any) any {
+	switch v := value.(type) {
+	case json.Number:
+		return Number(v)
+	case map[string]any:
+		for key, item := range v {
+			v[key] = jsonNumbers(item)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = jsonNumbers(item)
+		}
+	}
+	return value
+}
+
+func convertTagged(node *yaml.Node, fn string) (any, error) {
+	if node.Kind != yaml.ScalarNode {
+		untagged := *node
+		untagged.Tag = ""
+		return convertNode(&untagged)
+	}
+
+	// !GetAtt accepts the dotted "Resource.Attribute" short form
+	if fn == "Fn::GetAtt" {
+		if resource, attribute, found := strings.Cut(node.Value, "."); found {
+			return []any{resource, attribute}, nil
+		}
+	}
+	return node.Value, nil
+}
@@ -0,0 +1,76 @@
+package document
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestDecode_JSON(t *testing.T) {
+	doc, err := Decode([]byte(`{"Resources": {"Bucket": {"Type": "AWS::S3::Bucket"}}}`))
+
+	assert.NoError(t, err)
+	resources := doc.(map[string]any)["Resources"].(map[string]any)
+	assert.Contains(t, resources, "Bucket")
+}
+
+func TestDecode_YAMLShortForm(t *testing.T) {
+	content := `
+Conditions:
+  IsProd: !Equals [!Ref Env, prod]
+Outputs:
+  Arn:
+    Value: !GetAtt Bucket.Arn
+`
+	doc, err := Decode([]byte(content))
+	assert.NoError(t, err)
+
+	body := doc.(map[string]any)
+	isProd := body["Conditions"].(map[string]any)["IsProd"]
+	assert.Equal(t, map[string]any{"Fn::Equals": []any{map[string]any{"Ref": "Env"}, "prod"}}, isProd)
+
+	arn := body["Outputs"].(map[string]any)["Arn"].(map[string]any)["Value"]
+	assert.Equal(t, map[string]any{"Fn::GetAtt": []any{"Bucket", "Arn"}}, arn)
+}
+
+func TestDecode_ScalarsAsWritten(t *testing.T) {
+	doc, err := Decode([]byte("Date: 2024-01-01\nVersion: 1.10\nBig: 123456789012345678901234\nEnabled: true\nEmpty: null\nName: web\n"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"Date":    "2024-01-01",
+		"Version": Number("1.10"),
+		"Big":     Number("123456789012345678901234"),
+		"Enabled": true,
+		"Empty":   nil,
+		"Name":    "web",
+	}, doc)
+
+	doc, err = Decode([]byte(`{"Version": 1.10, "Big": 123456789012345678901234}`))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"Version": Number("1.10"), "Big": Number("123456789012345678901234")}, doc)
+}
+
+func TestNumber_Marshal(t *testing.T) {
+	data, err := json.Marshal(map[string]any{"Version": Number("1.10"), "Mask": Number("0x1F")})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"Mask":"0x1F","Version":1.10}`, string(data))
+
+	data, err = yaml.Marshal(map[string]any{"Version": Number("1.10"), "Count": Number("3")})
+	assert.NoError(t, err)
+	assert.Equal(t, "Count: 3\nVersion: 1.10\n", string(data))
+}
+
+func TestDecode_InvalidYAML(t *testing.T) {
+	_, err := Decode([]byte("key: [unclosed"))
+	assert.Error(t, err)
+}
+
+func TestIsJSON(t *testing.T) {
+	assert.True(t, IsJSON([]byte(`  {"a": 1}`)))
+	assert.True(t, IsJSON([]byte(`[1, 2]`)))
+	assert.False(t, IsJSON([]byte("a: 1")))
+	assert.False(t, IsJSON([]byte("")))
+}
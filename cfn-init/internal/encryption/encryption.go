@@ -0,0 +1,153 @@
+package encryption
+
+import (
+	"cfn-init/internal/permissions"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// KeyFileEnv names the environment variable that overrides the key file location.
+const KeyFileEnv = "CFN_INIT_KEY_FILE"
+
+const (
+	keySize   = 32
+	nonceSize = 32
+	tagSize   = 16
+)
+
+// encryptedValue matches encrypted values, ENC[AES256_GCM,data:...,iv:...,tag:...,type:str].
+// The format looks like a SOPS value, but files have no sops metadata and the
+// parameter name alone is authenticated, so sops cannot decrypt them.
+var encryptedValue = regexp.MustCompile(`^ENC\[AES256_GCM,data:([A-Za-z0-9+/=]*),iv:([A-Za-z0-9+/=]+),tag:([A-Za-z0-9+/=]+),type:str\]$`)
+
+// IsEncrypted reports whether a value is in the encrypted value format.
+func IsEncrypted(value string) bool {
+	return encryptedValue.MatchString(value)
+}
+
+// Encrypt encrypts a parameter value with AES-256-GCM in the encrypted value
+// format. The parameter name is authenticated with the value, so encrypted
+// values cannot be moved between parameters.
+func Encrypt(key []byte, name, value string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, nonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := gcm.Seal(nil, nonce, []byte(value), []byte(name))
+	data, tag := sealed[:len(sealed)-tagSize], sealed[len(sealed)-tagSize:]
+	return fmt.Sprintf("ENC[AES256_GCM,data:%s,iv:%s,tag:%s,type:str]",
+		base64.StdEncoding.EncodeToString(data),
+		base64.StdEncoding.EncodeToString(nonce),
+		base64.StdEncoding.EncodeToString(tag)), nil
+}
+
+// Decrypt decrypts a value produced by Encrypt for the same parameter name.
+func Decrypt(key []byte, name, value string) (string, error) {
+	match := encryptedValue.FindStringSubmatch(value)
+	if match == nil {
+		return "", fmt.Errorf("parameter '%s' is not an encrypted value", name)
+	}
+	parts := make([][]byte, 3)
+	for i := range parts {
+		decoded, err := base64.StdEncoding.DecodeString(match[i+1])
+		if err != nil {
+			return "", fmt.Errorf("parameter '%s' has a malformed encrypted value: %w", name, err)
+		}
+		parts[i] = decoded
+	}
+	data, nonce, tag := parts[0], parts[1], parts[2]
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(nonce) != nonceSize || len(tag) != tagSize {
+		return "", fmt.Errorf("parameter '%s' has a malformed encrypted value", name)
+	}
+	plain, err := gcm.Open(nil, nonce, append(data, tag...), []byte(name))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt parameter '%s': wrong key or modified value", name)
+	}
+	return string(plain), nil
+}
+
+// DefaultKeyPath returns the key file used for a project: the path in
+// CFN_INIT_KEY_FILE, or ~/.cfn-init/keys/<project>.key. Keys are kept out of
+// the repository so they are never committed with the files they protect.
+func DefaultKeyPath(projectName string) (string, error) {
+	if path := os.Getenv(KeyFileEnv); path != "" {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find home directory: %w", err)
+	}
+	return filepath.Join(home, ".cfn-init", "keys", projectName+".key"), nil
+}
+
+// LoadKey reads a base64 key file. Key files readable by other users are rejected.
+func LoadKey(path string) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("key file '%s' not found", path)
+		}
+		return nil, err
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return nil, fmt.Errorf("key file '%s' is accessible by other users (run chmod 600)", path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(key) != keySize {
+		return nil, fmt.Errorf("key file '%s' does not contain a base64 %d-byte key", path, keySize)
+	}
+	return key, nil
+}
+
+// GenerateKey writes a new random key file. Existing key files are never replaced.
+func GenerateKey(path string) ([]byte, error) {
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("key file '%s' already exists", path)
+	}
+
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), permissions.SecretDir); err != nil {
+		return nil, fmt.Errorf("failed to create key directory: %w", err)
+	}
+	data := base64.StdEncoding.EncodeToString(key) + "\n"
+	if err := os.WriteFile(path, []byte(data), permissions.SecretFile); err != nil {
+		return nil, fmt.Errorf("failed to write key file: %w", err)
+	}
+	return key, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != keySize {
+		return nil, fmt.Errorf("key must be %d bytes", keySize)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCMWithNonceSize(block, nonceSize)
+}
@@ -0,0 +1,82 @@
+package encryption
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testKey() []byte {
+	return []byte("0123456789abcdef0123456789abcdef")
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	encrypted, err := Encrypt(testKey(), "DbPassword", "hunter2")
+
+	assert.NoError(t, err)
+	assert.True(t, IsEncrypted(encrypted))
+	assert.Regexp(t, `^ENC\[AES256_GCM,data:.+,iv:.+,tag:.+,type:str\]$`, encrypted)
+
+	plain, err := Decrypt(testKey(), "DbPassword", encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, "hunter2", plain)
+}
+
+func TestDecrypt_OtherParameter(t *testing.T) {
+	encrypted, err := Encrypt(testKey(), "DbPassword", "hunter2")
+	assert.NoError(t, err)
+
+	_, err = Decrypt(testKey(), "ApiKey", encrypted)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "wrong key or modified value")
+}
+
+func TestDecrypt_WrongKey(t *testing.T) {
+	encrypted, err := Encrypt(testKey(), "DbPassword", "hunter2")
+	assert.NoError(t, err)
+
+	_, err = Decrypt([]byte("fedcba9876543210fedcba9876543210"), "DbPassword", encrypted)
+
+	assert.Error(t, err)
+}
+
+func TestGenerateAndLoadKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys", "project.key")
+
+	key, err := GenerateKey(path)
+	assert.NoError(t, err)
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	loaded, err := LoadKey(path)
+	assert.NoError(t, err)
+	assert.Equal(t, key, loaded)
+
+	_, err = GenerateKey(path)
+	assert.Error(t, err)
+}
+
+func TestLoadKey_SharedKeyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "project.key")
+	_, err := GenerateKey(path)
+	assert.NoError(t, err)
+	assert.NoError(t, os.Chmod(path, 0644))
+
+	_, err = LoadKey(path)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "accessible by other users")
+}
+
+func TestDefaultKeyPath_Environment(t *testing.T) {
+	t.Setenv(KeyFileEnv, "/tmp/custom.key")
+
+	path, err := DefaultKeyPath("project")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "/tmp/custom.key", path)
+}
@@ -0,0 +1,296 @@
+package environment
+
+import (
+	"cfn-init/internal/classify"
+	"cfn-init/internal/config"
+	"cfn-init/internal/jsonschema"
+	"cfn-init/internal/tags"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Categories of environment files, recorded in the config so that tools
+// know each file's role without classifying its content.
+const (
+	CategoryParameters  = string(classify.KindParameters)
+	CategoryTags        = string(classify.KindTags)
+	CategoryGitSync     = string(classify.KindGitSync)
+	CategoryStackPolicy = string(classify.KindStackPolicy)
+)
+
+// Category is a kind of environment file: which files it accepts, where they
+// are placed and how they are checked before they are added.
+type Category struct {
+	Name string
+	// Extensions are the allowed file extensions, in lower case with the dot.
+	Extensions []string
+	// Subfolder is where files are placed inside the environment or stack
+	// folder. Empty places them in the folder itself.
+	Subfolder string
+	// Validate checks a file before it is added to an environment, or its
+	// folder for a stack. Nil accepts any file.
+	Validate func(path, envName, stackName string) error
+}
+
+var defaultExtensions = []string{".json", ".yaml", ".yml"}
+
+// builtinCategories are the categories every project has. They stay in the
+// environment folder, where parameters and tags are read from.
+var builtinCategories = []Category{
+	{Name: CategoryParameters, Extensions: defaultExtensions},
+	{Name: CategoryTags, Extensions: defaultExtensions, Validate: func(path, _, _ string) error {
+		return tags.ValidateFile(path)
+	}},
+	{Name: CategoryGitSync, Extensions: defaultExtensions, Validate: func(path, _, _ string) error {
+		return validateGitSyncFile(path)
+	}},
+	{Name: CategoryStackPolicy, Extensions: []string{".json"}, Validate: validateStackPolicy},
+}
+
+// Categories returns the built-in file categories followed by the ones the
+// project declares in fileCategories, in name order.
+func Categories(configFile *config.ProjectConfig) ([]Category, error) {
+	categories := append([]Category{}, builtinCategories...)
+	for _, name := range sortedKeys(configFile.FileCategories) {
+		category, err := customCategory(configFile, name, configFile.FileCategories[name])
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+	return categories, nil
+}
+
+// lookupCategory returns the category of the given name. Only that
+// category's declaration is checked.
+func lookupCategory(configFile *config.ProjectConfig, name string) (Category, error) {
+	var names []string
+	for _, builtin := range builtinCategories {
+		if builtin.Name == name {
+			return builtin, nil
+		}
+		names = append(names, builtin.Name)
+	}
+	if declared, exists := configFile.FileCategories[name]; exists {
+		return customCategory(configFile, name, declared)
+	}
+	names = append(names, sortedKeys(configFile.FileCategories)...)
+	return Category{}, fmt.Errorf("unknown file category '%s' (only %s allowed)", name, strings.Join(names, ", "))
+}
+
+// categoryOrder returns the category names of files in the order of
+// Categories: the built-in categories first, then the others by name.
+func categoryOrder(files map[string][]string) []string {
+	rank := func(name string) int {
+		if index := slices.IndexFunc(builtinCategories, func(c Category) bool { return c.Name == name }); index >= 0 {
+			return index
+		}
+		return len(builtinCategories)
+	}
+	names := sortedKeys(files)
+	slices.SortStableFunc(names, func(a, b string) int { return rank(a) - rank(b) })
+	return names
+}
+
+// customCategory checks a category declared in the config and builds its
+// validator from its schema, which is read when the first file is validated.
+func customCategory(configFile *config.ProjectConfig, name string, declared config.FileCategory) (Category, error) {
+	for _, builtin := range builtinCategories {
+		if builtin.Name == name {
+			return Category{}, fmt.Errorf("file category '%s' is built in and cannot be redeclared", name)
+		}
+	}
+
+	category := Category{Name: name, Extensions: defaultExtensions}
+	if len(declared.Extensions) > 0 {
+		category.Extensions = nil
+		for _, ext := range declared.Extensions {
+			ext = strings.ToLower(ext)
+			if !strings.HasPrefix(ext, ".") {
+				ext = "." + ext
+			}
+			category.Extensions = append(category.Extensions, ext)
+		}
+	}
+
+	if declared.Subfolder != "" {
+		subfolder := path.Clean(filepath.ToSlash(declared.Subfolder))
+		if path.IsAbs(subfolder) || subfolder == "." || subfolder == ".." || strings.HasPrefix(subfolder, "../") {
+			return Category{}, fmt.Errorf("file category '%s': subfolder '%s' must be a folder inside the environment folder", name, declared.Subfolder)
+		}
+		top, _, _ := strings.Cut(subfolder, "/")
+		if _, exists := configFile.Stacks[top]; exists {
+			return Category{}, fmt.Errorf("file category '%s': subfolder '%s' is the folder of stack '%s'", name, declared.Subfolder, top)
+		}
+		category.Subfolder = filepath.FromSlash(subfolder)
+	}
+
+	if declared.Schema != "" {
+		var schema *jsonschema.Schema
+		category.Validate = func(file, _, _ string) error {
+			if schema == nil {
+				loaded, err := jsonschema.ReadFile(filepath.FromSlash(declared.Schema))
+				if err != nil {
+					return fmt.Errorf("file category '%s': failed to read schema: %w", name, err)
+				}
+				schema = loaded
+			}
+			return schema.ValidateFile(file, declared.Schema)
+		}
+	}
+	return category, nil
+}
+
+// accepts reports whether a file has one of the category's extensions.
+func (c Category) accepts(file string) bool {
+	return slices.Contains(c.Extensions, strings.ToLower(filepath.Ext(file)))
+}
+
+// CategorizedFile is an environment file and the category a migration
+// found for it. Files whose content matches no category have none.
+type CategorizedFile struct {
+	Environment string
+	Path        string
+	Category    string
+}
+
+// MigrateFiles records the category of every file in the folders of an
+// environment, or of every environment when envName is empty, that has none
+// yet. Categories are taken from the file's content, as they were before
+// they were recorded. Files of no known category are reported without one
+// and left unrecorded.
+func MigrateFiles(envName string) ([]CategorizedFile, error) {
+	configFile, envNames, err := trackedEnvironments(envName)
+	if err != nil {
+		return nil, err
+	}
+
+	var migrated []CategorizedFile
+	for _, name := range envNames {
+		env := configFile.Environments[name]
+		for _, scope := range stackScopes(configFile) {
+			entries, err := os.ReadDir(StackPath(name, scope))
+			if err != nil && !os.IsNotExist(err) {
+				return nil, fmt.Errorf("failed to read environment directory: %w", err)
+			}
+			for _, entry := range entries {
+				if entry.IsDir() || !validateFileType(entry.Name()) {
+					continue
+				}
+				relPath := path.Join(scope, entry.Name())
+				if index := trackedIndex(env, relPath); index >= 0 && env.Files[index].Category != "" {
+					continue
+				}
+				kind, err := classify.File(filepath.Join(StackPath(name, scope), entry.Name()))
+				if err != nil {
+					return nil, err
+				}
+				category := fileCategory(kind)
+				if category != "" {
+					recordCategory(&env, relPath, category)
+				}
+				migrated = append(migrated, CategorizedFile{Environment: name, Path: relPath, Category: category})
+			}
+		}
+
+		// Referenced files live outside the folder and are classified at their source
+		for i, tracked := range env.Files {
+			if tracked.Category != "" || tracked.Mode != ModeReference {
+				continue
+			}
+			kind, err := classify.File(filepath.FromSlash(tracked.Source))
+			if err != nil {
+				return nil, fmt.Errorf("referenced file '%s' of environment '%s': %w", tracked.Source, name, err)
+			}
+			env.Files[i].Category = fileCategory(kind)
+			migrated = append(migrated, CategorizedFile{Environment: name, Path: tracked.Path, Category: env.Files[i].Category})
+		}
+		configFile.Environments[name] = env
+	}
+
+	if len(migrated) == 0 {
+		return nil, nil
+	}
+	if err := config.WriteConfigFile(".", configFile); err != nil {
+		return nil, err
+	}
+	return migrated, nil
+}
+
+// recordCategory sets the category of a file in an environment's records,
+// adding a record for files that have none. path is relative to the
+// environment folder.
+func recordCategory(env *config.Environment, path, category string) {
+	if index := trackedIndex(*env, path); index >= 0 {
+		env.Files[index].Category = category
+		return
+	}
+	env.Files = append(env.Files, config.TrackedFile{Path: path, Category: category})
+}
+
+// recordWrittenFiles records the categories of files written into an
+// environment folder. paths are relative to the environment folder.
+func recordWrittenFiles(envName string, categories map[string]string) error {
+	configFile, err := getEnvironmentConfig(envName)
+	if err != nil {
+		return err
+	}
+	env := configFile.Environments[envName]
+	for _, path := range sortedKeys(categories) {
+		recordCategory(&env, path, categories[path])
+	}
+	configFile.Environments[envName] = env
+	return config.WriteConfigFile(".", configFile)
+}
+
+// trackedFilePath returns where a recorded file of an environment is read
+// from: its source when referenced in place, otherwise the environment folder.
+func trackedFilePath(envName string, file config.TrackedFile) string {
+	if file.Mode == ModeReference {
+		return filepath.FromSlash(file.Source)
+	}
+	return filepath.Join(getEnvironmentPath(envName), filepath.FromSlash(file.Path))
+}
+
+// trackedStack returns the stack whose folder holds a recorded file, or
+// nothing for files of the environment folder itself.
+func trackedStack(configFile *config.ProjectConfig, file config.TrackedFile) string {
+	top, _, found := strings.Cut(file.Path, "/")
+	if _, exists := configFile.Stacks[top]; found && exists {
+		return top
+	}
+	return ""
+}
+
+// isValueCategory reports whether files of a category hold parameters or
+// tags and are read when values are resolved.
+func isValueCategory(category string) bool {
+	return category == CategoryParameters || category == CategoryTags || category == CategoryGitSync
+}
+
+func trackedIndex(env config.Environment, path string) int {
+	return slices.IndexFunc(env.Files, func(f config.TrackedFile) bool { return f.Path == path })
+}
+
+// recordedKind returns the kind recorded for a file of an environment, if any.
+func recordedKind(env config.Environment, path string) (classify.Kind, bool) {
+	index := trackedIndex(env, path)
+	if index < 0 || env.Files[index].Category == "" {
+		return "", false
+	}
+	return classify.Kind(env.Files[index].Category), true
+}
+
+// fileCategory returns the category of environment files of a kind, or
+// nothing for kinds that do not belong in an environment folder.
+func fileCategory(kind classify.Kind) string {
+	switch kind {
+	case classify.KindParameters, classify.KindTags, classify.KindGitSync, classify.KindStackPolicy:
+		return string(kind)
+	}
+	return ""
+}
@@ -0,0 +1,172 @@
+package environment
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cfn-init/internal/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrateFiles(t *testing.T) {
+	setupTestProject(t)
+	assert.NoError(t, addEnvironment("dev", "dev-profile", ""))
+	envDir := filepath.Join(ProjectDir, EnvironmentsDir, "dev")
+	assert.NoError(t, os.WriteFile(filepath.Join(envDir, "params.json"), []byte(`{"Env": "dev"}`), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(envDir, "tags.json"), []byte(`[{"Key": "team", "Value": "core"}]`), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(envDir, "notes.json"), []byte(`"not an environment file"`), 0644))
+
+	migrated, err := MigrateFiles("")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []CategorizedFile{
+		{Environment: "dev", Path: "notes.json"},
+		{Environment: "dev", Path: "params.json", Category: CategoryParameters},
+		{Environment: "dev", Path: "tags.json", Category: CategoryTags},
+	}, migrated)
+	configFile, err := config.ReadConfigFile(".")
+	assert.NoError(t, err)
+	assert.Equal(t, []config.TrackedFile{
+		{Path: "params.json", Category: CategoryParameters},
+		{Path: "tags.json", Category: CategoryTags},
+	}, configFile.Environments["dev"].Files)
+
+	migrated, err = MigrateFiles("dev")
+	assert.NoError(t, err)
+	assert.Equal(t, []CategorizedFile{{Environment: "dev", Path: "notes.json"}}, migrated)
+}
+
+func TestAddFiles_RecordsCategories(t *testing.T) {
+	setupTestProject(t)
+	assert.NoError(t, addEnvironment("dev", "dev-profile", ""))
+	assert.NoError(t, os.WriteFile("params.json", []byte(`{"Env": "dev"}`), 0644))
+	assert.NoError(t, os.WriteFile("tags.json", []byte(`[{"Key": "team", "Value": "core"}]`), 0644))
+
+	assert.NoError(t, AddFiles("dev", []string{"params.json"}, []string{"tags.json"}, nil))
+
+	configFile, err := config.ReadConfigFile(".")
+	assert.NoError(t, err)
+	files := configFile.Environments["dev"].Files
+	assert.Len(t, files, 2)
+	assert.Equal(t, CategoryParameters, files[0].Category)
+	assert.Equal(t, CategoryTags, files[1].Category)
+}
+
+func TestResolveValues_RecordedCategoryWins(t *testing.T) {
+	setupTestProject(t)
+	assert.NoError(t, addEnvironment("dev", "dev-profile", ""))
+	envDir := filepath.Join(ProjectDir, EnvironmentsDir, "dev")
+	// A flat map named like a parameters file would be classified as one
+	assert.NoError(t, os.WriteFile(filepath.Join(envDir, "settings.json"), []byte(`{"team": "core"}`), 0644))
+
+	configFile, err := config.ReadConfigFile(".")
+	assert.NoError(t, err)
+	env := configFile.Environments["dev"]
+	env.Files = []config.TrackedFile{{Path: "settings.json", Category: CategoryTags}}
+	configFile.Environments["dev"] = env
+	assert.NoError(t, config.WriteConfigFile(".", configFile))
+
+	values, err := ResolveValues("dev", "")
+
+	assert.NoError(t, err)
+	assert.Empty(t, values.Parameters)
+	assert.Equal(t, map[string]ResolvedValue{"team": {Value: "core", Source: "environments/dev/settings.json"}}, values.Tags)
+}
+
+func setupImportCategory(t *testing.T) string {
+	t.Helper()
+	projectDir := setupTestProject(t)
+	assert.NoError(t, addEnvironment("dev", "dev-profile", ""))
+	assert.NoError(t, os.WriteFile("import.schema.json", []byte(`{
+  "type": "array",
+  "items": {"type": "object", "required": ["LogicalResourceId"]}
+}`), 0644))
+
+	configFile, err := config.ReadConfigFile(".")
+	assert.NoError(t, err)
+	configFile.FileCategories = map[string]config.FileCategory{
+		"resources-to-import": {Extensions: []string{"json"}, Subfolder: "import", Schema: "import.schema.json"},
+	}
+	assert.NoError(t, config.WriteConfigFile(".", configFile))
+	return filepath.Join(projectDir, EnvironmentsDir, "dev")
+}
+
+func TestAddCategoryFiles(t *testing.T) {
+	envDir := setupImportCategory(t)
+	assert.NoError(t, os.WriteFile("bucket.json", []byte(`[{"LogicalResourceId": "Bucket"}]`), 0644))
+
+	added, err := AddCategoryFiles("dev", "resources-to-import", []string{"bucket.json"}, FileOptions{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []AddedFile{{Source: "bucket.json", Path: "import/bucket.json", Status: FileAdded}}, added)
+	assert.FileExists(t, filepath.Join(envDir, "import", "bucket.json"))
+	configFile, err := config.ReadConfigFile(".")
+	assert.NoError(t, err)
+	assert.Equal(t, "resources-to-import", configFile.Environments["dev"].Files[0].Category)
+
+	problems, err := Validate("dev")
+	assert.NoError(t, err)
+	assert.Empty(t, problems)
+
+	assert.NoError(t, os.WriteFile(filepath.Join(envDir, "import", "bucket.json"), []byte(`[{}]`), 0644))
+	problems, err = Validate("dev")
+	assert.NoError(t, err)
+	assert.Len(t, problems, 1)
+	assert.Contains(t, problems[0].Error(), "$[0]: missing required property 'LogicalResourceId'")
+}
+
+func TestAddCategoryFiles_Invalid(t *testing.T) {
+	envDir := setupImportCategory(t)
+	assert.NoError(t, os.WriteFile("bucket.json", []byte(`{"LogicalResourceId": "Bucket"}`), 0644))
+	assert.NoError(t, os.WriteFile("bucket.yaml", []byte(`- LogicalResourceId: Bucket`), 0644))
+
+	_, err := AddCategoryFiles("dev", "resources-to-import", []string{"bucket.json"}, FileOptions{})
+	assert.EqualError(t, err, "file bucket.json does not match schema import.schema.json: $: expected array, found object")
+
+	_, err = AddCategoryFiles("dev", "resources-to-import", []string{"bucket.yaml"}, FileOptions{})
+	assert.EqualError(t, err, "unsupported file type: bucket.yaml (only .json allowed for resources-to-import files)")
+
+	_, err = AddCategoryFiles("dev", "lint", []string{"bucket.json"}, FileOptions{})
+	assert.EqualError(t, err, "unknown file category 'lint' (only parameters, tags, gitsync, stack-policy, resources-to-import allowed)")
+	assert.NoDirExists(t, filepath.Join(envDir, "import"))
+}
+
+func TestCategories_InvalidDeclarations(t *testing.T) {
+	configFile := &config.ProjectConfig{
+		Stacks:         map[string]config.Stack{"network": {Name: "network"}},
+		FileCategories: map[string]config.FileCategory{"tags": {}},
+	}
+	_, err := Categories(configFile)
+	assert.EqualError(t, err, "file category 'tags' is built in and cannot be redeclared")
+
+	configFile.FileCategories = map[string]config.FileCategory{"policy": {Subfolder: "../policies"}}
+	_, err = Categories(configFile)
+	assert.EqualError(t, err, "file category 'policy': subfolder '../policies' must be a folder inside the environment folder")
+
+	configFile.FileCategories = map[string]config.FileCategory{"policy": {Subfolder: "network/policies"}}
+	_, err = Categories(configFile)
+	assert.EqualError(t, err, "file category 'policy': subfolder 'network/policies' is the folder of stack 'network'")
+}
+
+func TestCategories_UnsupportedSchema(t *testing.T) {
+	setupImportCategory(t)
+	assert.NoError(t, os.WriteFile("import.schema.json", []byte(`{"type": "array", "items": {"patternProperties": {"^Id$": {"type": "string"}}}}`), 0644))
+	assert.NoError(t, os.WriteFile("bucket.json", []byte(`[{"LogicalResourceId": "Bucket"}]`), 0644))
+
+	_, err := AddCategoryFiles("dev", "resources-to-import", []string{"bucket.json"}, FileOptions{})
+
+	assert.EqualError(t, err, "file category 'resources-to-import': failed to read schema: schema import.schema.json: unsupported keyword 'patternProperties' at #/items")
+}
+
+func TestAddFiles_IgnoresOtherCategorySchemas(t *testing.T) {
+	envDir := setupImportCategory(t)
+	assert.NoError(t, os.Remove("import.schema.json"))
+	assert.NoError(t, os.WriteFile("params.json", []byte(`{"Env": "dev"}`), 0644))
+
+	_, err := AddFilesWithOptions("dev", []string{"params.json"}, nil, nil, FileOptions{})
+
+	assert.NoError(t, err)
+	assert.FileExists(t, filepath.Join(envDir, "params.json"))
+}
@@ -0,0 +1,202 @@
+package environment
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Ways of resolving a file that would replace another file of the same name.
+const (
+	OnConflictFail      = "fail"
+	OnConflictOverwrite = "overwrite"
+	OnConflictRename    = "rename"
+	OnConflictSkip      = "skip"
+)
+
+// What happened to each file given to AddCategoryFiles.
+const (
+	FileAdded       = "added"
+	FileOverwritten = "overwritten"
+	FileRenamed     = "renamed"
+	FileSkipped     = "skipped"
+)
+
+// FileCollision is a file whose name is already taken in the environment
+// folder, either by an existing file or by another file being added.
+type FileCollision struct {
+	// Source is the file being added
+	Source string
+	// Path is the contested name, relative to the environment folder
+	Path string
+	// With is the other file being added, or the existing file's path
+	With string
+	// Existing is set when With is a file already in the environment
+	Existing bool
+}
+
+// AddedFile reports what happened to one of the files given to
+// AddCategoryFiles. Path is relative to the environment folder and is
+// empty for skipped files. Warning notes a check that could not be made.
+type AddedFile struct {
+	Source  string
+	Path    string
+	Status  string
+	Warning string
+}
+
+// placement is a file to add and the name it gets in the destination folder,
+// including its category's subfolder.
+type placement struct {
+	source   string
+	name     string
+	category string
+	status   string
+	warning  string
+}
+
+// fileGroup is files of one category to add.
+type fileGroup struct {
+	category Category
+	files    []string
+}
+
+// planFiles assigns every file a name in the destination folder before
+// anything is written. Names taken by an existing file or by an earlier file
+// of the batch are resolved with opts.OnConflict, or by asking opts.Prompt
+// when no strategy is set. Without either, or with OnConflictFail, every
+// collision is reported in one error.
+func planFiles(envName, stackName string, opts FileOptions, groups []fileGroup) ([]*placement, error) {
+	configFile, err := getEnvironmentConfig(envName)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]bool)
+	prefix := ""
+	if stackName != "" {
+		prefix = stackName + "/"
+	}
+	for _, tracked := range configFile.Environments[envName].Files {
+		if rel, ok := strings.CutPrefix(tracked.Path, prefix); ok {
+			existing[filepath.FromSlash(rel)] = true
+		}
+	}
+
+	var files []*placement
+	for _, group := range groups {
+		subfolder := group.category.Subfolder
+		if entries, err := os.ReadDir(filepath.Join(StackPath(envName, stackName), subfolder)); err == nil {
+			for _, entry := range entries {
+				existing[filepath.Join(subfolder, entry.Name())] = true
+			}
+		}
+		for _, file := range group.files {
+			if _, err := os.Stat(file); os.IsNotExist(err) {
+				return nil, fmt.Errorf("file not found: %s", file)
+			}
+			if !group.category.accepts(file) {
+				return nil, fmt.Errorf("unsupported file type: %s (only %s allowed for %s files)", file, strings.Join(group.category.Extensions, ", "), group.category.Name)
+			}
+			var warning string
+			if group.category.Name == CategoryStackPolicy {
+				if warning, err = validateAddedStackPolicy(configFile, file, envName, stackName, opts.Template); err != nil {
+					return nil, err
+				}
+			} else if group.category.Validate != nil {
+				if err := group.category.Validate(file, envName, stackName); err != nil {
+					return nil, err
+				}
+			}
+			name := filepath.Join(subfolder, filepath.Base(file))
+			files = append(files, &placement{source: file, name: name, category: group.category.Name, status: FileAdded, warning: warning})
+		}
+	}
+
+	taken := make(map[string]*placement)
+	var failed []string
+	for _, file := range files {
+		collision := FileCollision{Source: file.source, Path: filepath.ToSlash(filepath.Join(stackName, file.name))}
+		earlier, inBatch := taken[file.name]
+		switch {
+		case inBatch:
+			collision.With = earlier.source
+		case existing[file.name]:
+			collision.With = filepath.ToSlash(filepath.Join(StackPath(envName, stackName), file.name))
+			collision.Existing = true
+		default:
+			taken[file.name] = file
+			continue
+		}
+
+		action := opts.OnConflict
+		if action == "" && opts.Prompt != nil {
+			if action, err = opts.Prompt(collision); err != nil {
+				return nil, err
+			}
+		}
+		switch action {
+		case OnConflictOverwrite:
+			if inBatch {
+				earlier.status = FileSkipped
+			} else {
+				file.status = FileOverwritten
+			}
+			taken[file.name] = file
+		case OnConflictRename:
+			file.name = freeName(file.name, func(name string) bool { return existing[name] || taken[name] != nil })
+			file.status = FileRenamed
+			taken[file.name] = file
+		case OnConflictSkip:
+			file.status = FileSkipped
+		case "", OnConflictFail:
+			if collision.Existing {
+				failed = append(failed, fmt.Sprintf("%s would replace existing %s", collision.Source, collision.With))
+			} else {
+				failed = append(failed, fmt.Sprintf("%s and %s are both named %s", collision.With, collision.Source, collision.Path))
+			}
+		default:
+			return nil, fmt.Errorf("invalid conflict resolution '%s' (only fail, overwrite, rename, skip allowed)", action)
+		}
+	}
+	if len(failed) > 0 {
+		return nil, fmt.Errorf("file name collisions in environment '%s': %s (choose how to resolve them with --on-conflict)", envName, strings.Join(failed, "; "))
+	}
+	return files, nil
+}
+
+// freeName returns name with the first numeric suffix, starting at 2, that
+// is not taken: params.json becomes params-2.json.
+func freeName(name string, taken func(string) bool) string {
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s-%d%s", stem, i, ext)
+		if !taken(candidate) {
+			return candidate
+		}
+	}
+}
+
+func addedFiles(stackName string, files []*placement) []AddedFile {
+	added := make([]AddedFile, 0, len(files))
+	for _, file := range files {
+		result := AddedFile{Source: file.source, Status: file.status, Warning: file.warning}
+		if file.status != FileSkipped {
+			result.Path = filepath.ToSlash(filepath.Join(stackName, file.name))
+		}
+		added = append(added, result)
+	}
+	return added
+}
+
+// written returns the files that are not skipped.
+func written(files []*placement) []*placement {
+	var result []*placement
+	for _, file := range files {
+		if file.status != FileSkipped {
+			result = append(result, file)
+		}
+	}
+	return result
+}
@@ -0,0 +1,77 @@
+package environment
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupCollision(t *testing.T) (string, string, string) {
+	t.Helper()
+	projectDir := setupTestProject(t)
+	assert.NoError(t, addEnvironment("dev", "dev-profile", ""))
+	for _, dir := range []string{"a", "b"} {
+		assert.NoError(t, os.MkdirAll(dir, 0755))
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "params.json"), []byte(`{"From": "`+dir+`"}`), 0644))
+	}
+	return filepath.Join(projectDir, EnvironmentsDir, "dev"), filepath.Join("a", "params.json"), filepath.Join("b", "params.json")
+}
+
+func TestAddFilesWithOptions_CollisionsFailUpFront(t *testing.T) {
+	envDir, a, b := setupCollision(t)
+	assert.NoError(t, os.WriteFile("tags.json", []byte(`[{"Key": "team", "Value": "core"}]`), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(envDir, "tags.json"), []byte(`[]`), 0644))
+
+	_, err := AddFilesWithOptions("dev", []string{a, b}, []string{"tags.json"}, nil, FileOptions{})
+
+	assert.EqualError(t, err, "file name collisions in environment 'dev': a/params.json and b/params.json are both named params.json; "+
+		"tags.json would replace existing cfn-project/environments/dev/tags.json (choose how to resolve them with --on-conflict)")
+	assert.NoFileExists(t, filepath.Join(envDir, "params.json"))
+}
+
+func TestAddFilesWithOptions_Rename(t *testing.T) {
+	envDir, a, b := setupCollision(t)
+
+	added, err := AddFilesWithOptions("dev", []string{a, b}, nil, nil, FileOptions{OnConflict: OnConflictRename})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []AddedFile{
+		{Source: a, Path: "params.json", Status: FileAdded},
+		{Source: b, Path: "params-2.json", Status: FileRenamed},
+	}, added)
+	assert.FileExists(t, filepath.Join(envDir, "params-2.json"))
+}
+
+func TestAddFilesWithOptions_OverwriteAndSkip(t *testing.T) {
+	envDir, a, b := setupCollision(t)
+
+	added, err := AddFilesWithOptions("dev", []string{a, b}, nil, nil, FileOptions{OnConflict: OnConflictOverwrite})
+	assert.NoError(t, err)
+	assert.Equal(t, FileSkipped, added[0].Status)
+	assert.Equal(t, AddedFile{Source: b, Path: "params.json", Status: FileAdded}, added[1])
+	data, _ := os.ReadFile(filepath.Join(envDir, "params.json"))
+	assert.Equal(t, `{"From": "b"}`, string(data))
+
+	added, err = AddFilesWithOptions("dev", []string{a}, nil, nil, FileOptions{OnConflict: OnConflictSkip})
+	assert.NoError(t, err)
+	assert.Equal(t, []AddedFile{{Source: a, Status: FileSkipped}}, added)
+	data, _ = os.ReadFile(filepath.Join(envDir, "params.json"))
+	assert.Equal(t, `{"From": "b"}`, string(data))
+}
+
+func TestAddFilesWithOptions_Prompt(t *testing.T) {
+	_, a, _ := setupCollision(t)
+	assert.NoError(t, AddFiles("dev", []string{a}, nil, nil))
+
+	var asked []FileCollision
+	added, err := AddFilesWithOptions("dev", []string{a}, nil, nil, FileOptions{Prompt: func(c FileCollision) (string, error) {
+		asked = append(asked, c)
+		return OnConflictOverwrite, nil
+	}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []FileCollision{{Source: a, Path: "params.json", With: "cfn-project/environments/dev/params.json", Existing: true}}, asked)
+	assert.Equal(t, FileOverwritten, added[0].Status)
+}
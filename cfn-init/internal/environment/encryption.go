@@ -0,0 +1,369 @@
+package environment
+
+import (
+	"cfn-init/internal/classify"
+	"cfn-init/internal/config"
+	"cfn-init/internal/encryption"
+	"cfn-init/internal/parameters"
+	"cfn-init/internal/permissions"
+	"cfn-init/internal/secrets"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// EncryptParameters encrypts parameter values in place in the environment's
+// parameters and GitSync files. Only the named parameters are encrypted, or
+// the ones the secrets scanner flags when no names are given. It returns the
+// parameters encrypted in each file.
+func EncryptParameters(envName string, key []byte, names []string) (map[string][]string, error) {
+	configFile, files, err := environmentParameterFiles(envName)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := make(map[string]map[string]bool)
+	for stackName, stackFiles := range files {
+		if len(names) > 0 {
+			for _, file := range stackFiles {
+				selected[file] = toSet(names)
+			}
+			continue
+		}
+		findings, err := scanFiles(configFile, stackName, stackFiles)
+		if err != nil {
+			return nil, err
+		}
+		for _, finding := range findings {
+			if selected[finding.File] == nil {
+				selected[finding.File] = make(map[string]bool)
+			}
+			selected[finding.File][finding.Parameter] = true
+		}
+	}
+
+	encrypted := make(map[string][]string)
+	var rewritten []string
+	for file, fileNames := range selected {
+		changed, err := parameters.Rewrite(file, func(name, value string) (string, error) {
+			if !fileNames[name] || value == "" || encryption.IsEncrypted(value) || strings.HasPrefix(value, secrets.DynamicReferencePrefix) {
+				return value, nil
+			}
+			encrypted[file] = append(encrypted[file], name)
+			return encryption.Encrypt(key, name, value)
+		})
+		if err != nil {
+			return nil, err
+		}
+		if changed > 0 {
+			rewritten = append(rewritten, file)
+		}
+	}
+	if err := recordRewrites(envName, rewritten); err != nil {
+		return nil, err
+	}
+	return encrypted, nil
+}
+
+// DecryptParameters decrypts every encrypted value in the environment's
+// parameters and GitSync files in place and returns the parameters decrypted
+// in each file.
+func DecryptParameters(envName string, key []byte) (map[string][]string, error) {
+	_, files, err := environmentParameterFiles(envName)
+	if err != nil {
+		return nil, err
+	}
+
+	decrypted := make(map[string][]string)
+	var rewritten []string
+	for _, stackFiles := range files {
+		for _, file := range stackFiles {
+			changed, err := parameters.Rewrite(file, decryptValue(key, func(name string) {
+				decrypted[file] = append(decrypted[file], name)
+			}))
+			if err != nil {
+				return nil, err
+			}
+			if changed > 0 {
+				rewritten = append(rewritten, file)
+			}
+		}
+	}
+	if err := recordRewrites(envName, rewritten); err != nil {
+		return nil, err
+	}
+	return decrypted, nil
+}
+
+// EditParameters decrypts a parameters file into a private temporary file,
+// lets edit change it, and writes it back with the previously encrypted
+// parameters encrypted again. The file is given relative to the environment
+// folder and may be omitted when the environment has a single parameters file.
+// The original file is left unchanged when the edited content is invalid.
+func EditParameters(envName, file string, key []byte, edit func(path string) error) error {
+	path, err := parametersFileToEdit(envName, file)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	wasEncrypted := make(map[string]bool)
+	plain, _, err := parameters.RewriteContent(data, decryptValue(key, func(name string) {
+		wasEncrypted[name] = true
+	}))
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	tempDir, err := os.MkdirTemp("", "cfn-init-edit-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+	tempPath := filepath.Join(tempDir, filepath.Base(path))
+	if err := os.WriteFile(tempPath, plain, permissions.SecretFile); err != nil {
+		return fmt.Errorf("failed to write temporary file: %w", err)
+	}
+
+	if err := edit(tempPath); err != nil {
+		return err
+	}
+
+	edited, err := os.ReadFile(tempPath)
+	if err != nil {
+		return err
+	}
+	if _, _, err := parameters.Parse(edited); err != nil {
+		return fmt.Errorf("edited file is not a valid parameters file: %w", err)
+	}
+	out, _, err := parameters.RewriteContent(edited, func(name, value string) (string, error) {
+		if !wasEncrypted[name] || encryption.IsEncrypted(value) {
+			return value, nil
+		}
+		return encryption.Encrypt(key, name, value)
+	})
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, out, info.Mode().Perm()); err != nil {
+		return err
+	}
+	return recordRewrites(envName, []string{path})
+}
+
+func parametersFileToEdit(envName, file string) (string, error) {
+	_, files, err := environmentParameterFiles(envName)
+	if err != nil {
+		return "", err
+	}
+	if file != "" {
+		relPath, err := environmentRelPath(envName, file)
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(StackPath(envName, ""), relPath), nil
+	}
+
+	var all []string
+	for _, stackFiles := range files {
+		all = append(all, stackFiles...)
+	}
+	if len(all) != 1 {
+		return "", fmt.Errorf("environment '%s' has %d parameters files; name the file to edit", envName, len(all))
+	}
+	return all[0], nil
+}
+
+// RenderDecrypted copies an environment's files into a new private temporary
+// directory with every encrypted value decrypted, for tools that need plain
+// parameter values. The environment folder, with its stack folders, is
+// copied to the top of the directory, files referenced in place are written
+// at their path in the environment and the project's defaults folder is
+// copied into a defaults folder. The caller removes the directory when done.
+func RenderDecrypted(envName string, key []byte) (string, error) {
+	if !projectExists() {
+		return "", fmt.Errorf("project directory not found")
+	}
+	configFile, err := getEnvironmentConfig(envName)
+	if err != nil {
+		return "", err
+	}
+	env := configFile.Environments[envName]
+
+	buildDir, err := os.MkdirTemp("", "cfn-init-"+envName+"-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create build directory: %w", err)
+	}
+	if err := os.Chmod(buildDir, permissions.SecretDir); err != nil {
+		os.RemoveAll(buildDir)
+		return "", err
+	}
+
+	err = renderDecryptedFolder(StackPath(envName, ""), buildDir, key, func(rel string) (classify.Kind, bool) {
+		return recordedKind(env, filepath.ToSlash(rel))
+	})
+	if err == nil {
+		err = renderDecryptedFolder(filepath.Join(ProjectDir, DefaultsDir), filepath.Join(buildDir, DefaultsDir), key, nil)
+	}
+	for _, tracked := range env.Files {
+		if err != nil {
+			break
+		}
+		if tracked.Mode == ModeReference {
+			err = renderDecryptedFile(trackedFilePath(envName, tracked), filepath.Join(buildDir, filepath.FromSlash(tracked.Path)), classify.Kind(tracked.Category), key)
+		}
+	}
+	if err != nil {
+		os.RemoveAll(buildDir)
+		return "", err
+	}
+	return buildDir, nil
+}
+
+// renderDecryptedFolder copies a folder with renderDecryptedFile. recorded
+// returns the recorded kind of a file given relative to the folder; files
+// without one are classified by their content. Missing folders are skipped.
+func renderDecryptedFolder(dir, target string, key []byte, recorded func(rel string) (classify.Kind, bool)) error {
+	return filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir && os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return os.MkdirAll(filepath.Join(target, rel), permissions.SecretDir)
+		}
+		var kind classify.Kind
+		if recorded != nil {
+			kind, _ = recorded(rel)
+		}
+		return renderDecryptedFile(path, filepath.Join(target, rel), kind, key)
+	})
+}
+
+// renderDecryptedFile writes a file to target with its encrypted values
+// decrypted when it is a parameters or GitSync file. An empty kind is
+// classified from the file's content.
+func renderDecryptedFile(path, target string, kind classify.Kind, key []byte) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if kind == "" {
+		kind = classify.Content(data, filepath.Base(path))
+	}
+	if kind == classify.KindParameters || kind == classify.KindGitSync {
+		if data, _, err = parameters.RewriteContent(data, decryptValue(key, nil)); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(target), permissions.SecretDir); err != nil {
+		return err
+	}
+	return os.WriteFile(target, data, permissions.SecretFile)
+}
+
+// RenderPlainValues renders an environment's values like RenderValues with
+// encrypted parameter values decrypted before references are expanded, for
+// output that is deployed. Encrypted values are an error when key is nil. It
+// also returns the names of the decrypted parameters.
+func RenderPlainValues(envName, stackName string, key []byte) (*EffectiveValues, []string, error) {
+	values, err := ResolveValues(envName, stackName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var decrypted []string
+	for _, name := range sortedKeys(values.Parameters) {
+		resolved := values.Parameters[name]
+		if !encryption.IsEncrypted(resolved.Value) {
+			continue
+		}
+		if key == nil {
+			return nil, nil, fmt.Errorf("parameter '%s' in %s is encrypted and no key file was found to decrypt it", name, resolved.Source)
+		}
+		if resolved.Value, err = encryption.Decrypt(key, name, resolved.Value); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", resolved.Source, err)
+		}
+		values.Parameters[name] = resolved
+		decrypted = append(decrypted, name)
+	}
+
+	if err := values.interpolate(); err != nil {
+		return nil, nil, err
+	}
+	return values, decrypted, nil
+}
+
+// decryptValue returns a ValueFunc that decrypts encrypted values and leaves
+// others unchanged, calling decrypted with the name of each decrypted parameter.
+func decryptValue(key []byte, decrypted func(name string)) parameters.ValueFunc {
+	return func(name, value string) (string, error) {
+		if !encryption.IsEncrypted(value) {
+			return value, nil
+		}
+		plain, err := encryption.Decrypt(key, name, value)
+		if err != nil {
+			return "", err
+		}
+		if decrypted != nil {
+			decrypted(name)
+		}
+		return plain, nil
+	}
+}
+
+// environmentParameterFiles returns the parameters and GitSync files of an
+// environment by stack, with "" for the environment folder itself.
+func environmentParameterFiles(envName string) (*config.ProjectConfig, map[string][]string, error) {
+	if !projectExists() {
+		return nil, nil, fmt.Errorf("project directory not found")
+	}
+	configFile, err := getEnvironmentConfig(envName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	files := make(map[string][]string)
+	for _, stackName := range stackScopes(configFile) {
+		stackFiles, err := scopeFiles(configFile, envName, stackName, classify.KindParameters, classify.KindGitSync)
+		if err != nil {
+			return nil, nil, err
+		}
+		files[stackName] = stackFiles
+	}
+	return configFile, files, nil
+}
+
+// stackScopes returns "" for the environment folder followed by the sorted stack names.
+func stackScopes(configFile *config.ProjectConfig) []string {
+	names := make([]string, 0, len(configFile.Stacks))
+	for name := range configFile.Stacks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return append([]string{""}, names...)
+}
+
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
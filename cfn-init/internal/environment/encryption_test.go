@@ -0,0 +1,125 @@
+package environment
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cfn-init/internal/encryption"
+	"cfn-init/internal/parameters"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testKey = []byte("0123456789abcdef0123456789abcdef")
+
+func setupEncryptionProject(t *testing.T) string {
+	setupTestProject(t)
+	assert.NoError(t, addEnvironment("dev", "dev-profile", ""))
+	path := filepath.Join(ProjectDir, EnvironmentsDir, "dev", "params.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte("DbPassword: hunter2\nEnv: dev\n"), 0644))
+	return path
+}
+
+func TestEncryptParameters_ScannerSelection(t *testing.T) {
+	path := setupEncryptionProject(t)
+
+	encrypted, err := EncryptParameters("dev", testKey, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]string{path: {"DbPassword"}}, encrypted)
+	values, _, err := parameters.ReadFile(path)
+	assert.NoError(t, err)
+	assert.True(t, encryption.IsEncrypted(values["DbPassword"]))
+	assert.Equal(t, "dev", values["Env"])
+}
+
+func TestEncryptDecryptParameters(t *testing.T) {
+	path := setupEncryptionProject(t)
+
+	_, err := EncryptParameters("dev", testKey, []string{"Env"})
+	assert.NoError(t, err)
+	decrypted, err := DecryptParameters("dev", testKey)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]string{path: {"Env"}}, decrypted)
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "DbPassword: hunter2\nEnv: dev\n", string(data))
+}
+
+func TestEditParameters(t *testing.T) {
+	path := setupEncryptionProject(t)
+	_, err := EncryptParameters("dev", testKey, nil)
+	assert.NoError(t, err)
+
+	err = EditParameters("dev", "", testKey, func(tempPath string) error {
+		data, err := os.ReadFile(tempPath)
+		assert.NoError(t, err)
+		assert.Equal(t, "DbPassword: hunter2\nEnv: dev\n", string(data))
+		info, err := os.Stat(tempPath)
+		assert.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+		return os.WriteFile(tempPath, []byte("DbPassword: correct-horse\nEnv: dev\n"), 0600)
+	})
+
+	assert.NoError(t, err)
+	values, _, err := parameters.ReadFile(path)
+	assert.NoError(t, err)
+	assert.True(t, encryption.IsEncrypted(values["DbPassword"]))
+	plain, err := encryption.Decrypt(testKey, "DbPassword", values["DbPassword"])
+	assert.NoError(t, err)
+	assert.Equal(t, "correct-horse", plain)
+}
+
+func TestRenderDecrypted(t *testing.T) {
+	setupEncryptionProject(t)
+	_, err := EncryptParameters("dev", testKey, nil)
+	assert.NoError(t, err)
+
+	buildDir, err := RenderDecrypted("dev", testKey)
+	assert.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(buildDir) })
+
+	info, err := os.Stat(buildDir)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0700), info.Mode().Perm())
+	rendered := filepath.Join(buildDir, "params.yaml")
+	info, err = os.Stat(rendered)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	values, _, err := parameters.ReadFile(rendered)
+	assert.NoError(t, err)
+	assert.Equal(t, "hunter2", values["DbPassword"])
+}
+
+func TestRenderDecrypted_StacksReferencesAndDefaults(t *testing.T) {
+	setupEncryptionProject(t)
+	encrypted := func(name string) string {
+		value, err := encryption.Encrypt(testKey, name, "secret-"+name)
+		assert.NoError(t, err)
+		return value
+	}
+	envDir := filepath.Join(ProjectDir, EnvironmentsDir, "dev")
+	assert.NoError(t, os.MkdirAll(filepath.Join(envDir, "app"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(envDir, "app", "params.json"), []byte(`{"AppToken": "`+encrypted("AppToken")+`"}`), 0644))
+	assert.NoError(t, os.MkdirAll(filepath.Join(ProjectDir, DefaultsDir), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(ProjectDir, DefaultsDir, "params.json"), []byte(`{"ApiKey": "`+encrypted("ApiKey")+`"}`), 0644))
+	assert.NoError(t, os.WriteFile("shared.json", []byte(`{"SharedKey": "`+encrypted("SharedKey")+`"}`), 0644))
+	_, err := AddFilesWithOptions("dev", []string{"shared.json"}, nil, nil, FileOptions{Mode: ModeReference})
+	assert.NoError(t, err)
+
+	buildDir, err := RenderDecrypted("dev", testKey)
+	assert.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(buildDir) })
+
+	for path, name := range map[string]string{
+		filepath.Join("app", "params.json"):       "AppToken",
+		filepath.Join(DefaultsDir, "params.json"): "ApiKey",
+		"shared.json": "SharedKey",
+	} {
+		values, _, err := parameters.ReadFile(filepath.Join(buildDir, path))
+		assert.NoError(t, err)
+		assert.Equal(t, "secret-"+name, values[name])
+	}
+}
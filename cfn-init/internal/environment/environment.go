@@ -3,6 +3,8 @@ package environment
 import (
 	"cfn-init/internal"
 	"cfn-init/internal/config"
+	"cfn-init/internal/gitsync"
+	"cfn-init/internal/tags"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -13,6 +15,7 @@ const (
 	ProjectDir      = "cfn-project"
 	ConfigFile      = "cfn-config.json"
 	EnvironmentsDir = "environments"
+	DefaultsDir     = "defaults"
 )
 
 var allowedExtensions = map[string]bool{
@@ -21,7 +24,7 @@ var allowedExtensions = map[string]bool{
 	".yml":  true,
 }
 
-func addEnvironment(envName, awsProfile string) error {
+func addEnvironment(envName, awsProfile, region string) error {
 	if !projectExists() {
 		return fmt.Errorf("project directory not found")
 	}
@@ -44,6 +47,7 @@ func addEnvironment(envName, awsProfile string) error {
 	env := config.Environment{
 		Name:    envName,
 		Profile: awsProfile,
+		Region:  region,
 	}
 
 	configFile.Environments[envName] = env
@@ -56,34 +60,91 @@ func AddEnvironments(environments []internal.EnvironmentConfig) error {
 		return fmt.Errorf("project directory not found")
 	}
 
-	for _, env := range environments {
-		if env.Name == "" {
-			return fmt.Errorf("environment name is required")
-		}
-		if env.AwsProfile == "" {
-			return fmt.Errorf("aws profile is required for environment '%s'", env.Name)
-		}
+	configFile, err := config.ReadConfigFile(".")
+	if err != nil {
+		return err
+	}
+	if err := checkEnvironments(configFile, environments); err != nil {
+		return err
+	}
 
+	for _, env := range environments {
 		fmt.Printf("Adding environment '%s'...\n", env.Name)
-		if err := addEnvironment(env.Name, env.AwsProfile); err != nil {
+		if err := addEnvironment(env.Name, env.AwsProfile, env.Region); err != nil {
 			return fmt.Errorf("failed to add environment '%s': %w", env.Name, err)
 		}
 
 		// Add files if specified
 		if len(env.ParametersFiles) > 0 || len(env.TagsFiles) > 0 || len(env.GitSyncFiles) > 0 {
 			fmt.Printf("Adding files to environment '%s'...\n", env.Name)
-			if err := AddFiles(env.Name, env.ParametersFiles, env.TagsFiles, env.GitSyncFiles); err != nil {
+			if _, err := AddFilesWithOptions(env.Name, env.ParametersFiles, env.TagsFiles, env.GitSyncFiles, FileOptions{Mode: env.Mode, OnConflict: env.OnConflict}); err != nil {
 				return fmt.Errorf("failed to add files to environment '%s': %w", env.Name, err)
 			}
 		}
 	}
 
+	for _, env := range environments {
+		if env.Extends == "" {
+			continue
+		}
+		if err := setExtends(env.Name, env.Extends); err != nil {
+			return fmt.Errorf("failed to add environment '%s': %w", env.Name, err)
+		}
+	}
+
 	fmt.Printf("✓ Successfully added %d environments\n", len(environments))
 	return nil
 }
 
+// checkEnvironments checks a batch of new environments against the config
+// they will be added to before any of them is created: every environment
+// needs a name, a file mode it can use and an inheritance chain without
+// unknown parents or cycles that provides an AWS profile.
+func checkEnvironments(configFile *config.ProjectConfig, environments []internal.EnvironmentConfig) error {
+	planned := &config.ProjectConfig{Environments: make(map[string]config.Environment, len(configFile.Environments)+len(environments))}
+	for name, env := range configFile.Environments {
+		planned.Environments[name] = env
+	}
+	for _, env := range environments {
+		if env.Name == "" {
+			return fmt.Errorf("environment name is required")
+		}
+		if _, exists := planned.Environments[env.Name]; exists {
+			return fmt.Errorf("environment '%s' already exists", env.Name)
+		}
+		if _, err := fileMode(env.Mode); err != nil {
+			return fmt.Errorf("environment '%s': %w", env.Name, err)
+		}
+		planned.Environments[env.Name] = config.Environment{Name: env.Name, Profile: env.AwsProfile, Region: env.Region, Extends: env.Extends}
+	}
+
+	for _, env := range environments {
+		resolved, err := planned.ResolveEnvironment(env.Name)
+		if err != nil {
+			return err
+		}
+		if resolved.Profile == "" {
+			return fmt.Errorf("aws profile is required for environment '%s'", env.Name)
+		}
+	}
+	return nil
+}
+
+// setExtends makes an environment extend another. The inheritance chain is
+// checked by the caller.
+func setExtends(envName, parent string) error {
+	configFile, err := getEnvironmentConfig(envName)
+	if err != nil {
+		return err
+	}
+	env := configFile.Environments[envName]
+	env.Extends = parent
+	configFile.Environments[envName] = env
+	return config.WriteConfigFile(".", configFile)
+}
+
 // UpdateEnvironment modifies an existing environment
-func UpdateEnvironment(envName string, newName, newProfile *string) error {
+func UpdateEnvironment(envName string, newName, newProfile, newRegion, newExtends *string) error {
 	configFile, err := getEnvironmentConfig(envName)
 	if err != nil {
 		return err
@@ -92,6 +153,15 @@ func UpdateEnvironment(envName string, newName, newProfile *string) error {
 	env := configFile.Environments[envName]
 	oldDir := getEnvironmentPath(envName)
 
+	// Check the new parent before changing anything on disk
+	if newExtends != nil {
+		env.Extends = *newExtends
+		configFile.Environments[envName] = env
+		if _, err := configFile.InheritanceChain(envName); err != nil {
+			return err
+		}
+	}
+
 	if newName != nil && *newName != envName {
 		newDir := getEnvironmentPath(*newName)
 		if _, err := os.Stat(newDir); err == nil {
@@ -102,6 +172,11 @@ func UpdateEnvironment(envName string, newName, newProfile *string) error {
 		}
 		env.Name = *newName
 		delete(configFile.Environments, envName)
+		for _, child := range configFile.Extenders(envName) {
+			childEnv := configFile.Environments[child]
+			childEnv.Extends = *newName
+			configFile.Environments[child] = childEnv
+		}
 		envName = *newName
 	}
 
@@ -109,17 +184,45 @@ func UpdateEnvironment(envName string, newName, newProfile *string) error {
 		env.Profile = *newProfile
 	}
 
+	if newRegion != nil {
+		env.Region = *newRegion
+	}
+
 	configFile.Environments[envName] = env
 	return config.WriteConfigFile(".", configFile)
 }
 
-// RemoveEnvironment deletes an environment
-func RemoveEnvironment(envName string) error {
+// RemoveEnvironment deletes an environment. Environments that extend it
+// block the removal unless force is set, in which case they extend its parent
+// instead and keep the profile and region they inherited from it.
+func RemoveEnvironment(envName string, force bool) error {
 	configFile, err := getEnvironmentConfig(envName)
 	if err != nil {
 		return err
 	}
 
+	// Environments extending this one take over what they inherited from it
+	if extenders := configFile.Extenders(envName); len(extenders) > 0 {
+		if !force {
+			return fmt.Errorf("environment '%s' is extended by %s (use --force to remove it anyway)", envName, quoteList(extenders))
+		}
+		removed, err := configFile.ResolveEnvironment(envName)
+		if err != nil {
+			return err
+		}
+		for _, child := range extenders {
+			childEnv := configFile.Environments[child]
+			childEnv.Extends = removed.Extends
+			if childEnv.Profile == "" {
+				childEnv.Profile = removed.Profile
+			}
+			if childEnv.Region == "" {
+				childEnv.Region = removed.Region
+			}
+			configFile.Environments[child] = childEnv
+		}
+	}
+
 	envDir := getEnvironmentPath(envName)
 	if err := os.RemoveAll(envDir); err != nil {
 		return fmt.Errorf("failed to remove environment directory: %w", err)
@@ -142,6 +245,9 @@ func ListEnvironments() (map[string]string, error) {
 
 	result := make(map[string]string)
 	for name, env := range configFile.Environments {
+		if resolved, err := configFile.ResolveEnvironment(name); err == nil {
+			env = resolved
+		}
 		result[name] = env.Profile
 	}
 	return result, nil
@@ -149,45 +255,99 @@ func ListEnvironments() (map[string]string, error) {
 
 // AddFiles copies files to the environment folder
 func AddFiles(envName string, paramFiles, tagFiles, gitSyncFiles []string) error {
+	return AddStackFiles(envName, "", paramFiles, tagFiles, gitSyncFiles)
+}
+
+// AddStackFiles copies files to the environment's folder for a stack, or to
+// the environment folder itself when no stack is given
+func AddStackFiles(envName, stackName string, paramFiles, tagFiles, gitSyncFiles []string) error {
+	_, err := AddFilesWithOptions(envName, paramFiles, tagFiles, gitSyncFiles, FileOptions{Stack: stackName})
+	return err
+}
+
+// AddFilesWithOptions adds parameters, tags and GitSync files to an
+// environment. See AddCategoryFiles.
+func AddFilesWithOptions(envName string, paramFiles, tagFiles, gitSyncFiles []string, opts FileOptions) ([]AddedFile, error) {
+	return addFiles(envName, opts, map[string][]string{
+		CategoryParameters: paramFiles,
+		CategoryTags:       tagFiles,
+		CategoryGitSync:    gitSyncFiles,
+	})
+}
+
+// AddCategoryFiles adds files of one category to an environment by copying
+// them, linking to them or referencing them in place, as the options' mode
+// selects. Every file must have one of the category's extensions and pass
+// its validator. Name collisions are resolved before anything is written.
+// It reports what happened to every file.
+func AddCategoryFiles(envName, category string, files []string, opts FileOptions) ([]AddedFile, error) {
+	return addFiles(envName, opts, map[string][]string{category: files})
+}
+
+// addFiles adds the files given for each category, in the order of the
+// categories.
+func addFiles(envName string, opts FileOptions, files map[string][]string) ([]AddedFile, error) {
 	if !projectExists() {
-		return fmt.Errorf("project directory not found")
+		return nil, fmt.Errorf("project directory not found")
 	}
 
-	_, err := getEnvironmentConfig(envName)
+	configFile, err := getEnvironmentConfig(envName)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	destDir := getEnvironmentPath(envName)
-	return processFileGroups(destDir, paramFiles, tagFiles, gitSyncFiles)
-}
-
-func copyFiles(destDir string, srcFiles []string) error {
-	for _, srcFile := range srcFiles {
-		if _, err := os.Stat(srcFile); os.IsNotExist(err) {
-			return fmt.Errorf("file not found: %s", srcFile)
+	stackName := opts.Stack
+	if stackName != "" {
+		if _, exists := configFile.Stacks[stackName]; !exists {
+			return nil, fmt.Errorf("stack '%s' not found", stackName)
 		}
+	}
+	mode, err := fileMode(opts.Mode)
+	if err != nil {
+		return nil, err
+	}
 
-		if !validateFileType(srcFile) {
-			return fmt.Errorf("unsupported file type: %s (only .json, .yaml, .yml allowed)", srcFile)
+	var groups []fileGroup
+	for _, name := range categoryOrder(files) {
+		category, err := lookupCategory(configFile, name)
+		if err != nil {
+			return nil, err
+		}
+		if len(files[name]) > 0 {
+			groups = append(groups, fileGroup{category, files[name]})
 		}
+	}
 
-		fileName := filepath.Base(srcFile)
-		destFile := filepath.Join(destDir, fileName)
+	planned, err := planFiles(envName, stackName, opts, groups)
+	if err != nil {
+		return nil, err
+	}
+	placed := written(planned)
 
-		if err := copyFile(srcFile, destFile); err != nil {
-			return fmt.Errorf("failed to copy %s: %w", srcFile, err)
-		}
+	destDir := StackPath(envName, stackName)
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create environment directory: %w", err)
 	}
-	return nil
+	if err := placeFiles(destDir, placed, mode, maxFileSize(configFile)); err != nil {
+		return nil, err
+	}
+	if err := trackFiles(envName, stackName, mode, placed); err != nil {
+		return nil, err
+	}
+	return addedFiles(stackName, planned), nil
 }
 
-func copyFile(src, dst string) error {
-	data, err := os.ReadFile(src)
+// validateGitSyncFile checks a GitSync deployment file against the language
+// server's schema, resolving template-file-path from the repository root
+func validateGitSyncFile(path string) error {
+	deployment, err := gitsync.ReadFile(path, ".")
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(dst, data, 0644)
+	if problems := tags.Validate(tags.FromMap(deployment.Tags)); len(problems) > 0 {
+		return &tags.ValidationError{File: path, Problems: problems}
+	}
+	return nil
 }
 
 func validateFileType(filename string) bool {
@@ -227,11 +387,10 @@ func getEnvironmentConfig(envName string) (*config.ProjectConfig, error) {
 	return configFile, nil
 }
 
-func processFileGroups(destDir string, fileGroups ...[]string) error {
-	for _, files := range fileGroups {
-		if err := copyFiles(destDir, files); err != nil {
-			return err
-		}
+func quoteList(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = "'" + name + "'"
 	}
-	return nil
+	return strings.Join(quoted, ", ")
 }
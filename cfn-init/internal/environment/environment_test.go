@@ -30,7 +30,7 @@ func setupTestProject(t *testing.T) string {
 func TestAdd_Success(t *testing.T) {
 	projectDir := setupTestProject(t)
 
-	err := addEnvironment("dev", "my-dev-profile")
+	err := addEnvironment("dev", "my-dev-profile", "")
 
 	assert.NoError(t, err)
 	assert.DirExists(t, filepath.Join(projectDir, "environments", "dev"))
@@ -44,7 +44,7 @@ func TestAdd_ProjectNotFound(t *testing.T) {
 	err := os.Chdir(tempDir)
 	assert.NoError(t, err)
 
-	err = addEnvironment("dev", "my-dev-profile")
+	err = addEnvironment("dev", "my-dev-profile", "")
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "project directory not found")
@@ -62,7 +62,7 @@ func TestAdd_ConfigFileNotFound(t *testing.T) {
 	err = os.MkdirAll("cfn-project", 0755)
 	assert.NoError(t, err)
 
-	err = addEnvironment("dev", "my-dev-profile")
+	err = addEnvironment("dev", "my-dev-profile", "")
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "project directory not found")
@@ -71,10 +71,10 @@ func TestAdd_ConfigFileNotFound(t *testing.T) {
 func TestAdd_EnvironmentExists(t *testing.T) {
 	setupTestProject(t)
 
-	err := addEnvironment("dev", "my-dev-profile")
+	err := addEnvironment("dev", "my-dev-profile", "")
 	assert.NoError(t, err)
 
-	err = addEnvironment("dev", "another-profile")
+	err = addEnvironment("dev", "another-profile", "")
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "already exists")
 }
@@ -82,12 +82,12 @@ func TestAdd_EnvironmentExists(t *testing.T) {
 func TestUpdate_Success(t *testing.T) {
 	projectDir := setupTestProject(t)
 
-	err := addEnvironment("dev", "my-dev-profile")
+	err := addEnvironment("dev", "my-dev-profile", "")
 	assert.NoError(t, err)
 
 	newName := "development"
 	newProfile := "new-profile"
-	err = UpdateEnvironment("dev", &newName, &newProfile)
+	err = UpdateEnvironment("dev", &newName, &newProfile, nil, nil)
 
 	assert.NoError(t, err)
 	assert.DirExists(t, filepath.Join(projectDir, "environments", "development"))
@@ -98,7 +98,7 @@ func TestUpdate_EnvironmentNotFound(t *testing.T) {
 	setupTestProject(t)
 
 	newName := "development"
-	err := UpdateEnvironment("nonexistent", &newName, nil)
+	err := UpdateEnvironment("nonexistent", &newName, nil, nil, nil)
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "not found")
@@ -107,10 +107,10 @@ func TestUpdate_EnvironmentNotFound(t *testing.T) {
 func TestRemove_Success(t *testing.T) {
 	projectDir := setupTestProject(t)
 
-	err := addEnvironment("dev", "my-dev-profile")
+	err := addEnvironment("dev", "my-dev-profile", "")
 	assert.NoError(t, err)
 
-	err = RemoveEnvironment("dev")
+	err = RemoveEnvironment("dev", false)
 
 	assert.NoError(t, err)
 	assert.NoDirExists(t, filepath.Join(projectDir, "environments", "dev"))
@@ -119,7 +119,7 @@ func TestRemove_Success(t *testing.T) {
 func TestAddFiles_Success(t *testing.T) {
 	projectDir := setupTestProject(t)
 
-	err := addEnvironment("dev", "my-dev-profile")
+	err := addEnvironment("dev", "my-dev-profile", "")
 	assert.NoError(t, err)
 
 	// Create test file in temp directory (not inside cfn-project)
@@ -137,7 +137,7 @@ func TestAddFiles_Success(t *testing.T) {
 func TestAddFiles_FileNotFound(t *testing.T) {
 	setupTestProject(t)
 
-	err := addEnvironment("dev", "my-dev-profile")
+	err := addEnvironment("dev", "my-dev-profile", "")
 	assert.NoError(t, err)
 
 	err = AddFiles("dev", []string{"nonexistent.json"}, nil, nil)
@@ -149,7 +149,7 @@ func TestAddFiles_FileNotFound(t *testing.T) {
 func TestAddFiles_InvalidFileType(t *testing.T) {
 	projectDir := setupTestProject(t)
 
-	err := addEnvironment("dev", "my-dev-profile")
+	err := addEnvironment("dev", "my-dev-profile", "")
 	assert.NoError(t, err)
 
 	// Create test file with invalid extension in temp directory
@@ -171,3 +171,39 @@ func TestValidateFileType(t *testing.T) {
 	assert.False(t, validateFileType("test.txt"))
 	assert.False(t, validateFileType("test"))
 }
+
+func TestAddFiles_InvalidGitSyncFile(t *testing.T) {
+	projectDir := setupTestProject(t)
+
+	err := addEnvironment("dev", "my-dev-profile", "")
+	assert.NoError(t, err)
+
+	tempDir := filepath.Dir(projectDir)
+	testFile := filepath.Join(tempDir, "deploy.json")
+	err = os.WriteFile(testFile, []byte(`{"template-file-path": "missing.yaml", "on-stack-failure": "ABANDON"}`), 0644)
+	assert.NoError(t, err)
+
+	err = AddFiles("dev", nil, nil, []string{testFile})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "on-stack-failure must be one of")
+	assert.Contains(t, err.Error(), "does not exist relative to the repository root")
+	assert.NoFileExists(t, filepath.Join(projectDir, "environments", "dev", "deploy.json"))
+}
+
+func TestAddFiles_InvalidTagsFile(t *testing.T) {
+	projectDir := setupTestProject(t)
+
+	err := addEnvironment("dev", "my-dev-profile", "")
+	assert.NoError(t, err)
+
+	testFile := filepath.Join(filepath.Dir(projectDir), "tags.json")
+	err = os.WriteFile(testFile, []byte(`[{"Key": "aws:team", "Value": "platform"}]`), 0644)
+	assert.NoError(t, err)
+
+	err = AddFiles("dev", nil, []string{testFile}, nil)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "tag 'aws:team'")
+	assert.NoFileExists(t, filepath.Join(projectDir, "environments", "dev", "tags.json"))
+}
@@ -0,0 +1,206 @@
+package environment
+
+import (
+	"cfn-init/internal/classify"
+	"cfn-init/internal/config"
+	"cfn-init/internal/gitsync"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// EnvironmentFile is a file of an environment as listed by ListFiles.
+type EnvironmentFile struct {
+	// Path is relative to the environment folder
+	Path     string
+	Category string
+	Size     int64
+	SHA256   string
+	// Source and Mode are set for files added from elsewhere
+	Source string
+	Mode   string
+	// Missing is set when the file, or the source of a referenced file, is gone
+	Missing bool
+}
+
+// ListFiles lists the files of an environment's folder, including its stack
+// and category subfolders, and the files it references in place, in path
+// order. Files without a recorded category are classified by their content.
+func ListFiles(envName string) ([]EnvironmentFile, error) {
+	if !projectExists() {
+		return nil, fmt.Errorf("project directory not found")
+	}
+	configFile, err := getEnvironmentConfig(envName)
+	if err != nil {
+		return nil, err
+	}
+	env := configFile.Environments[envName]
+
+	var paths []string
+	envDir := getEnvironmentPath(envName)
+	err = filepath.WalkDir(envDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if path == envDir && os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !entry.IsDir() {
+			rel, err := filepath.Rel(envDir, path)
+			if err != nil {
+				return err
+			}
+			paths = append(paths, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read environment directory: %w", err)
+	}
+	for _, tracked := range env.Files {
+		if tracked.Mode == ModeReference || !slices.Contains(paths, tracked.Path) {
+			paths = append(paths, tracked.Path)
+		}
+	}
+	sort.Strings(paths)
+
+	files := make([]EnvironmentFile, 0, len(paths))
+	for _, path := range slices.Compact(paths) {
+		tracked := config.TrackedFile{Path: path}
+		if index := trackedIndex(env, path); index >= 0 {
+			tracked = env.Files[index]
+		}
+		file := EnvironmentFile{Path: path, Category: tracked.Category, Source: tracked.Source}
+		if tracked.Source != "" {
+			file.Mode, _ = fileMode(tracked.Mode)
+		}
+
+		location := trackedFilePath(envName, tracked)
+		info, err := os.Stat(location)
+		if err != nil {
+			file.Missing = true
+			files = append(files, file)
+			continue
+		}
+		file.Size = info.Size()
+		if file.SHA256, err = hashFile(location); err != nil {
+			return nil, err
+		}
+		if file.Category == "" {
+			kind, _ := classify.File(location)
+			file.Category = fileCategory(kind)
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// RemoveFiles deletes files from an environment folder together with their
+// records in files, and returns their paths relative to
+// the folder. Files are given relative to the environment folder or as paths
+// inside it. Files that other config entries still point to are refused, and
+// nothing is removed unless every file can be.
+func RemoveFiles(envName string, files []string) ([]string, error) {
+	if !projectExists() {
+		return nil, fmt.Errorf("project directory not found")
+	}
+	configFile, err := getEnvironmentConfig(envName)
+	if err != nil {
+		return nil, err
+	}
+	env := configFile.Environments[envName]
+
+	var removed []string
+	for _, file := range files {
+		rel := filepath.ToSlash(filepath.Clean(file))
+		if trackedIndex(env, rel) < 0 || !insideFolder(filepath.FromSlash(rel)) {
+			path, err := environmentRelPath(envName, file)
+			if err != nil {
+				return nil, err
+			}
+			rel = filepath.ToSlash(path)
+		}
+		if info, err := os.Stat(filepath.Join(getEnvironmentPath(envName), filepath.FromSlash(rel))); err == nil && info.IsDir() {
+			return nil, fmt.Errorf("'%s' is a folder; remove the files in it instead", rel)
+		}
+
+		references, err := fileReferences(configFile, envName, rel)
+		if err != nil {
+			return nil, err
+		}
+		if len(references) > 0 {
+			return nil, fmt.Errorf("cannot remove '%s' from environment '%s': still referenced by %s", rel, envName, strings.Join(references, ", "))
+		}
+		if !slices.Contains(removed, rel) {
+			removed = append(removed, rel)
+		}
+	}
+
+	for _, rel := range removed {
+		path := filepath.Join(getEnvironmentPath(envName), filepath.FromSlash(rel))
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to remove %s: %w", rel, err)
+		}
+		if index := trackedIndex(env, rel); index >= 0 {
+			env.Files = slices.Delete(env.Files, index, index+1)
+		}
+	}
+	configFile.Environments[envName] = env
+	if err := config.WriteConfigFile(".", configFile); err != nil {
+		return nil, err
+	}
+	return removed, nil
+}
+
+// fileReferences describes the config entries that point to a file of an
+// environment, other than the file's own records: the environment's source
+// of truth, files added from it, stack templates, category schemas and the
+// template-file-path of GitSync files.
+func fileReferences(configFile *config.ProjectConfig, envName, rel string) ([]string, error) {
+	repoPath := filepath.ToSlash(filepath.Join(getEnvironmentPath(envName), filepath.FromSlash(rel)))
+
+	var references []string
+	if configFile.Environments[envName].SourceOfTruth == rel {
+		references = append(references, fmt.Sprintf("the source of truth of environment '%s'", envName))
+	}
+	for _, name := range sortedKeys(configFile.Environments) {
+		for _, tracked := range configFile.Environments[name].Files {
+			if tracked.Source == repoPath && !(name == envName && tracked.Path == rel) {
+				references = append(references, fmt.Sprintf("file '%s' of environment '%s'", tracked.Path, name))
+			}
+		}
+	}
+	for _, name := range sortedKeys(configFile.Stacks) {
+		if filepath.ToSlash(filepath.Clean(configFile.Stacks[name].Template)) == repoPath {
+			references = append(references, fmt.Sprintf("the template of stack '%s'", name))
+		}
+	}
+	for _, name := range sortedKeys(configFile.FileCategories) {
+		if schema := configFile.FileCategories[name].Schema; schema != "" && filepath.ToSlash(filepath.Clean(schema)) == repoPath {
+			references = append(references, fmt.Sprintf("the schema of file category '%s'", name))
+		}
+	}
+
+	for _, name := range sortedKeys(configFile.Environments) {
+		for _, scope := range stackScopes(configFile) {
+			gitSyncFiles, err := scopeFiles(configFile, name, scope, classify.KindGitSync)
+			if err != nil {
+				return nil, err
+			}
+			for _, path := range gitSyncFiles {
+				deployment, err := gitsync.ReadFile(path, "")
+				if err != nil {
+					continue
+				}
+				if filepath.ToSlash(filepath.Clean(deployment.TemplateFilePath)) == repoPath {
+					references = append(references, fmt.Sprintf("the template-file-path of GitSync file '%s'", filepath.ToSlash(path)))
+				}
+			}
+		}
+	}
+	return references, nil
+}
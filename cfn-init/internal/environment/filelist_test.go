@@ -0,0 +1,105 @@
+package environment
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cfn-init/internal/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListFiles(t *testing.T) {
+	projectDir := setupTestProject(t)
+	assert.NoError(t, addEnvironment("dev", "dev-profile", ""))
+	assert.NoError(t, os.MkdirAll("shared", 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join("shared", "params.json"), []byte(`{"Env": "dev"}`), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join("shared", "common.json"), []byte(`{"Team": "core"}`), 0644))
+	assert.NoError(t, AddFiles("dev", []string{filepath.Join("shared", "params.json")}, nil, nil))
+	_, err := AddFilesWithOptions("dev", []string{filepath.Join("shared", "common.json")}, nil, nil, FileOptions{Mode: ModeReference})
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(projectDir, EnvironmentsDir, "dev", "tags.yaml"), []byte("team: core\n"), 0644))
+
+	files, err := ListFiles("dev")
+
+	assert.NoError(t, err)
+	assert.Len(t, files, 3)
+	assert.Equal(t, EnvironmentFile{
+		Path: "common.json", Category: CategoryParameters, Size: 16,
+		SHA256: files[0].SHA256, Source: "shared/common.json", Mode: ModeReference,
+	}, files[0])
+	assert.Equal(t, EnvironmentFile{
+		Path: "params.json", Category: CategoryParameters, Size: 14,
+		SHA256: files[1].SHA256, Source: "shared/params.json", Mode: ModeCopy,
+	}, files[1])
+	assert.Equal(t, EnvironmentFile{Path: "tags.yaml", Category: CategoryTags, Size: 11, SHA256: files[2].SHA256}, files[2])
+	assert.Len(t, files[1].SHA256, 64)
+
+	assert.NoError(t, os.Remove(filepath.Join("shared", "common.json")))
+	files, err = ListFiles("dev")
+	assert.NoError(t, err)
+	assert.True(t, files[0].Missing)
+}
+
+func TestRemoveFiles(t *testing.T) {
+	projectDir := setupTestProject(t)
+	assert.NoError(t, addEnvironment("dev", "dev-profile", ""))
+	assert.NoError(t, os.WriteFile("params.json", []byte(`{"Env": "dev"}`), 0644))
+	assert.NoError(t, os.WriteFile("tags.json", []byte(`[{"Key": "team", "Value": "core"}]`), 0644))
+	assert.NoError(t, AddFiles("dev", []string{"params.json"}, []string{"tags.json"}, nil))
+	envDir := filepath.Join(projectDir, EnvironmentsDir, "dev")
+
+	removed, err := RemoveFiles("dev", []string{"params.json", filepath.Join(ProjectDir, EnvironmentsDir, "dev", "tags.json")})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"params.json", "tags.json"}, removed)
+	assert.NoFileExists(t, filepath.Join(envDir, "params.json"))
+	assert.NoFileExists(t, filepath.Join(envDir, "tags.json"))
+	configFile, err := config.ReadConfigFile(".")
+	assert.NoError(t, err)
+	assert.Empty(t, configFile.Environments["dev"].Files)
+}
+
+func TestRemoveFiles_StillReferenced(t *testing.T) {
+	projectDir := setupTestProject(t)
+	assert.NoError(t, addEnvironment("dev", "dev-profile", ""))
+	assert.NoError(t, addEnvironment("prod", "prod-profile", ""))
+	envDir := filepath.Join(projectDir, EnvironmentsDir, "dev")
+	assert.NoError(t, os.WriteFile(filepath.Join(envDir, "params.json"), []byte(`{"Env": "dev"}`), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(envDir, "stack.yaml"), []byte("Resources:\n  Bucket:\n    Type: AWS::S3::Bucket\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(envDir, "deploy.yaml"), []byte("template-file-path: cfn-project/environments/dev/stack.yaml\n"), 0644))
+	_, err := AddFilesWithOptions("prod", []string{filepath.Join(envDir, "params.json")}, nil, nil, FileOptions{Mode: ModeReference})
+	assert.NoError(t, err)
+
+	_, err = RemoveFiles("dev", []string{"deploy.yaml", "params.json"})
+	assert.EqualError(t, err, "cannot remove 'params.json' from environment 'dev': still referenced by file 'params.json' of environment 'prod'")
+	assert.FileExists(t, filepath.Join(envDir, "deploy.yaml"))
+
+	_, err = RemoveFiles("dev", []string{"stack.yaml"})
+	assert.EqualError(t, err, "cannot remove 'stack.yaml' from environment 'dev': still referenced by the template-file-path of GitSync file 'cfn-project/environments/dev/deploy.yaml'")
+
+	_, err = RemoveFiles("dev", []string{"missing.json"})
+	assert.EqualError(t, err, "file 'missing.json' is not in environment 'dev'")
+}
+
+func TestRemoveFiles_OutsideEnvironment(t *testing.T) {
+	projectDir := setupTestProject(t)
+	assert.NoError(t, addEnvironment("dev", "dev-profile", ""))
+	assert.NoError(t, addEnvironment("prod", "prod-profile", ""))
+	prodFile := filepath.Join(projectDir, EnvironmentsDir, "prod", "q.json")
+	assert.NoError(t, os.WriteFile(prodFile, []byte(`{"Env": "prod"}`), 0644))
+	assert.NoError(t, os.WriteFile("template.yaml", []byte("Resources: {}\n"), 0644))
+
+	_, err := RemoveFiles("dev", []string{filepath.Join("..", "prod", "q.json")})
+	assert.EqualError(t, err, "file '../prod/q.json' is not in environment 'dev'")
+
+	_, err = RemoveFiles("dev", []string{filepath.Join("..", "..", "..", "template.yaml")})
+	assert.EqualError(t, err, "file '../../../template.yaml' is not in environment 'dev'")
+
+	_, err = RemoveFiles("dev", []string{prodFile})
+	assert.EqualError(t, err, "file '"+prodFile+"' is not in environment 'dev'")
+
+	assert.FileExists(t, prodFile)
+	assert.FileExists(t, "template.yaml")
+}
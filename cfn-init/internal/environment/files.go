@@ -0,0 +1,225 @@
+package environment
+
+import (
+	"cfn-init/internal/classify"
+	"cfn-init/internal/config"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Ways of adding a file to an environment.
+const (
+	// ModeCopy copies the file into the environment folder
+	ModeCopy = "copy"
+	// ModeSymlink links to the file from the environment folder
+	ModeSymlink = "symlink"
+	// ModeReference records the file's path in cfn-config.json and reads it in place
+	ModeReference = "reference"
+)
+
+// DefaultMaxFileSize is the largest file copied into an environment when the
+// project does not configure maxFileSize.
+const DefaultMaxFileSize int64 = 10 << 20
+
+// FileOptions control how files are added to an environment.
+type FileOptions struct {
+	// Stack scopes the files to the stack's folder
+	Stack string
+	// Mode is ModeCopy, ModeSymlink or ModeReference; empty means ModeCopy
+	Mode string
+	// OnConflict resolves name collisions: OnConflictFail, OnConflictOverwrite,
+	// OnConflictRename or OnConflictSkip. When empty, Prompt is asked.
+	OnConflict string
+	// Prompt chooses how to resolve a collision when OnConflict is empty.
+	// Without it, collisions fail.
+	Prompt func(FileCollision) (string, error)
+	// Template is the template stack policies are checked against, in place
+	// of the one the stack or the environment's GitSync file names
+	Template string
+}
+
+func fileMode(mode string) (string, error) {
+	switch mode {
+	case "", ModeCopy:
+		return ModeCopy, nil
+	case ModeSymlink, ModeReference:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid file mode '%s' (only copy, symlink, reference allowed)", mode)
+	}
+}
+
+func maxFileSize(configFile *config.ProjectConfig) int64 {
+	if configFile.MaxFileSize > 0 {
+		return configFile.MaxFileSize
+	}
+	return DefaultMaxFileSize
+}
+
+// placeFiles copies or links files into destDir under their planned names.
+// Referenced files are only checked, since they stay where they are.
+func placeFiles(destDir string, files []*placement, mode string, maxSize int64) error {
+	for _, file := range files {
+		destFile := filepath.Join(destDir, file.name)
+		if mode != ModeReference {
+			if err := os.MkdirAll(filepath.Dir(destFile), 0755); err != nil {
+				return fmt.Errorf("failed to create environment directory: %w", err)
+			}
+		}
+		switch mode {
+		case ModeSymlink:
+			if err := LinkFile(file.source, destFile); err != nil {
+				return fmt.Errorf("failed to link %s: %w", file.source, err)
+			}
+		case ModeReference:
+			if source := sourcePath(file.source); filepath.IsAbs(filepath.FromSlash(source)) {
+				return fmt.Errorf("cannot reference %s: referenced files must be inside the repository", file.source)
+			}
+			// A copy or link the reference replaces would otherwise be read too
+			if err := os.Remove(destFile); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		default:
+			if err := copyFile(file.source, destFile, maxSize); err != nil {
+				return fmt.Errorf("failed to copy %s: %w", file.source, err)
+			}
+		}
+	}
+	return nil
+}
+
+// copyFile streams src to dst, keeping the permission bits and modification
+// time of src. Files larger than maxSize are rejected. The copy is written to
+// a temporary file next to dst and renamed over it, so a read-only dst can be
+// replaced and a failed copy leaves dst unchanged.
+func copyFile(src, dst string, maxSize int64) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() > maxSize {
+		return fmt.Errorf("file is %d bytes, larger than the maximum of %d (set maxFileSize in cfn-config.json)", info.Size(), maxSize)
+	}
+
+	out, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+"-*")
+	if err != nil {
+		return err
+	}
+	tempPath := out.Name()
+	defer os.Remove(tempPath)
+
+	written, err := io.Copy(out, io.LimitReader(in, maxSize+1))
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if written > maxSize {
+		return fmt.Errorf("file grew beyond the maximum of %d bytes while copying", maxSize)
+	}
+
+	if err := os.Chmod(tempPath, info.Mode().Perm()); err != nil {
+		return err
+	}
+	if err := os.Chtimes(tempPath, info.ModTime(), info.ModTime()); err != nil {
+		return err
+	}
+	// Renaming replaces links rather than writing through them
+	return os.Rename(tempPath, dst)
+}
+
+// LinkFile replaces dst with a relative symbolic link to src.
+func LinkFile(src, dst string) error {
+	absSrc, err := filepath.Abs(src)
+	if err != nil {
+		return err
+	}
+	absDir, err := filepath.Abs(filepath.Dir(dst))
+	if err != nil {
+		return err
+	}
+	target, err := filepath.Rel(absDir, absSrc)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.Symlink(target, dst)
+}
+
+// scopeFiles lists the files of an environment's folder, or of its folder for
+// a stack, that are one of the given kinds, including the files referenced in
+// place for that folder. A file's kind is its recorded category, or else is
+// classified from its content. Files not recorded as parameters files come
+// first in name order, then the recorded ones in the order they were added,
+// so the last added file wins.
+func scopeFiles(configFile *config.ProjectConfig, envName, stackName string, kinds ...classify.Kind) ([]string, error) {
+	files, _, err := scopeFileKinds(configFile, envName, stackName, kinds...)
+	return files, err
+}
+
+// scopeFileKinds is scopeFiles that also returns the kind of each file.
+func scopeFileKinds(configFile *config.ProjectConfig, envName, stackName string, kinds ...classify.Kind) ([]string, map[string]classify.Kind, error) {
+	env := configFile.Environments[envName]
+	files, fileKinds, err := kindFiles(StackPath(envName, stackName), func(name string) (classify.Kind, bool) {
+		return recordedKind(env, path.Join(stackName, name))
+	}, kinds...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if fileKinds == nil {
+		fileKinds = make(map[string]classify.Kind)
+	}
+	names := make(map[string]string, len(files))
+	for _, file := range files {
+		names[file] = filepath.ToSlash(filepath.Join(stackName, filepath.Base(file)))
+	}
+
+	for _, tracked := range env.Files {
+		if tracked.Mode != ModeReference || path.Dir(tracked.Path) != path.Clean("./"+stackName) {
+			continue
+		}
+		source := filepath.FromSlash(tracked.Source)
+		kind, recorded := recordedKind(env, tracked.Path)
+		if !recorded {
+			if kind, err = classify.File(source); err != nil {
+				return nil, nil, fmt.Errorf("referenced file '%s' of environment '%s': %w", tracked.Source, envName, err)
+			}
+		}
+		if slices.Contains(kinds, kind) {
+			files = append(files, source)
+			fileKinds[source] = kind
+			names[source] = tracked.Path
+		}
+	}
+
+	// Parameters files merge in the order they were added to the environment
+	rank := make(map[string]int, len(env.Files))
+	for i, tracked := range env.Files {
+		if tracked.Category == CategoryParameters {
+			rank[tracked.Path] = i + 1
+		}
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		a, b := names[files[i]], names[files[j]]
+		if rank[a] != rank[b] {
+			return rank[a] < rank[b]
+		}
+		return strings.Compare(a, b) < 0
+	})
+	return files, fileKinds, nil
+}
@@ -0,0 +1,131 @@
+package environment
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"cfn-init/internal/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeSharedFile(t *testing.T, name, content string) string {
+	t.Helper()
+	assert.NoError(t, os.MkdirAll("shared", 0755))
+	path := filepath.Join("shared", name)
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestAddFilesWithOptions_CopyKeepsModeAndTime(t *testing.T) {
+	projectDir := setupTestProject(t)
+	assert.NoError(t, addEnvironment("dev", "dev-profile", ""))
+	source := writeSharedFile(t, "params.json", `{"Env": "dev"}`)
+	assert.NoError(t, os.Chmod(source, 0600))
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	assert.NoError(t, os.Chtimes(source, modTime, modTime))
+
+	_, err := AddFilesWithOptions("dev", []string{source}, nil, nil, FileOptions{Mode: ModeCopy})
+
+	assert.NoError(t, err)
+	info, err := os.Stat(filepath.Join(projectDir, EnvironmentsDir, "dev", "params.json"))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	assert.True(t, modTime.Equal(info.ModTime()))
+}
+
+func TestAddFilesWithOptions_OverwritesReadOnlyCopy(t *testing.T) {
+	projectDir := setupTestProject(t)
+	assert.NoError(t, addEnvironment("dev", "dev-profile", ""))
+	source := writeSharedFile(t, "params.json", `{"Env": "dev"}`)
+	assert.NoError(t, os.Chmod(source, 0444))
+	_, err := AddFilesWithOptions("dev", []string{source}, nil, nil, FileOptions{})
+	assert.NoError(t, err)
+
+	assert.NoError(t, os.Chmod(source, 0644))
+	assert.NoError(t, os.WriteFile(source, []byte(`{"Env": "test"}`), 0644))
+	assert.NoError(t, os.Chmod(source, 0444))
+	_, err = AddFilesWithOptions("dev", []string{source}, nil, nil, FileOptions{OnConflict: OnConflictOverwrite})
+
+	assert.NoError(t, err)
+	envDir := filepath.Join(projectDir, EnvironmentsDir, "dev")
+	data, err := os.ReadFile(filepath.Join(envDir, "params.json"))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"Env": "test"}`, string(data))
+	entries, err := os.ReadDir(envDir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestAddFilesWithOptions_MaxFileSize(t *testing.T) {
+	setupTestProject(t)
+	assert.NoError(t, addEnvironment("dev", "dev-profile", ""))
+	configFile, err := config.ReadConfigFile(".")
+	assert.NoError(t, err)
+	configFile.MaxFileSize = 8
+	assert.NoError(t, config.WriteConfigFile(".", configFile))
+	source := writeSharedFile(t, "params.json", `{"Env": "dev"}`)
+
+	_, err = AddFilesWithOptions("dev", []string{source}, nil, nil, FileOptions{})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "larger than the maximum of 8")
+}
+
+func TestAddFilesWithOptions_Symlink(t *testing.T) {
+	projectDir := setupTestProject(t)
+	assert.NoError(t, addEnvironment("dev", "dev-profile", ""))
+	source := writeSharedFile(t, "params.json", `{"Env": "dev"}`)
+
+	_, err := AddFilesWithOptions("dev", []string{source}, nil, nil, FileOptions{Mode: ModeSymlink})
+
+	assert.NoError(t, err)
+	target, err := os.Readlink(filepath.Join(projectDir, EnvironmentsDir, "dev", "params.json"))
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join("..", "..", "..", "shared", "params.json"), target)
+
+	assert.NoError(t, os.WriteFile(source, []byte(`{"Env": "changed"}`), 0644))
+	params, err := LoadParameters("dev", "")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"Env": "changed"}, params)
+
+	statuses, err := FilesStatus("dev")
+	assert.NoError(t, err)
+	assert.Equal(t, FileCurrent, statuses[0].Status)
+}
+
+func TestAddFilesWithOptions_Reference(t *testing.T) {
+	projectDir := setupTestProject(t)
+	assert.NoError(t, addEnvironment("dev", "dev-profile", ""))
+	source := writeSharedFile(t, "params.json", `{"Env": "dev"}`)
+
+	_, err := AddFilesWithOptions("dev", []string{source}, nil, nil, FileOptions{Mode: ModeReference})
+
+	assert.NoError(t, err)
+	assert.NoFileExists(t, filepath.Join(projectDir, EnvironmentsDir, "dev", "params.json"))
+	configFile, err := config.ReadConfigFile(".")
+	assert.NoError(t, err)
+	assert.Equal(t, []config.TrackedFile{{Path: "params.json", Category: CategoryParameters, Source: "shared/params.json", Mode: ModeReference}}, configFile.Environments["dev"].Files)
+
+	values, err := ResolveValues("dev", "")
+	assert.NoError(t, err)
+	assert.Equal(t, ResolvedValue{Value: "dev", Source: "shared/params.json"}, values.Parameters["Env"])
+
+	assert.NoError(t, os.Remove(source))
+	statuses, err := FilesStatus("dev")
+	assert.NoError(t, err)
+	assert.Equal(t, FileSourceMissing, statuses[0].Status)
+	_, err = ResolveValues("dev", "")
+	assert.Error(t, err)
+}
+
+func TestAddFilesWithOptions_InvalidMode(t *testing.T) {
+	setupTestProject(t)
+	assert.NoError(t, addEnvironment("dev", "dev-profile", ""))
+
+	_, err := AddFilesWithOptions("dev", nil, nil, nil, FileOptions{Mode: "hardlink"})
+
+	assert.EqualError(t, err, "invalid file mode 'hardlink' (only copy, symlink, reference allowed)")
+}
@@ -0,0 +1,373 @@
+package environment
+
+import (
+	"bytes"
+	"cfn-init/internal/classify"
+	"cfn-init/internal/config"
+	"cfn-init/internal/gitsync"
+	"cfn-init/internal/parameters"
+	"cfn-init/internal/tags"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// DefaultGitSyncFile is the name of the deployment file written by GenerateGitSync.
+const DefaultGitSyncFile = "gitsync-deployment.yaml"
+
+// File names written by SplitGitSync next to the deployment file.
+const (
+	SplitParametersFile = "parameters.json"
+	SplitTagsFile       = "tags.json"
+)
+
+// GenerateGitSync builds a GitSync deployment file from the project defaults
+// and the environment's parameters and tags files. Stack-scoped files override
+// environment-level ones. Settings such as on-stack-failure are kept from an
+// existing file or inherited from an extended environment's file.
+func GenerateGitSync(envName, stackName, templatePath, fileName string) (*gitsync.DeploymentConfig, error) {
+	if !projectExists() {
+		return nil, fmt.Errorf("project directory not found")
+	}
+	configFile, err := getEnvironmentConfig(envName)
+	if err != nil {
+		return nil, err
+	}
+
+	target := filepath.ToSlash(filepath.Join(stackName, fileName))
+	if source := configFile.Environments[envName].SourceOfTruth; source == target {
+		return nil, fmt.Errorf("'%s' is the source of truth for environment '%s'; edit it directly instead of generating it", target, envName)
+	}
+
+	templatePath, err = gitSyncTemplatePath(configFile, stackName, templatePath)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(templatePath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("template file '%s' does not exist", templatePath)
+	}
+
+	deployment, _, err := deploymentSettings(configFile, envName, stackName, fileName)
+	if err != nil {
+		return nil, err
+	}
+	deployment.TemplateFilePath = filepath.ToSlash(filepath.Clean(templatePath))
+	values, err := resolveValues(envName, stackName, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := values.interpolate(); err != nil {
+		return nil, err
+	}
+	deployment.Parameters = values.ParameterValues()
+	deployment.Tags = values.TagValues()
+	return deployment, nil
+}
+
+// RenderGitSync builds the deployment file an environment deploys with from
+// already rendered values. Deployment settings come from the environment's
+// GitSync file of the default name, or an extended environment's. The
+// template is the one given, the stack's, or the one the settings name.
+// The path of the settings file is returned when one was used.
+func RenderGitSync(envName, stackName, templatePath string, values *EffectiveValues) (*gitsync.DeploymentConfig, string, error) {
+	configFile, err := getEnvironmentConfig(envName)
+	if err != nil {
+		return nil, "", err
+	}
+	deployment, settingsPath, err := deploymentSettings(configFile, envName, stackName, DefaultGitSyncFile)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if templatePath != "" || stackName != "" || deployment.TemplateFilePath == "" {
+		templatePath, err = gitSyncTemplatePath(configFile, stackName, templatePath)
+		if err != nil {
+			return nil, "", err
+		}
+		deployment.TemplateFilePath = filepath.ToSlash(filepath.Clean(templatePath))
+	}
+	deployment.Parameters = values.ParameterValues()
+	deployment.Tags = values.TagValues()
+	return deployment, settingsPath, nil
+}
+
+// WriteGitSync writes a generated deployment file to the environment folder,
+// records it as a GitSync file and returns its path.
+func WriteGitSync(envName, stackName, fileName string, deployment *gitsync.DeploymentConfig) (string, error) {
+	path := filepath.Join(StackPath(envName, stackName), fileName)
+	data, err := gitsync.Marshal(deployment, path)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode GitSync file: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write GitSync file: %w", err)
+	}
+	if err := recordWrittenFiles(envName, map[string]string{filepath.ToSlash(filepath.Join(stackName, fileName)): CategoryGitSync}); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// CheckGitSync compares an existing deployment file with the one generated
+// from its sources and describes every difference. No differences means the
+// file is up to date.
+func CheckGitSync(envName, stackName, templatePath, fileName string) ([]string, error) {
+	expected, err := GenerateGitSync(envName, stackName, templatePath, fileName)
+	if err != nil {
+		return nil, err
+	}
+	actual, err := readGitSyncFile(envName, stackName, fileName)
+	if err != nil {
+		return nil, err
+	}
+	if actual == nil {
+		return nil, fmt.Errorf("GitSync file '%s' does not exist", filepath.Join(StackPath(envName, stackName), fileName))
+	}
+
+	var drift []string
+	if actual.TemplateFilePath != expected.TemplateFilePath {
+		drift = append(drift, fmt.Sprintf("%s: expected '%s', found '%s'", gitsync.KeyTemplateFilePath, expected.TemplateFilePath, actual.TemplateFilePath))
+	}
+	drift = append(drift, diffValues(gitsync.KeyParameters, expected.Parameters, actual.Parameters)...)
+	drift = append(drift, diffValues(gitsync.KeyTags, expected.Tags, actual.Tags)...)
+
+	// Also catch formatting changes so the committed file matches what generate writes
+	if len(drift) == 0 {
+		path := filepath.Join(StackPath(envName, stackName), fileName)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		generated, err := gitsync.Marshal(expected, path)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(data, generated) {
+			drift = append(drift, "file is not formatted as generated")
+		}
+	}
+	return drift, nil
+}
+
+// splitFile is a file to be written by SplitGitSync.
+type splitFile struct {
+	path     string
+	category string
+	data     []byte
+}
+
+// SplitGitSync writes the parameters and tags of a GitSync deployment file
+// as AWS CLI parameters.json and tags.json files next to it, and records the
+// deployment file as the environment's source of truth. The file is given
+// relative to the environment folder. Existing files are only replaced with force.
+func SplitGitSync(envName, file string, force bool) ([]string, error) {
+	if !projectExists() {
+		return nil, fmt.Errorf("project directory not found")
+	}
+	configFile, err := getEnvironmentConfig(envName)
+	if err != nil {
+		return nil, err
+	}
+
+	relPath, err := environmentRelPath(envName, file)
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(StackPath(envName, ""), relPath)
+	deployment, err := gitsync.ReadFile(path, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var outputs []splitFile
+	dir := filepath.Dir(path)
+	if len(deployment.Parameters) > 0 {
+		data, err := parameters.MarshalCLI(deployment.Parameters)
+		if err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, splitFile{filepath.Join(dir, SplitParametersFile), CategoryParameters, data})
+	}
+	if len(deployment.Tags) > 0 {
+		data, err := tags.Marshal(tags.FromMap(deployment.Tags))
+		if err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, splitFile{filepath.Join(dir, SplitTagsFile), CategoryTags, data})
+	}
+	if len(outputs) == 0 {
+		return nil, fmt.Errorf("GitSync file '%s' has no parameters or tags", relPath)
+	}
+
+	if !force {
+		for _, output := range outputs {
+			if _, err := os.Stat(output.path); err == nil {
+				return nil, fmt.Errorf("file '%s' already exists (use --force to replace it)", output.path)
+			}
+		}
+	}
+
+	var written []string
+	env := configFile.Environments[envName]
+	for _, output := range outputs {
+		if err := os.WriteFile(output.path, output.data, 0644); err != nil {
+			return nil, fmt.Errorf("failed to write '%s': %w", output.path, err)
+		}
+		written = append(written, output.path)
+		recordCategory(&env, filepath.ToSlash(filepath.Join(filepath.Dir(relPath), filepath.Base(output.path))), output.category)
+	}
+	recordCategory(&env, filepath.ToSlash(relPath), CategoryGitSync)
+	env.SourceOfTruth = filepath.ToSlash(relPath)
+	configFile.Environments[envName] = env
+	if err := config.WriteConfigFile(".", configFile); err != nil {
+		return nil, err
+	}
+	return written, nil
+}
+
+// environmentRelPath returns a file's path relative to the environment folder.
+// The file may be given relative to that folder or as a path inside it, and
+// paths that lead out of the folder are rejected.
+func environmentRelPath(envName, file string) (string, error) {
+	envDir := StackPath(envName, "")
+	if rel := filepath.Clean(file); insideFolder(rel) {
+		if _, err := os.Stat(filepath.Join(envDir, rel)); err == nil {
+			return rel, nil
+		}
+	}
+
+	rel, err := filepath.Rel(envDir, file)
+	if err != nil || !insideFolder(rel) {
+		return "", fmt.Errorf("file '%s' is not in environment '%s'", file, envName)
+	}
+	if _, err := os.Stat(file); os.IsNotExist(err) {
+		return "", fmt.Errorf("file '%s' does not exist", file)
+	}
+	return rel, nil
+}
+
+// insideFolder reports whether a cleaned relative path names something
+// inside the folder it is relative to.
+func insideFolder(rel string) bool {
+	return !filepath.IsAbs(rel) && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// gitSyncTemplatePath returns the template path given on the command line or
+// the stack's template.
+func gitSyncTemplatePath(configFile *config.ProjectConfig, stackName, templatePath string) (string, error) {
+	if stackName != "" {
+		s, exists := configFile.Stacks[stackName]
+		if !exists {
+			return "", fmt.Errorf("stack '%s' not found", stackName)
+		}
+		if templatePath == "" {
+			return filepath.FromSlash(s.Template), nil
+		}
+	}
+	if templatePath == "" {
+		return "", fmt.Errorf("template path or stack name is required")
+	}
+	return templatePath, nil
+}
+
+// deploymentSettings returns the existing deployment file, or the one of the
+// nearest extended environment with a file of the same name, and its path.
+// Without one, empty settings and no path are returned.
+func deploymentSettings(configFile *config.ProjectConfig, envName, stackName, fileName string) (*gitsync.DeploymentConfig, string, error) {
+	chain, err := configFile.InheritanceChain(envName)
+	if err != nil {
+		return nil, "", err
+	}
+	for _, name := range chain {
+		existing, err := readGitSyncFile(name, stackName, fileName)
+		if err != nil {
+			return nil, "", err
+		}
+		if existing != nil {
+			return existing, filepath.Join(StackPath(name, stackName), fileName), nil
+		}
+	}
+	return &gitsync.DeploymentConfig{}, "", nil
+}
+
+// readGitSyncFile reads a previously generated deployment file, returning nil
+// when it does not exist yet.
+func readGitSyncFile(envName, stackName, fileName string) (*gitsync.DeploymentConfig, error) {
+	path := filepath.Join(StackPath(envName, stackName), fileName)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, nil
+	}
+	return gitsync.ReadFile(path, "")
+}
+
+// kindFiles lists the files in dir that are one of the given kinds, in name
+// order, with the kind of each. A file's kind is the one recorded for its
+// name, if recorded is given and has one, or else classified from its content.
+func kindFiles(dir string, recorded func(name string) (classify.Kind, bool), kinds ...classify.Kind) ([]string, map[string]classify.Kind, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, nil
+		}
+		return nil, nil, fmt.Errorf("failed to read environment directory: %w", err)
+	}
+
+	var files []string
+	fileKinds := make(map[string]classify.Kind)
+	for _, entry := range entries {
+		if entry.IsDir() || !validateFileType(entry.Name()) {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		kind, ok := classify.Kind(""), false
+		if recorded != nil {
+			kind, ok = recorded(entry.Name())
+		}
+		if !ok {
+			if kind, err = classify.File(path); err != nil {
+				continue
+			}
+		}
+		if slices.Contains(kinds, kind) {
+			files = append(files, path)
+			fileKinds[path] = kind
+		}
+	}
+	return files, fileKinds, nil
+}
+
+func diffValues(section string, expected, actual map[string]string) []string {
+	keys := make(map[string]bool)
+	for key := range expected {
+		keys[key] = true
+	}
+	for key := range actual {
+		keys[key] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	var drift []string
+	for _, key := range sorted {
+		want, inExpected := expected[key]
+		got, inActual := actual[key]
+		switch {
+		case !inActual:
+			drift = append(drift, fmt.Sprintf("%s.%s: missing, expected '%s'", section, key, want))
+		case !inExpected:
+			drift = append(drift, fmt.Sprintf("%s.%s: not in any source file", section, key))
+		case want != got:
+			drift = append(drift, fmt.Sprintf("%s.%s: expected '%s', found '%s'", section, key, want, got))
+		}
+	}
+	return drift
+}
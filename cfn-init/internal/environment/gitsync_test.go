@@ -0,0 +1,115 @@
+package environment
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupGitSyncProject(t *testing.T) {
+	setupInventoryProject(t)
+	tagsFile := filepath.Join(ProjectDir, EnvironmentsDir, "dev", "tags.json")
+	assert.NoError(t, os.WriteFile(tagsFile, []byte(`[{"Key": "team", "Value": "platform"}]`), 0644))
+}
+
+func TestGenerateGitSync(t *testing.T) {
+	setupGitSyncProject(t)
+
+	deployment, err := GenerateGitSync("dev", "", "template.yaml", DefaultGitSyncFile)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "template.yaml", deployment.TemplateFilePath)
+	assert.Equal(t, map[string]string{"Env": "dev"}, deployment.Parameters)
+	assert.Equal(t, map[string]string{"team": "platform"}, deployment.Tags)
+}
+
+func TestGenerateGitSync_KeepsExistingSettings(t *testing.T) {
+	setupGitSyncProject(t)
+	existing := "template-file-path: old.yaml\non-stack-failure: ROLLBACK\n"
+	path := filepath.Join(ProjectDir, EnvironmentsDir, "dev", DefaultGitSyncFile)
+	assert.NoError(t, os.WriteFile(path, []byte(existing), 0644))
+
+	deployment, err := GenerateGitSync("dev", "", "template.yaml", DefaultGitSyncFile)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "template.yaml", deployment.TemplateFilePath)
+	assert.Equal(t, "ROLLBACK", deployment.OnStackFailure)
+}
+
+func TestGenerateGitSync_TemplateRequired(t *testing.T) {
+	setupGitSyncProject(t)
+
+	_, err := GenerateGitSync("dev", "", "", DefaultGitSyncFile)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "template path or stack name is required")
+}
+
+func TestCheckGitSync(t *testing.T) {
+	setupGitSyncProject(t)
+	deployment, err := GenerateGitSync("dev", "", "template.yaml", DefaultGitSyncFile)
+	assert.NoError(t, err)
+	_, err = WriteGitSync("dev", "", DefaultGitSyncFile, deployment)
+	assert.NoError(t, err)
+
+	drift, err := CheckGitSync("dev", "", "template.yaml", DefaultGitSyncFile)
+	assert.NoError(t, err)
+	assert.Empty(t, drift)
+
+	params := filepath.Join(ProjectDir, EnvironmentsDir, "dev", "params.json")
+	assert.NoError(t, os.WriteFile(params, []byte(`[{"ParameterKey": "Env", "ParameterValue": "staging"}]`), 0644))
+
+	drift, err = CheckGitSync("dev", "", "template.yaml", DefaultGitSyncFile)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"parameters.Env: expected 'staging', found 'dev'"}, drift)
+}
+
+func TestCheckGitSync_FileMissing(t *testing.T) {
+	setupGitSyncProject(t)
+
+	_, err := CheckGitSync("dev", "", "template.yaml", DefaultGitSyncFile)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "does not exist")
+}
+
+func TestSplitGitSync(t *testing.T) {
+	setupTestProject(t)
+	assert.NoError(t, addEnvironment("dev", "dev-profile", ""))
+	deployment := "template-file-path: template.yaml\nparameters:\n  Env: dev\n  Size: small\ntags:\n  team: platform\n"
+	envDir := filepath.Join(ProjectDir, EnvironmentsDir, "dev")
+	assert.NoError(t, os.WriteFile(filepath.Join(envDir, "deploy.yaml"), []byte(deployment), 0644))
+
+	written, err := SplitGitSync("dev", "deploy.yaml", false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(envDir, SplitParametersFile), filepath.Join(envDir, SplitTagsFile)}, written)
+	params, err := os.ReadFile(filepath.Join(envDir, SplitParametersFile))
+	assert.NoError(t, err)
+	assert.Contains(t, string(params), `"ParameterKey": "Env"`)
+
+	configFile, err := getEnvironmentConfig("dev")
+	assert.NoError(t, err)
+	assert.Equal(t, "deploy.yaml", configFile.Environments["dev"].SourceOfTruth)
+
+	_, err = GenerateGitSync("dev", "", "template.yaml", "deploy.yaml")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "source of truth")
+}
+
+func TestSplitGitSync_ExistingFile(t *testing.T) {
+	setupTestProject(t)
+	assert.NoError(t, addEnvironment("dev", "dev-profile", ""))
+	envDir := filepath.Join(ProjectDir, EnvironmentsDir, "dev")
+	assert.NoError(t, os.WriteFile(filepath.Join(envDir, "deploy.yaml"), []byte("parameters:\n  Env: dev\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(envDir, SplitParametersFile), []byte("[]"), 0644))
+
+	_, err := SplitGitSync("dev", "deploy.yaml", false)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "already exists")
+
+	_, err = SplitGitSync("dev", "deploy.yaml", true)
+	assert.NoError(t, err)
+}
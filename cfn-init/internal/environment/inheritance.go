@@ -0,0 +1,50 @@
+package environment
+
+import "fmt"
+
+// EnvironmentDetails describes an environment with the settings it inherits.
+// ProfileFrom and RegionFrom name the environment each setting comes from.
+type EnvironmentDetails struct {
+	Name          string
+	Profile       string
+	ProfileFrom   string
+	Region        string
+	RegionFrom    string
+	SourceOfTruth string
+	// Chain is the environment followed by the environments it extends, nearest first.
+	Chain []string
+	// Extenders lists the environments that directly extend this one.
+	Extenders []string
+}
+
+// ShowEnvironment returns an environment's settings and inheritance chain.
+func ShowEnvironment(envName string) (*EnvironmentDetails, error) {
+	if !projectExists() {
+		return nil, fmt.Errorf("project directory not found")
+	}
+	configFile, err := getEnvironmentConfig(envName)
+	if err != nil {
+		return nil, err
+	}
+	chain, err := configFile.InheritanceChain(envName)
+	if err != nil {
+		return nil, err
+	}
+
+	details := &EnvironmentDetails{
+		Name:          envName,
+		SourceOfTruth: configFile.Environments[envName].SourceOfTruth,
+		Chain:         chain,
+		Extenders:     configFile.Extenders(envName),
+	}
+	for _, name := range chain {
+		env := configFile.Environments[name]
+		if details.ProfileFrom == "" && env.Profile != "" {
+			details.Profile, details.ProfileFrom = env.Profile, name
+		}
+		if details.RegionFrom == "" && env.Region != "" {
+			details.Region, details.RegionFrom = env.Region, name
+		}
+	}
+	return details, nil
+}
@@ -0,0 +1,143 @@
+package environment
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cfn-init/internal"
+	"cfn-init/internal/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupInheritanceProject(t *testing.T) {
+	setupTestProject(t)
+	err := AddEnvironments([]internal.EnvironmentConfig{
+		{Name: "prod-eu", Region: "eu-west-1", Extends: "prod"},
+		{Name: "prod", AwsProfile: "prod-profile", Region: "us-east-1"},
+	})
+	assert.NoError(t, err)
+}
+
+func TestAddEnvironments_Extends(t *testing.T) {
+	setupInheritanceProject(t)
+
+	configFile, err := config.ReadConfigFile(".")
+	assert.NoError(t, err)
+	assert.Equal(t, "prod", configFile.Environments["prod-eu"].Extends)
+	assert.Empty(t, configFile.Environments["prod-eu"].Profile)
+
+	envs, err := ListEnvironments()
+	assert.NoError(t, err)
+	assert.Equal(t, "prod-profile", envs["prod-eu"])
+}
+
+func TestShowEnvironment(t *testing.T) {
+	setupInheritanceProject(t)
+
+	details, err := ShowEnvironment("prod-eu")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"prod-eu", "prod"}, details.Chain)
+	assert.Equal(t, "prod-profile", details.Profile)
+	assert.Equal(t, "prod", details.ProfileFrom)
+	assert.Equal(t, "eu-west-1", details.Region)
+	assert.Equal(t, "prod-eu", details.RegionFrom)
+
+	parent, err := ShowEnvironment("prod")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"prod-eu"}, parent.Extenders)
+}
+
+func TestUpdateEnvironment_ExtendsCycle(t *testing.T) {
+	setupInheritanceProject(t)
+	extends := "prod-eu"
+
+	err := UpdateEnvironment("prod", nil, nil, nil, &extends)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "environment inheritance cycle: prod -> prod-eu -> prod")
+	configFile, err := config.ReadConfigFile(".")
+	assert.NoError(t, err)
+	assert.Empty(t, configFile.Environments["prod"].Extends)
+}
+
+func TestUpdateEnvironment_RenameKeepsExtenders(t *testing.T) {
+	setupInheritanceProject(t)
+	newName := "production"
+
+	err := UpdateEnvironment("prod", &newName, nil, nil, nil)
+
+	assert.NoError(t, err)
+	configFile, err := config.ReadConfigFile(".")
+	assert.NoError(t, err)
+	assert.Equal(t, "production", configFile.Environments["prod-eu"].Extends)
+}
+
+func TestRemoveEnvironment_Extended(t *testing.T) {
+	setupInheritanceProject(t)
+
+	err := RemoveEnvironment("prod", false)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "environment 'prod' is extended by 'prod-eu'")
+
+	err = RemoveEnvironment("prod", true)
+	assert.NoError(t, err)
+	configFile, err := config.ReadConfigFile(".")
+	assert.NoError(t, err)
+	assert.Equal(t, config.Environment{Name: "prod-eu", Profile: "prod-profile", Region: "eu-west-1"}, configFile.Environments["prod-eu"])
+}
+
+func TestResolveValues_Inherited(t *testing.T) {
+	setupInheritanceProject(t)
+	parentDir := filepath.Join(ProjectDir, EnvironmentsDir, "prod")
+	childDir := filepath.Join(ProjectDir, EnvironmentsDir, "prod-eu")
+	assert.NoError(t, os.WriteFile(filepath.Join(parentDir, "params.json"), []byte(`{"Env": "prod", "Size": "large"}`), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(childDir, "params.json"), []byte(`{"Size": "medium"}`), 0644))
+
+	values, err := ResolveValues("prod-eu", "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]ResolvedValue{
+		"Env":  {Value: "prod", Source: "environments/prod/params.json"},
+		"Size": {Value: "medium", Source: "environments/prod-eu/params.json"},
+	}, values.Parameters)
+}
+
+func TestAddEnvironments_UnknownParent(t *testing.T) {
+	setupTestProject(t)
+
+	err := AddEnvironments([]internal.EnvironmentConfig{{Name: "prod-eu", Extends: "prod"}})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "extends unknown environment 'prod'")
+	assert.NoDirExists(t, filepath.Join(ProjectDir, EnvironmentsDir, "prod-eu"))
+}
+
+func TestAddEnvironments_Cycle(t *testing.T) {
+	setupTestProject(t)
+
+	err := AddEnvironments([]internal.EnvironmentConfig{
+		{Name: "a", Extends: "b"},
+		{Name: "b", Extends: "a"},
+	})
+
+	assert.EqualError(t, err, "environment inheritance cycle: a -> b -> a")
+	assert.NoDirExists(t, filepath.Join(ProjectDir, EnvironmentsDir, "a"))
+	configFile, err := config.ReadConfigFile(".")
+	assert.NoError(t, err)
+	assert.Empty(t, configFile.Environments)
+}
+
+func TestAddEnvironments_InheritedProfileRequired(t *testing.T) {
+	setupTestProject(t)
+
+	err := AddEnvironments([]internal.EnvironmentConfig{
+		{Name: "prod-eu", Extends: "prod"},
+		{Name: "prod", Region: "us-east-1"},
+	})
+
+	assert.EqualError(t, err, "aws profile is required for environment 'prod-eu'")
+	assert.NoDirExists(t, filepath.Join(ProjectDir, EnvironmentsDir, "prod"))
+}
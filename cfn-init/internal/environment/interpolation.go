@@ -0,0 +1,189 @@
+package environment
+
+import (
+	"cfn-init/internal/config"
+	"cfn-init/internal/interpolate"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// InterpolationError lists every reference in an environment's values that
+// cannot be resolved, each prefixed with its file, line and column.
+type InterpolationError struct {
+	Environment string
+	Problems    []string
+}
+
+func (e *InterpolationError) Error() string {
+	return fmt.Sprintf("environment '%s' has unresolved references:\n  %s", e.Environment, strings.Join(e.Problems, "\n  "))
+}
+
+// RenderValues resolves an environment's effective values like ResolveValues
+// and expands the ${...} references in them.
+func RenderValues(envName, stackName string) (*EffectiveValues, error) {
+	values, err := ResolveValues(envName, stackName)
+	if err != nil {
+		return nil, err
+	}
+	if err := values.interpolate(); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// interpolate expands the references in the values in place. Parameters may
+// reference variables and other parameters; tags may reference both as well.
+// Available variables are project.name, env.name, env.profile, env.region,
+// stack.name when a stack is given, and os.NAME for the OS environment
+// variables listed in allowedEnvVars. Every parameter and tag that cannot be
+// expanded is reported in one InterpolationError.
+func (v *EffectiveValues) interpolate() error {
+	configFile, err := config.ReadConfigFile(".")
+	if err != nil {
+		return err
+	}
+	env, err := configFile.ResolveEnvironment(v.Environment)
+	if err != nil {
+		return err
+	}
+
+	variables := map[string]string{
+		"project.name": configFile.Project.Name,
+		"env.name":     v.Environment,
+		"env.profile":  env.Profile,
+		"env.region":   env.Region,
+	}
+	if v.Stack != "" {
+		variables["stack.name"] = v.Stack
+	}
+
+	expander := interpolate.New(variables, v.ParameterValues())
+	expander.Lookup = func(variable string) (string, bool, string) {
+		name, ok := strings.CutPrefix(variable, "os.")
+		if !ok {
+			return "", false, ""
+		}
+		if !slices.Contains(configFile.AllowedEnvVars, name) {
+			return "", false, fmt.Sprintf("environment variable '%s' is not in allowedEnvVars", name)
+		}
+		value, set := os.LookupEnv(name)
+		if !set {
+			return "", false, fmt.Sprintf("environment variable '%s' is not set", name)
+		}
+		return value, true, ""
+	}
+
+	var problems []string
+	reported := make(map[*interpolate.Error]bool)
+	report := func(err error, parameter bool) {
+		ierr, ok := err.(*interpolate.Error)
+		if !ok || reported[ierr] {
+			return
+		}
+		reported[ierr] = true
+
+		kind, values := "tag", v.Tags
+		if parameter {
+			kind, values = "parameter", v.Parameters
+		}
+		source := values[ierr.Name].Source
+		line, column := referencePosition(source, parameter, ierr.Name, ierr.Offset)
+		problems = append(problems, fmt.Sprintf("%s:%d:%d: %s '%s': %s", source, line, column, kind, ierr.Name, ierr.Message))
+	}
+
+	for _, name := range sortedKeys(v.Parameters) {
+		value, err := expander.Parameter(name)
+		if err != nil {
+			report(err, true)
+			continue
+		}
+		v.Parameters[name] = ResolvedValue{Value: value, Source: v.Parameters[name].Source}
+	}
+
+	for _, name := range sortedKeys(v.Tags) {
+		value, err := expander.Expand(name, v.Tags[name].Value)
+		if err != nil {
+			report(err, false)
+			continue
+		}
+		v.Tags[name] = ResolvedValue{Value: value, Source: v.Tags[name].Source}
+	}
+	if len(problems) > 0 {
+		return &InterpolationError{Environment: v.Environment, Problems: problems}
+	}
+	return nil
+}
+
+// referencePosition finds the line and column of a reference in the file a
+// value came from. It returns zeros when the value cannot be located.
+func referencePosition(source string, parameter bool, name string, offset int) (int, int) {
+	data, err := os.ReadFile(filepath.Join(ProjectDir, filepath.FromSlash(source)))
+	if err != nil {
+		return 0, 0
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil || len(root.Content) == 0 {
+		return 0, 0
+	}
+
+	section, keyField, valueField := "tags", "Key", "Value"
+	if parameter {
+		section, keyField, valueField = "parameters", "ParameterKey", "ParameterValue"
+	}
+
+	node := root.Content[0]
+	if defaults := childNode(node, "defaults"); defaults != nil && source == ConfigFile {
+		node = defaults
+	}
+	if nested := childNode(node, section); nested != nil {
+		node = nested
+	}
+
+	var value *yaml.Node
+	switch node.Kind {
+	case yaml.MappingNode:
+		value = childNode(node, name)
+	case yaml.SequenceNode:
+		for _, entry := range node.Content {
+			if key := childNode(entry, keyField); key != nil && key.Value == name {
+				value = childNode(entry, valueField)
+			}
+		}
+	}
+	if value == nil {
+		return 0, 0
+	}
+
+	column := value.Column + offset
+	if value.Style == yaml.DoubleQuotedStyle || value.Style == yaml.SingleQuotedStyle {
+		column++
+	}
+	return value.Line, column
+}
+
+func childNode(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
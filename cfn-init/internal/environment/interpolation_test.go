@@ -0,0 +1,99 @@
+package environment
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cfn-init/internal/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderValues_ExpandsReferences(t *testing.T) {
+	projectDir := setupTestProject(t)
+	assert.NoError(t, addEnvironment("prod", "prod-profile", "eu-west-1"))
+	t.Setenv("BUILD_ID", "42")
+
+	configFile, err := config.ReadConfigFile(".")
+	assert.NoError(t, err)
+	configFile.AllowedEnvVars = []string{"BUILD_ID"}
+	configFile.Defaults = &config.Defaults{
+		Parameters: map[string]string{"Bucket": "${project.name}-${env.name}-bucket"},
+		Tags:       map[string]string{"bucket": "${param.Bucket}"},
+	}
+	assert.NoError(t, config.WriteConfigFile(".", configFile))
+
+	envDir := filepath.Join(projectDir, EnvironmentsDir, "prod")
+	assert.NoError(t, os.WriteFile(filepath.Join(envDir, "params.json"),
+		[]byte(`{"Region": "${env.region}", "Build": "${os.BUILD_ID}", "Literal": "$${env.name}"}`), 0644))
+
+	values, err := RenderValues("prod", "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"Bucket":  "test-project-prod-bucket",
+		"Region":  "eu-west-1",
+		"Build":   "42",
+		"Literal": "${env.name}",
+	}, values.ParameterValues())
+	assert.Equal(t, map[string]string{"bucket": "test-project-prod-bucket"}, values.TagValues())
+	assert.Equal(t, ConfigFile, values.Parameters["Bucket"].Source)
+
+	raw, err := ResolveValues("prod", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "${env.region}", raw.Parameters["Region"].Value)
+}
+
+func TestRenderValues_ReportsPositions(t *testing.T) {
+	projectDir := setupTestProject(t)
+	assert.NoError(t, addEnvironment("prod", "prod-profile", ""))
+
+	envDir := filepath.Join(projectDir, EnvironmentsDir, "prod")
+	assert.NoError(t, os.WriteFile(filepath.Join(envDir, "params.yaml"), []byte(
+		"Name: app-${env.nme}\nA: ${param.B}\nB: \"x${param.A}\"\nHome: ${os.HOME}\n"), 0644))
+
+	_, err := RenderValues("prod", "")
+
+	assert.Equal(t, &InterpolationError{Environment: "prod", Problems: []string{
+		"environments/prod/params.yaml:3:6: parameter 'B': reference cycle: A -> B -> A",
+		"environments/prod/params.yaml:4:7: parameter 'Home': environment variable 'HOME' is not in allowedEnvVars",
+		"environments/prod/params.yaml:1:11: parameter 'Name': undefined variable 'env.nme'",
+	}}, err)
+
+	_, err = LoadParameters("prod", "")
+	assert.Error(t, err)
+}
+
+func TestRenderValues_CLIFormatPosition(t *testing.T) {
+	projectDir := setupTestProject(t)
+	assert.NoError(t, addEnvironment("dev", "dev-profile", ""))
+
+	envDir := filepath.Join(projectDir, EnvironmentsDir, "dev")
+	assert.NoError(t, os.WriteFile(filepath.Join(envDir, "params.json"), []byte(
+		"[\n  {\"ParameterKey\": \"Stack\", \"ParameterValue\": \"${stack.name}\"}\n]\n"), 0644))
+
+	_, err := RenderValues("dev", "")
+
+	assert.EqualError(t, err, "environment 'dev' has unresolved references:\n  environments/dev/params.json:2:48: parameter 'Stack': undefined variable 'stack.name'")
+}
+
+func TestRenderValues_ReportsParametersAndTags(t *testing.T) {
+	projectDir := setupTestProject(t)
+	assert.NoError(t, addEnvironment("prod", "prod-profile", ""))
+
+	configFile, err := config.ReadConfigFile(".")
+	assert.NoError(t, err)
+	configFile.Defaults = &config.Defaults{Tags: map[string]string{"owner": "${env.owner}"}}
+	assert.NoError(t, config.WriteConfigFile(".", configFile))
+	envDir := filepath.Join(projectDir, EnvironmentsDir, "prod")
+	assert.NoError(t, os.WriteFile(filepath.Join(envDir, "params.json"), []byte(`{"Name": "${env.nme}"}`), 0644))
+
+	_, err = RenderValues("prod", "")
+
+	var interpolationErr *InterpolationError
+	assert.ErrorAs(t, err, &interpolationErr)
+	assert.Len(t, interpolationErr.Problems, 2)
+	assert.Contains(t, interpolationErr.Problems[0], "parameter 'Name': undefined variable 'env.nme'")
+	assert.Contains(t, interpolationErr.Problems[1], "tag 'owner': undefined variable 'env.owner'")
+}
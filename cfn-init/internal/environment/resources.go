@@ -0,0 +1,226 @@
+package environment
+
+import (
+	"cfn-init/internal/classify"
+	"cfn-init/internal/config"
+	"cfn-init/internal/gitsync"
+	"cfn-init/internal/tags"
+	"cfn-init/internal/template"
+	"fmt"
+	"path/filepath"
+	"sort"
+)
+
+// InventoryEntry describes a resource or output and whether it exists in an environment.
+type InventoryEntry struct {
+	Name      string
+	Type      string
+	Condition string
+	Status    template.Truth
+}
+
+// Inventory lists the resources and outputs a template produces in an environment.
+type Inventory struct {
+	Environment string
+	Template    string
+	Resources   []InventoryEntry
+	Outputs     []InventoryEntry
+}
+
+// InventoryDiffEntry describes a resource or output whose existence differs between environments.
+type InventoryDiffEntry struct {
+	Name  string
+	Type  string
+	Left  template.Truth
+	Right template.Truth
+}
+
+// InventoryDiff holds the differences between the inventories of two environments.
+type InventoryDiff struct {
+	Left      string
+	Right     string
+	Resources []InventoryDiffEntry
+	Outputs   []InventoryDiffEntry
+}
+
+// BuildInventory evaluates the template's conditions with the environment's
+// parameters and reports which resources and outputs would exist.
+func BuildInventory(envName, stackName, templatePath string) (*Inventory, error) {
+	tmpl, err := LoadTemplate(stackName, templatePath)
+	if err != nil {
+		return nil, err
+	}
+
+	evaluator, err := NewEvaluator(envName, stackName, tmpl)
+	if err != nil {
+		return nil, err
+	}
+
+	inventory := &Inventory{Environment: envName, Template: tmpl.Path}
+
+	for _, name := range template.SortedKeys(tmpl.Resources) {
+		entry, err := inventoryEntry(evaluator, name, tmpl.ResourceType(name), tmpl.ResourceCondition(name))
+		if err != nil {
+			return nil, fmt.Errorf("resource '%s': %w", name, err)
+		}
+		inventory.Resources = append(inventory.Resources, entry)
+	}
+
+	for _, name := range template.SortedKeys(tmpl.Outputs) {
+		entry, err := inventoryEntry(evaluator, name, "", tmpl.OutputCondition(name))
+		if err != nil {
+			return nil, fmt.Errorf("output '%s': %w", name, err)
+		}
+		inventory.Outputs = append(inventory.Outputs, entry)
+	}
+
+	return inventory, nil
+}
+
+// LoadTemplate loads the template at templatePath, or the template of the
+// named stack when no path is given.
+func LoadTemplate(stackName, templatePath string) (*template.Template, error) {
+	if templatePath != "" {
+		return template.Load(templatePath)
+	}
+	if stackName == "" {
+		return nil, fmt.Errorf("template path or stack name is required")
+	}
+
+	configFile, err := config.ReadConfigFile(".")
+	if err != nil {
+		return nil, err
+	}
+	s, exists := configFile.Stacks[stackName]
+	if !exists {
+		return nil, fmt.Errorf("stack '%s' not found", stackName)
+	}
+	return template.Load(filepath.FromSlash(s.Template))
+}
+
+// NewEvaluator creates a condition and intrinsic function evaluator for a
+// template using the environment's parameters and region. When a stack is
+// given, its scoped parameters files apply and AWS::StackName is known.
+func NewEvaluator(envName, stackName string, tmpl *template.Template) (*template.Evaluator, error) {
+	if !projectExists() {
+		return nil, fmt.Errorf("project directory not found")
+	}
+
+	configFile, err := getEnvironmentConfig(envName)
+	if err != nil {
+		return nil, err
+	}
+
+	params, err := LoadParameters(envName, stackName)
+	if err != nil {
+		return nil, err
+	}
+
+	if s, exists := configFile.Stacks[stackName]; exists {
+		params["AWS::StackName"] = s.StackName(configFile.Project.Name, envName)
+	}
+
+	env, err := configFile.ResolveEnvironment(envName)
+	if err != nil {
+		return nil, err
+	}
+	return template.NewEvaluator(tmpl, params, env.Region), nil
+}
+
+// DiffInventories compares two inventories and returns the entries whose existence differs.
+func DiffInventories(left, right *Inventory) *InventoryDiff {
+	return &InventoryDiff{
+		Left:      left.Environment,
+		Right:     right.Environment,
+		Resources: diffEntries(left.Resources, right.Resources),
+		Outputs:   diffEntries(left.Outputs, right.Outputs),
+	}
+}
+
+// LoadParameters collects the effective parameter values of an environment:
+// the project defaults, overridden by the environment's parameters files and,
+// when a stack is given, by the files in the environment's folder for that stack.
+// References to variables and other parameters are expanded.
+func LoadParameters(envName, stackName string) (map[string]string, error) {
+	values, err := resolveValues(envName, stackName, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := values.interpolate(); err != nil {
+		return nil, err
+	}
+	return values.ParameterValues(), nil
+}
+
+// LoadTags collects the effective tags of an environment, with the same
+// layering as LoadParameters.
+func LoadTags(envName, stackName string) (map[string]string, error) {
+	values, err := resolveValues(envName, stackName, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := values.interpolate(); err != nil {
+		return nil, err
+	}
+	return values.TagValues(), nil
+}
+
+// StackPath returns the folder holding an environment's files for a stack,
+// or the environment folder itself when no stack is given.
+func StackPath(envName, stackName string) string {
+	if stackName == "" {
+		return getEnvironmentPath(envName)
+	}
+	return filepath.Join(getEnvironmentPath(envName), stackName)
+}
+
+func readTags(path string) (map[string]string, error) {
+	if kind, _ := classify.File(path); kind == classify.KindGitSync {
+		deployment, err := gitsync.ReadFile(path, "")
+		if err != nil {
+			return nil, err
+		}
+		return deployment.Tags, nil
+	}
+	fileTags, err := tags.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return tags.ToMap(fileTags), nil
+}
+
+func inventoryEntry(evaluator *template.Evaluator, name, entryType, condition string) (InventoryEntry, error) {
+	entry := InventoryEntry{Name: name, Type: entryType, Condition: condition, Status: template.True}
+	if condition == "" {
+		return entry, nil
+	}
+	status, err := evaluator.Condition(condition)
+	if err != nil {
+		return entry, err
+	}
+	entry.Status = status
+	return entry, nil
+}
+
+func diffEntries(left, right []InventoryEntry) []InventoryDiffEntry {
+	merged := make(map[string]*InventoryDiffEntry)
+	for _, entry := range left {
+		merged[entry.Name] = &InventoryDiffEntry{Name: entry.Name, Type: entry.Type, Left: entry.Status, Right: template.False}
+	}
+	for _, entry := range right {
+		if existing, ok := merged[entry.Name]; ok {
+			existing.Right = entry.Status
+			continue
+		}
+		merged[entry.Name] = &InventoryDiffEntry{Name: entry.Name, Type: entry.Type, Left: template.False, Right: entry.Status}
+	}
+
+	var diffs []InventoryDiffEntry
+	for _, entry := range merged {
+		if entry.Left != entry.Right {
+			diffs = append(diffs, *entry)
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Name < diffs[j].Name })
+	return diffs
+}
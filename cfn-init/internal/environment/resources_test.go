@@ -0,0 +1,148 @@
+package environment
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cfn-init/internal/config"
+	"cfn-init/internal/template"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const inventoryTemplate = `
+Parameters:
+  Env:
+    Type: String
+Conditions:
+  IsProd: !Equals [!Ref Env, prod]
+Resources:
+  Bucket:
+    Type: AWS::S3::Bucket
+  Alarm:
+    Type: AWS::CloudWatch::Alarm
+    Condition: IsProd
+Outputs:
+  AlarmName:
+    Condition: IsProd
+    Value: !Ref Alarm
+`
+
+func setupInventoryProject(t *testing.T) string {
+	projectDir := setupTestProject(t)
+
+	for env, value := range map[string]string{"dev": "dev", "prod": "prod"} {
+		assert.NoError(t, addEnvironment(env, env+"-profile", ""))
+		params := `[{"ParameterKey": "Env", "ParameterValue": "` + value + `"}]`
+		err := os.WriteFile(filepath.Join(projectDir, "environments", env, "params.json"), []byte(params), 0644)
+		assert.NoError(t, err)
+	}
+
+	templatePath := filepath.Join(filepath.Dir(projectDir), "template.yaml")
+	assert.NoError(t, os.WriteFile(templatePath, []byte(inventoryTemplate), 0644))
+	return templatePath
+}
+
+func TestBuildInventory(t *testing.T) {
+	templatePath := setupInventoryProject(t)
+
+	inventory, err := BuildInventory("dev", "", templatePath)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []InventoryEntry{
+		{Name: "Alarm", Type: "AWS::CloudWatch::Alarm", Condition: "IsProd", Status: template.False},
+		{Name: "Bucket", Type: "AWS::S3::Bucket", Status: template.True},
+	}, inventory.Resources)
+	assert.Equal(t, template.False, inventory.Outputs[0].Status)
+}
+
+func TestBuildInventory_EnvironmentNotFound(t *testing.T) {
+	templatePath := setupInventoryProject(t)
+
+	_, err := BuildInventory("staging", "", templatePath)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not found")
+}
+
+func TestDiffInventories(t *testing.T) {
+	templatePath := setupInventoryProject(t)
+
+	dev, err := BuildInventory("dev", "", templatePath)
+	assert.NoError(t, err)
+	prod, err := BuildInventory("prod", "", templatePath)
+	assert.NoError(t, err)
+
+	diff := DiffInventories(prod, dev)
+
+	assert.Equal(t, []InventoryDiffEntry{
+		{Name: "Alarm", Type: "AWS::CloudWatch::Alarm", Left: template.True, Right: template.False},
+	}, diff.Resources)
+	assert.Len(t, diff.Outputs, 1)
+}
+
+func TestLoadParameters_SkipsTagFiles(t *testing.T) {
+	projectDir := setupTestProject(t)
+	assert.NoError(t, addEnvironment("dev", "dev-profile", ""))
+
+	envDir := filepath.Join(projectDir, "environments", "dev")
+	assert.NoError(t, os.WriteFile(filepath.Join(envDir, "params.yaml"), []byte("Env: dev\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(envDir, "tags.yaml"), []byte("Team: core\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(envDir, "tags.json"), []byte(`[{"Key": "Team", "Value": "core"}]`), 0644))
+
+	values, err := LoadParameters("dev", "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"Env": "dev"}, values)
+}
+
+func TestLoadParameters_StackOverridesEnvironment(t *testing.T) {
+	projectDir := setupTestProject(t)
+	assert.NoError(t, addEnvironment("dev", "dev-profile", ""))
+
+	envDir := filepath.Join(projectDir, "environments", "dev")
+	assert.NoError(t, os.MkdirAll(filepath.Join(envDir, "network"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(envDir, "params.yaml"), []byte("Env: dev\nSize: small\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(envDir, "network", "params.yaml"), []byte("Size: large\n"), 0644))
+
+	values, err := LoadParameters("dev", "network")
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"Env": "dev", "Size": "large"}, values)
+}
+
+func TestAddStackFiles_StackNotFound(t *testing.T) {
+	setupTestProject(t)
+	assert.NoError(t, addEnvironment("dev", "dev-profile", ""))
+
+	err := AddStackFiles("dev", "network", nil, nil, nil)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "stack 'network' not found")
+}
+
+func TestAddStackFiles_RecordsParametersFiles(t *testing.T) {
+	projectDir := setupTestProject(t)
+	assert.NoError(t, addEnvironment("dev", "dev-profile", ""))
+
+	first := filepath.Join(projectDir, "..", "base.json")
+	second := filepath.Join(projectDir, "..", "override.json")
+	assert.NoError(t, os.WriteFile(first, []byte(`{"Size": "small"}`), 0644))
+	assert.NoError(t, os.WriteFile(second, []byte(`{"Size": "large"}`), 0644))
+
+	assert.NoError(t, AddStackFiles("dev", "", []string{second, first}, nil, nil))
+	_, err := AddFilesWithOptions("dev", []string{second}, nil, nil, FileOptions{OnConflict: OnConflictOverwrite})
+	assert.NoError(t, err)
+
+	configFile, err := config.ReadConfigFile(".")
+	assert.NoError(t, err)
+	files := configFile.Environments["dev"].Files
+	assert.Len(t, files, 2)
+	assert.Equal(t, "override.json", files[0].Path)
+	assert.Equal(t, "base.json", files[1].Path)
+
+	values, err := LoadParameters("dev", "")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"Size": "small"}, values)
+}
@@ -0,0 +1,104 @@
+package environment
+
+import (
+	"cfn-init/internal/config"
+	"cfn-init/internal/secrets"
+	"cfn-init/internal/template"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// SecretsError reports the plaintext secrets found in files before they were
+// written to an environment.
+type SecretsError struct {
+	Findings []secrets.Finding
+}
+
+func (e *SecretsError) Error() string {
+	return fmt.Sprintf("found %d possible plaintext secret(s); replace them with dynamic references or use --allow-secrets", len(e.Findings))
+}
+
+// ScanSecrets scans the parameters and GitSync files of an environment,
+// including its stack folders, for plaintext secrets.
+func ScanSecrets(envName string) ([]secrets.Finding, error) {
+	configFile, files, err := environmentParameterFiles(envName)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []secrets.Finding
+	for _, stackName := range stackScopes(configFile) {
+		stackFindings, err := scanFiles(configFile, stackName, files[stackName])
+		if err != nil {
+			return nil, err
+		}
+		findings = append(findings, stackFindings...)
+	}
+	return findings, nil
+}
+
+// ScanFilesForSecrets scans parameters or GitSync files before they are added
+// to an environment, using the NoEcho parameters of the stack's template, or
+// of every stack's template when no stack is given.
+func ScanFilesForSecrets(stackName string, files []string) ([]secrets.Finding, error) {
+	if !projectExists() {
+		return nil, fmt.Errorf("project directory not found")
+	}
+	configFile, err := config.ReadConfigFile(".")
+	if err != nil {
+		return nil, err
+	}
+
+	// Missing and unsupported files are reported when they are added
+	var existing []string
+	for _, file := range files {
+		if _, err := os.Stat(file); err == nil && validateFileType(file) {
+			existing = append(existing, file)
+		}
+	}
+	return scanFiles(configFile, stackName, existing)
+}
+
+func scanFiles(configFile *config.ProjectConfig, stackName string, files []string) ([]secrets.Finding, error) {
+	if len(files) == 0 {
+		return nil, nil
+	}
+	noEcho, err := noEchoParameters(configFile, stackName)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []secrets.Finding
+	for _, file := range files {
+		fileFindings, err := secrets.ScanFile(file, noEcho)
+		if err != nil {
+			return nil, err
+		}
+		findings = append(findings, fileFindings...)
+	}
+	return findings, nil
+}
+
+func noEchoParameters(configFile *config.ProjectConfig, stackName string) (map[string]bool, error) {
+	stacks := configFile.Stacks
+	if stackName != "" {
+		s, exists := configFile.Stacks[stackName]
+		if !exists {
+			return nil, fmt.Errorf("stack '%s' not found", stackName)
+		}
+		stacks = map[string]config.Stack{stackName: s}
+	}
+
+	names := make(map[string]bool)
+	for name, s := range stacks {
+		tmpl, err := template.Load(filepath.FromSlash(s.Template))
+		if err != nil {
+			return nil, fmt.Errorf("stack '%s': %w", name, err)
+		}
+		for param := range tmpl.NoEchoParameters() {
+			names[param] = true
+		}
+	}
+	return names, nil
+}
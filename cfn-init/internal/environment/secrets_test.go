@@ -0,0 +1,53 @@
+package environment
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cfn-init/internal/config"
+	"cfn-init/internal/secrets"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const noEchoTemplate = `
+Parameters:
+  AdminUser:
+    Type: String
+    NoEcho: true
+Resources:
+  Bucket:
+    Type: AWS::S3::Bucket
+`
+
+func TestScanSecrets(t *testing.T) {
+	setupTestProject(t)
+	assert.NoError(t, os.WriteFile("app.yaml", []byte(noEchoTemplate), 0644))
+	configFile, err := config.ReadConfigFile(".")
+	assert.NoError(t, err)
+	configFile.Stacks = map[string]config.Stack{"app": {Name: "app", Template: "app.yaml"}}
+	assert.NoError(t, config.WriteConfigFile(".", configFile))
+	assert.NoError(t, addEnvironment("dev", "dev-profile", ""))
+	stackDir := StackPath("dev", "app")
+	assert.NoError(t, os.MkdirAll(stackDir, 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(stackDir, "params.json"), []byte(`{"AdminUser": "root", "Env": "dev"}`), 0644))
+
+	findings, err := ScanSecrets("dev")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []secrets.Finding{{
+		File:      filepath.Join(stackDir, "params.json"),
+		Parameter: "AdminUser",
+		Reasons:   []string{secrets.ReasonNoEcho},
+	}}, findings)
+}
+
+func TestScanFilesForSecrets_SkipsMissingFiles(t *testing.T) {
+	setupTestProject(t)
+
+	findings, err := ScanFilesForSecrets("", []string{"missing.json"})
+
+	assert.NoError(t, err)
+	assert.Empty(t, findings)
+}
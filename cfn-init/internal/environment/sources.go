@@ -0,0 +1,379 @@
+package environment
+
+import (
+	"cfn-init/internal/classify"
+	"cfn-init/internal/config"
+	"cfn-init/internal/encryption"
+	"cfn-init/internal/parameters"
+	"cfn-init/internal/secrets"
+	"cfn-init/internal/textdiff"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Statuses of a copied file compared with its source.
+const (
+	FileCurrent        = "up to date"
+	FileSourceModified = "source modified"
+	FileSourceMissing  = "source missing"
+	FileCopyModified   = "copy modified"
+	FileCopyMissing    = "copy missing"
+	FileConflict       = "conflict"
+)
+
+// FileStatus compares a copied environment file with its source.
+type FileStatus struct {
+	Environment string
+	Path        string
+	Source      string
+	Mode        string
+	Status      string
+}
+
+// FileChange is a copied file that a sync would update, with the unified
+// diff from the copy to the source.
+type FileChange struct {
+	FileStatus
+	Diff string
+}
+
+// FilesStatus reports the added files of an environment, or of every
+// environment when envName is empty. Files without a source are skipped. A source is modified when its content
+// differs from the recorded hash; a copy is modified when it was edited
+// after the last copy, sync or rewrite by the tool, such as encrypting its
+// values. Both being modified is a conflict.
+func FilesStatus(envName string) ([]FileStatus, error) {
+	configFile, envNames, err := trackedEnvironments(envName)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []FileStatus
+	for _, name := range envNames {
+		for _, file := range configFile.Environments[name].Files {
+			if file.Source == "" {
+				continue
+			}
+			statuses = append(statuses, fileStatus(name, file))
+		}
+	}
+	return statuses, nil
+}
+
+// SyncFiles copies updated sources over their copies. Linked and referenced
+// files always match their sources and are skipped. Copies that were
+// edited locally are only overwritten with force. Updated sources are
+// checked like files being added: they must pass their category's validator
+// and, unless allowSecrets is set, hold no plaintext secrets, which are
+// reported in a *SecretsError. confirm receives the pending changes with
+// their diffs and may return false to cancel the sync. Parameters that are
+// encrypted in a copy are encrypted again with key after the sync. The
+// applied changes are returned.
+func SyncFiles(envName string, force, allowSecrets bool, key []byte, confirm func([]FileChange) bool) ([]FileChange, error) {
+	statuses, err := FilesStatus(envName)
+	if err != nil {
+		return nil, err
+	}
+	configFile, err := config.ReadConfigFile(".")
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []FileChange
+	var findings []secrets.Finding
+	encrypted := make(map[FileStatus]map[string]bool)
+	for _, status := range statuses {
+		if status.Mode != ModeCopy {
+			continue
+		}
+		switch status.Status {
+		case FileSourceModified, FileCopyMissing:
+		case FileCopyModified, FileConflict:
+			if !force {
+				continue
+			}
+		default:
+			continue
+		}
+		copyPath := filepath.Join(getEnvironmentPath(status.Environment), filepath.FromSlash(status.Path))
+		current, _ := os.ReadFile(copyPath)
+		source, err := os.ReadFile(filepath.FromSlash(status.Source))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read source '%s': %w", status.Source, err)
+		}
+
+		sourceFindings, err := checkSource(configFile, status)
+		if err != nil {
+			return nil, err
+		}
+		findings = append(findings, sourceFindings...)
+
+		if names := encryptedNames(current); len(names) > 0 {
+			if key == nil {
+				return nil, fmt.Errorf("cannot sync %s: it has encrypted values and no key file was found to encrypt them again", copyPath)
+			}
+			encrypted[status] = names
+		}
+
+		diff := textdiff.Unified(filepath.ToSlash(copyPath), status.Source, string(current), string(source))
+		changes = append(changes, FileChange{FileStatus: status, Diff: diff})
+	}
+	if len(findings) > 0 && !allowSecrets {
+		return nil, &SecretsError{Findings: findings}
+	}
+	if len(changes) == 0 || !confirm(changes) {
+		return nil, nil
+	}
+
+	maxSize := maxFileSize(configFile)
+	for _, change := range changes {
+		dest := filepath.Join(getEnvironmentPath(change.Environment), filepath.FromSlash(change.Path))
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create environment directory: %w", err)
+		}
+		if err := copyFile(filepath.FromSlash(change.Source), dest, maxSize); err != nil {
+			return nil, fmt.Errorf("failed to sync %s: %w", change.Path, err)
+		}
+		if names := encrypted[change.FileStatus]; len(names) > 0 {
+			_, err := parameters.Rewrite(dest, func(name, value string) (string, error) {
+				if !names[name] || value == "" || encryption.IsEncrypted(value) || strings.HasPrefix(value, secrets.DynamicReferencePrefix) {
+					return value, nil
+				}
+				return encryption.Encrypt(key, name, value)
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to encrypt %s again: %w", change.Path, err)
+			}
+		}
+		sourceHash, err := hashFile(filepath.FromSlash(change.Source))
+		if err != nil {
+			return nil, err
+		}
+		copyHash, err := hashFile(dest)
+		if err != nil {
+			return nil, err
+		}
+
+		env := configFile.Environments[change.Environment]
+		if index := trackedIndex(env, change.Path); index >= 0 {
+			env.Files[index].SHA256 = sourceHash
+			env.Files[index].CopySHA256 = rewrittenHash(sourceHash, copyHash)
+		}
+		configFile.Environments[change.Environment] = env
+	}
+	if err := config.WriteConfigFile(".", configFile); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// checkSource checks the source of a copied file the way the file was
+// checked when it was added: against its category's validator and, for
+// parameters and GitSync files, for plaintext secrets, which are returned.
+func checkSource(configFile *config.ProjectConfig, status FileStatus) ([]secrets.Finding, error) {
+	env := configFile.Environments[status.Environment]
+	tracked := config.TrackedFile{Path: status.Path}
+	if index := trackedIndex(env, status.Path); index >= 0 {
+		tracked = env.Files[index]
+	}
+	source := filepath.FromSlash(status.Source)
+	categoryName := tracked.Category
+	if categoryName == "" {
+		kind, err := classify.File(source)
+		if err != nil {
+			return nil, err
+		}
+		categoryName = fileCategory(kind)
+	}
+	if categoryName == "" {
+		return nil, nil
+	}
+
+	category, err := lookupCategory(configFile, categoryName)
+	if err != nil {
+		return nil, err
+	}
+	stackName := trackedStack(configFile, tracked)
+	if category.Validate != nil {
+		if err := category.Validate(source, status.Environment, stackName); err != nil {
+			return nil, fmt.Errorf("cannot sync %s: %w", status.Path, err)
+		}
+	}
+	if categoryName != CategoryParameters && categoryName != CategoryGitSync {
+		return nil, nil
+	}
+	return scanFiles(configFile, stackName, []string{source})
+}
+
+// trackFiles records the categories and sources of files just added to an
+// environment's folder, replacing earlier records of the same files. Copies
+// also record their content hash.
+func trackFiles(envName, stackName, mode string, files []*placement) error {
+	configFile, err := getEnvironmentConfig(envName)
+	if err != nil {
+		return err
+	}
+
+	env := configFile.Environments[envName]
+	for _, file := range files {
+		path := filepath.ToSlash(filepath.Join(stackName, file.name))
+		tracked := config.TrackedFile{Path: path, Category: file.category, Source: sourcePath(file.source)}
+		if mode == ModeCopy {
+			tracked.SHA256, err = hashFile(filepath.Join(StackPath(envName, stackName), file.name))
+			if err != nil {
+				return err
+			}
+		} else {
+			tracked.Mode = mode
+		}
+
+		index := slices.IndexFunc(env.Files, func(f config.TrackedFile) bool { return f.Path == path })
+		if index >= 0 {
+			env.Files[index] = tracked
+		} else {
+			env.Files = append(env.Files, tracked)
+		}
+	}
+	configFile.Environments[envName] = env
+	return config.WriteConfigFile(".", configFile)
+}
+
+func fileStatus(envName string, file config.TrackedFile) FileStatus {
+	mode, _ := fileMode(file.Mode)
+	status := FileStatus{Environment: envName, Path: file.Path, Source: file.Source, Mode: mode, Status: FileCurrent}
+	copyPath := filepath.Join(getEnvironmentPath(envName), filepath.FromSlash(file.Path))
+
+	sourceHash, err := hashFile(filepath.FromSlash(file.Source))
+	if err != nil {
+		status.Status = FileSourceMissing
+		return status
+	}
+	switch mode {
+	case ModeReference:
+		return status
+	case ModeSymlink:
+		if _, err := os.Lstat(copyPath); err != nil {
+			status.Status = FileCopyMissing
+		}
+		return status
+	}
+	copyHash, err := hashFile(copyPath)
+	if err != nil {
+		status.Status = FileCopyMissing
+		return status
+	}
+
+	expected := file.SHA256
+	if file.CopySHA256 != "" {
+		expected = file.CopySHA256
+	}
+	sourceChanged, copyChanged := sourceHash != file.SHA256, copyHash != expected
+	switch {
+	case sourceChanged && copyChanged && sourceHash != copyHash:
+		status.Status = FileConflict
+	case sourceChanged && copyChanged:
+		// Both were changed the same way, so the copy is in sync
+	case sourceChanged:
+		status.Status = FileSourceModified
+	case copyChanged:
+		status.Status = FileCopyModified
+	}
+	return status
+}
+
+// sourcePath records a source relative to the repository root when it is
+// inside it, and as an absolute path otherwise.
+func sourcePath(file string) string {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return filepath.ToSlash(file)
+	}
+	if root, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(root, abs); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return filepath.ToSlash(abs)
+}
+
+func trackedEnvironments(envName string) (*config.ProjectConfig, []string, error) {
+	if !projectExists() {
+		return nil, nil, fmt.Errorf("project directory not found")
+	}
+	if envName != "" {
+		configFile, err := getEnvironmentConfig(envName)
+		return configFile, []string{envName}, err
+	}
+	configFile, err := config.ReadConfigFile(".")
+	if err != nil {
+		return nil, nil, err
+	}
+	return configFile, sortedKeys(configFile.Environments), nil
+}
+
+// recordRewrites records the new hash of the tracked copies among files,
+// which the tool rewrote in place, so that they are not reported as edited.
+// Files of other modes are their sources and are left alone.
+func recordRewrites(envName string, files []string) error {
+	configFile, err := getEnvironmentConfig(envName)
+	if err != nil {
+		return err
+	}
+	env := configFile.Environments[envName]
+	for _, file := range files {
+		rel, err := filepath.Rel(getEnvironmentPath(envName), file)
+		if err != nil || !insideFolder(rel) {
+			continue
+		}
+		index := trackedIndex(env, filepath.ToSlash(rel))
+		if index < 0 || env.Files[index].Source == "" {
+			continue
+		}
+		if mode, _ := fileMode(env.Files[index].Mode); mode != ModeCopy {
+			continue
+		}
+		hash, err := hashFile(file)
+		if err != nil {
+			return err
+		}
+		env.Files[index].CopySHA256 = rewrittenHash(env.Files[index].SHA256, hash)
+	}
+	configFile.Environments[envName] = env
+	return config.WriteConfigFile(".", configFile)
+}
+
+// rewrittenHash is the CopySHA256 to record for a copy: empty when the copy
+// matches the content of its source at the last sync.
+func rewrittenHash(sourceHash, copyHash string) string {
+	if copyHash == sourceHash {
+		return ""
+	}
+	return copyHash
+}
+
+// encryptedNames returns the parameters with encrypted values in parameters
+// or GitSync file content, or none when the content is neither.
+func encryptedNames(data []byte) map[string]bool {
+	names := make(map[string]bool)
+	parameters.RewriteContent(data, func(name, value string) (string, error) {
+		if encryption.IsEncrypted(value) {
+			names[name] = true
+		}
+		return value, nil
+	})
+	return names
+}
+
+func hashFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
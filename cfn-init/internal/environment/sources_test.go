@@ -0,0 +1,157 @@
+package environment
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cfn-init/internal/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupTrackedFile(t *testing.T) (string, string) {
+	t.Helper()
+	projectDir := setupTestProject(t)
+	assert.NoError(t, addEnvironment("dev", "dev-profile", ""))
+
+	assert.NoError(t, os.MkdirAll("shared", 0755))
+	source := filepath.Join("shared", "params.json")
+	assert.NoError(t, os.WriteFile(source, []byte("{\"Size\": \"small\"}\n"), 0644))
+	assert.NoError(t, AddStackFiles("dev", "", []string{source}, nil, nil))
+	return source, filepath.Join(projectDir, EnvironmentsDir, "dev", "params.json")
+}
+
+func TestAddStackFiles_TracksSources(t *testing.T) {
+	setupTrackedFile(t)
+
+	configFile, err := config.ReadConfigFile(".")
+
+	assert.NoError(t, err)
+	files := configFile.Environments["dev"].Files
+	assert.Len(t, files, 1)
+	assert.Equal(t, "params.json", files[0].Path)
+	assert.Equal(t, "shared/params.json", files[0].Source)
+	assert.Len(t, files[0].SHA256, 64)
+}
+
+func TestFilesStatus(t *testing.T) {
+	source, copyPath := setupTrackedFile(t)
+
+	statuses, err := FilesStatus("")
+	assert.NoError(t, err)
+	assert.Equal(t, []FileStatus{{Environment: "dev", Path: "params.json", Source: "shared/params.json", Mode: ModeCopy, Status: FileCurrent}}, statuses)
+
+	assert.NoError(t, os.WriteFile(source, []byte("{\"Size\": \"large\"}\n"), 0644))
+	statuses, _ = FilesStatus("dev")
+	assert.Equal(t, FileSourceModified, statuses[0].Status)
+
+	assert.NoError(t, os.WriteFile(copyPath, []byte("{\"Size\": \"medium\"}\n"), 0644))
+	statuses, _ = FilesStatus("dev")
+	assert.Equal(t, FileConflict, statuses[0].Status)
+
+	assert.NoError(t, os.Remove(source))
+	statuses, _ = FilesStatus("dev")
+	assert.Equal(t, FileSourceMissing, statuses[0].Status)
+}
+
+func TestSyncFiles(t *testing.T) {
+	source, copyPath := setupTrackedFile(t)
+	assert.NoError(t, os.WriteFile(source, []byte("{\"Size\": \"large\"}\n"), 0644))
+
+	changes, err := SyncFiles("dev", false, false, nil, func([]FileChange) bool { return false })
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+	data, _ := os.ReadFile(copyPath)
+	assert.Equal(t, "{\"Size\": \"small\"}\n", string(data))
+
+	var shown []FileChange
+	changes, err = SyncFiles("dev", false, false, nil, func(pending []FileChange) bool {
+		shown = pending
+		return true
+	})
+	assert.NoError(t, err)
+	assert.Len(t, changes, 1)
+	assert.Contains(t, shown[0].Diff, "-{\"Size\": \"small\"}\n+{\"Size\": \"large\"}\n")
+	data, _ = os.ReadFile(copyPath)
+	assert.Equal(t, "{\"Size\": \"large\"}\n", string(data))
+
+	statuses, _ := FilesStatus("dev")
+	assert.Equal(t, FileCurrent, statuses[0].Status)
+}
+
+func TestSyncFiles_LocalEditsNeedForce(t *testing.T) {
+	_, copyPath := setupTrackedFile(t)
+	assert.NoError(t, os.WriteFile(copyPath, []byte("{\"Size\": \"medium\"}\n"), 0644))
+	accept := func([]FileChange) bool { return true }
+
+	changes, err := SyncFiles("dev", false, false, nil, accept)
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+
+	changes, err = SyncFiles("dev", true, false, nil, accept)
+	assert.NoError(t, err)
+	assert.Len(t, changes, 1)
+	data, _ := os.ReadFile(copyPath)
+	assert.Equal(t, "{\"Size\": \"small\"}\n", string(data))
+}
+
+func TestSyncFiles_ChecksSources(t *testing.T) {
+	source, copyPath := setupTrackedFile(t)
+	accept := func([]FileChange) bool { return true }
+
+	assert.NoError(t, os.WriteFile(source, []byte("{\"Size\": \"small\", \"DbPassword\": \"hunter2\"}\n"), 0644))
+	_, err := SyncFiles("dev", false, false, nil, accept)
+	var secretsErr *SecretsError
+	assert.ErrorAs(t, err, &secretsErr)
+	assert.Equal(t, "DbPassword", secretsErr.Findings[0].Parameter)
+	data, _ := os.ReadFile(copyPath)
+	assert.Equal(t, "{\"Size\": \"small\"}\n", string(data))
+
+	changes, err := SyncFiles("dev", false, true, nil, accept)
+	assert.NoError(t, err)
+	assert.Len(t, changes, 1)
+
+	tagsSource := filepath.Join("shared", "tags.json")
+	assert.NoError(t, os.WriteFile(tagsSource, []byte(`[{"Key": "team", "Value": "core"}]`), 0644))
+	assert.NoError(t, AddStackFiles("dev", "", nil, []string{tagsSource}, nil))
+	assert.NoError(t, os.WriteFile(tagsSource, []byte(`[{"Key": "aws:team", "Value": "core"}]`), 0644))
+	_, err = SyncFiles("dev", false, false, nil, accept)
+	assert.ErrorContains(t, err, "cannot sync tags.json: ")
+	assert.ErrorContains(t, err, "aws:")
+}
+
+func TestSyncFiles_KeepsEncryptedValues(t *testing.T) {
+	source, copyPath := setupTrackedFile(t)
+	accept := func([]FileChange) bool { return true }
+
+	_, err := EncryptParameters("dev", testKey, []string{"Size"})
+	assert.NoError(t, err)
+	statuses, err := FilesStatus("dev")
+	assert.NoError(t, err)
+	assert.Equal(t, FileCurrent, statuses[0].Status)
+
+	assert.NoError(t, os.WriteFile(source, []byte("{\"Size\": \"large\"}\n"), 0644))
+	statuses, _ = FilesStatus("dev")
+	assert.Equal(t, FileSourceModified, statuses[0].Status)
+
+	_, err = SyncFiles("dev", false, false, nil, accept)
+	assert.ErrorContains(t, err, "has encrypted values and no key file was found")
+
+	changes, err := SyncFiles("dev", false, false, testKey, accept)
+	assert.NoError(t, err)
+	assert.Len(t, changes, 1)
+	content, err := os.ReadFile(copyPath)
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "ENC[AES256_GCM")
+	values, _, err := RenderPlainValues("dev", "", testKey)
+	assert.NoError(t, err)
+	assert.Equal(t, "large", values.Parameters["Size"].Value)
+	statuses, _ = FilesStatus("dev")
+	assert.Equal(t, FileCurrent, statuses[0].Status)
+
+	_, err = DecryptParameters("dev", testKey)
+	assert.NoError(t, err)
+	statuses, _ = FilesStatus("dev")
+	assert.Equal(t, FileCurrent, statuses[0].Status)
+}
@@ -0,0 +1,76 @@
+package environment
+
+import (
+	"cfn-init/internal/config"
+	"cfn-init/internal/stackpolicy"
+	"cfn-init/internal/template"
+	"fmt"
+	"path/filepath"
+)
+
+// validateStackPolicy checks the structure of a stack policy and that the
+// logical IDs it names exist in the template the environment deploys. The
+// IDs are not checked when no template is known.
+func validateStackPolicy(path, envName, stackName string) error {
+	configFile, err := config.ReadConfigFile(".")
+	if err != nil {
+		return err
+	}
+	templatePath, err := policyTemplate(configFile, envName, stackName)
+	if err != nil {
+		return err
+	}
+	return checkStackPolicy(path, templatePath)
+}
+
+// validateAddedStackPolicy checks a stack policy being added like
+// validateStackPolicy, against templatePath when it is set. It returns a
+// warning when no template is known and the logical IDs were not checked.
+func validateAddedStackPolicy(configFile *config.ProjectConfig, path, envName, stackName, templatePath string) (string, error) {
+	if templatePath == "" {
+		var err error
+		if templatePath, err = policyTemplate(configFile, envName, stackName); err != nil {
+			return "", err
+		}
+	}
+	if err := checkStackPolicy(path, templatePath); err != nil {
+		return "", err
+	}
+	if templatePath == "" {
+		return fmt.Sprintf("the logical IDs in %s were not checked: environment '%s' names no template (give one with --template)", path, envName), nil
+	}
+	return "", nil
+}
+
+// checkStackPolicy checks the structure of a stack policy and, when
+// templatePath is set, that the logical IDs it names exist in the template.
+func checkStackPolicy(path, templatePath string) error {
+	policy, err := stackpolicy.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if templatePath == "" {
+		return nil
+	}
+	tmpl, err := template.Load(templatePath)
+	if err != nil {
+		return fmt.Errorf("cannot check stack policy %s: %w", path, err)
+	}
+	if problems := policy.CheckResources(sortedKeys(tmpl.Resources)); len(problems) > 0 {
+		return &stackpolicy.ValidationError{File: path, Problems: problems}
+	}
+	return nil
+}
+
+// policyTemplate returns the template a stack policy protects: the stack's
+// template, or the one the environment's GitSync deployment file names.
+func policyTemplate(configFile *config.ProjectConfig, envName, stackName string) (string, error) {
+	if s, exists := configFile.Stacks[stackName]; stackName != "" && exists {
+		return filepath.FromSlash(s.Template), nil
+	}
+	deployment, _, err := deploymentSettings(configFile, envName, "", DefaultGitSyncFile)
+	if err != nil {
+		return "", err
+	}
+	return filepath.FromSlash(deployment.TemplateFilePath), nil
+}
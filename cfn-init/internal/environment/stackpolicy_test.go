@@ -0,0 +1,94 @@
+package environment
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cfn-init/internal/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupStackPolicy(t *testing.T) string {
+	t.Helper()
+	projectDir := setupTestProject(t)
+	assert.NoError(t, addEnvironment("prod", "prod-profile", ""))
+	assert.NoError(t, os.WriteFile("app.yaml", []byte("Resources:\n  Database:\n    Type: AWS::RDS::DBInstance\n  Bucket:\n    Type: AWS::S3::Bucket\n"), 0644))
+
+	configFile, err := config.ReadConfigFile(".")
+	assert.NoError(t, err)
+	configFile.Stacks = map[string]config.Stack{"app": {Name: "app", Template: "app.yaml"}}
+	assert.NoError(t, config.WriteConfigFile(".", configFile))
+	return filepath.Join(projectDir, EnvironmentsDir, "prod")
+}
+
+func TestAddCategoryFiles_StackPolicy(t *testing.T) {
+	envDir := setupStackPolicy(t)
+	assert.NoError(t, os.WriteFile("stack-policy.json", []byte(`{"Statement": [
+  {"Effect": "Allow", "Action": "Update:*", "Principal": "*", "Resource": "*"},
+  {"Effect": "Deny", "Action": "Update:Replace", "Principal": "*", "Resource": "LogicalResourceId/Database"}
+]}`), 0644))
+
+	added, err := AddCategoryFiles("prod", CategoryStackPolicy, []string{"stack-policy.json"}, FileOptions{Stack: "app"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []AddedFile{{Source: "stack-policy.json", Path: "app/stack-policy.json", Status: FileAdded}}, added)
+	assert.FileExists(t, filepath.Join(envDir, "app", "stack-policy.json"))
+
+	// The policy is neither parameters nor tags
+	values, err := ResolveValues("prod", "app")
+	assert.NoError(t, err)
+	assert.Empty(t, values.Parameters)
+
+	problems, err := Validate("prod")
+	assert.NoError(t, err)
+	assert.Empty(t, problems)
+}
+
+func TestAddCategoryFiles_StackPolicyUnknownResource(t *testing.T) {
+	envDir := setupStackPolicy(t)
+	assert.NoError(t, os.WriteFile("stack-policy.json", []byte(`{"Statement": [
+  {"Effect": "Deny", "Action": "Update:Replace", "Principal": "*", "Resource": "LogicalResourceId/Db"}
+]}`), 0644))
+
+	_, err := AddCategoryFiles("prod", CategoryStackPolicy, []string{"stack-policy.json"}, FileOptions{Stack: "app"})
+
+	assert.EqualError(t, err, "invalid stack policy stack-policy.json: statement 1: resource 'LogicalResourceId/Db' is not in the template")
+	assert.NoFileExists(t, filepath.Join(envDir, "app", "stack-policy.json"))
+
+	// Without a template only the structure is checked, with a warning
+	added, err := AddCategoryFiles("prod", CategoryStackPolicy, []string{"stack-policy.json"}, FileOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "the logical IDs in stack-policy.json were not checked: environment 'prod' names no template (give one with --template)", added[0].Warning)
+}
+
+func TestAddCategoryFiles_StackPolicyTemplate(t *testing.T) {
+	envDir := setupStackPolicy(t)
+	assert.NoError(t, os.WriteFile("stack-policy.json", []byte(`{"Statement": [
+  {"Effect": "Deny", "Action": "Update:Replace", "Principal": "*", "Resource": "LogicalResourceId/Db"}
+]}`), 0644))
+
+	_, err := AddCategoryFiles("prod", CategoryStackPolicy, []string{"stack-policy.json"}, FileOptions{Template: "app.yaml"})
+
+	assert.EqualError(t, err, "invalid stack policy stack-policy.json: statement 1: resource 'LogicalResourceId/Db' is not in the template")
+	assert.NoFileExists(t, filepath.Join(envDir, "stack-policy.json"))
+}
+
+func TestValidate_StackPolicy(t *testing.T) {
+	envDir := setupStackPolicy(t)
+	assert.NoError(t, os.MkdirAll(filepath.Join(envDir, "app"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(envDir, "app", "policy.json"), []byte(`{"Statement": [
+  {"Effect": "Deny", "Action": "Update:Delete", "Principal": "*", "Resource": "LogicalResourceId/Queue"}
+]}`), 0644))
+
+	migrated, err := MigrateFiles("prod")
+	assert.NoError(t, err)
+	assert.Equal(t, []CategorizedFile{{Environment: "prod", Path: "app/policy.json", Category: CategoryStackPolicy}}, migrated)
+
+	problems, err := Validate("prod")
+
+	assert.NoError(t, err)
+	assert.Len(t, problems, 1)
+	assert.EqualError(t, problems[0], "invalid stack policy cfn-project/environments/prod/app/policy.json: statement 1: resource 'LogicalResourceId/Queue' is not in the template")
+}
@@ -0,0 +1,66 @@
+package environment
+
+import (
+	"cfn-init/internal/classify"
+	"cfn-init/internal/gitsync"
+	"cfn-init/internal/tags"
+	"fmt"
+	"slices"
+)
+
+// Validate checks the tags and GitSync files of an environment, including
+// its stack folders, and its recorded files of other categories, such as
+// stack policies, against their category's validator. It returns one error per invalid file.
+func Validate(envName string) ([]error, error) {
+	if !projectExists() {
+		return nil, fmt.Errorf("project directory not found")
+	}
+	configFile, err := getEnvironmentConfig(envName)
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []error
+	for _, stackName := range stackScopes(configFile) {
+		tagFiles, err := scopeFiles(configFile, envName, stackName, classify.KindTags)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range tagFiles {
+			if err := tags.ValidateFile(path); err != nil {
+				problems = append(problems, err)
+			}
+		}
+
+		gitSyncFiles, err := scopeFiles(configFile, envName, stackName, classify.KindGitSync)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range gitSyncFiles {
+			deployment, err := gitsync.ReadFile(path, ".")
+			if err != nil {
+				problems = append(problems, err)
+				continue
+			}
+			if tagProblems := tags.Validate(tags.FromMap(deployment.Tags)); len(tagProblems) > 0 {
+				problems = append(problems, &tags.ValidationError{File: path, Problems: tagProblems})
+			}
+		}
+	}
+
+	categories, err := Categories(configFile)
+	if err != nil {
+		return nil, err
+	}
+	// Tags and GitSync files were checked above and unknown categories have no validator
+	for _, file := range configFile.Environments[envName].Files {
+		index := slices.IndexFunc(categories, func(c Category) bool { return c.Name == file.Category })
+		if index < 0 || categories[index].Validate == nil || isValueCategory(file.Category) {
+			continue
+		}
+		if err := categories[index].Validate(trackedFilePath(envName, file), envName, trackedStack(configFile, file)); err != nil {
+			problems = append(problems, err)
+		}
+	}
+	return problems, nil
+}
@@ -0,0 +1,32 @@
+package environment
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	setupTestProject(t)
+	assert.NoError(t, addEnvironment("dev", "dev-profile", ""))
+	envDir := filepath.Join(ProjectDir, EnvironmentsDir, "dev")
+	assert.NoError(t, os.WriteFile(filepath.Join(envDir, "tags.json"), []byte(`{"team": "a", "TEAM": "b"}`), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(envDir, "params.json"), []byte(`{"Env": "dev"}`), 0644))
+
+	problems, err := Validate("dev")
+
+	assert.NoError(t, err)
+	assert.Len(t, problems, 1)
+	assert.Contains(t, problems[0].Error(), "tag 'team': duplicates key 'TEAM'")
+}
+
+func TestValidate_EnvironmentNotFound(t *testing.T) {
+	setupTestProject(t)
+
+	_, err := Validate("missing")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "environment 'missing' not found")
+}
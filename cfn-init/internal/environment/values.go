@@ -0,0 +1,197 @@
+package environment
+
+import (
+	"cfn-init/internal/classify"
+	"cfn-init/internal/config"
+	"cfn-init/internal/parameters"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ResolvedValue is an effective parameter or tag value and where it was set.
+// Source is cfn-config.json for configured defaults, or the file's path
+// relative to the project folder. Files referenced from outside the project
+// folder keep their path relative to the repository root.
+type ResolvedValue struct {
+	Value  string
+	Source string
+}
+
+// Conflict is a parameter that files of the same folder set to different
+// values. Sources and Values are in precedence order; the last one wins.
+type Conflict struct {
+	Parameter string
+	Sources   []string
+	Values    []string
+}
+
+// EffectiveValues are the parameters and tags that apply to an environment.
+type EffectiveValues struct {
+	Environment string
+	Stack       string
+	Parameters  map[string]ResolvedValue
+	Tags        map[string]ResolvedValue
+	Conflicts   []Conflict
+	// Files lists the files the values were read from in the order they
+	// applied, relative to the repository root.
+	Files []string
+}
+
+// ResolveValues computes the effective parameters and tags of an environment.
+// Later layers override earlier ones: the defaults in cfn-config.json, files
+// in the defaults folder, then the files of each environment in the
+// inheritance chain from the furthest ancestor to the environment itself.
+// When a stack is given, each environment's stack files follow its own files.
+// Within a folder, files apply in the environment's recorded order and
+// parameters set to different values are reported as conflicts.
+func ResolveValues(envName, stackName string) (*EffectiveValues, error) {
+	if !projectExists() {
+		return nil, fmt.Errorf("project directory not found")
+	}
+	if _, err := getEnvironmentConfig(envName); err != nil {
+		return nil, err
+	}
+	return resolveValues(envName, stackName, true)
+}
+
+// resolveValues layers the project defaults and environment files. GitSync
+// files are only read when includeGitSync is set.
+func resolveValues(envName, stackName string, includeGitSync bool) (*EffectiveValues, error) {
+	configFile, err := config.ReadConfigFile(".")
+	if err != nil {
+		return nil, err
+	}
+
+	values := &EffectiveValues{
+		Environment: envName,
+		Stack:       stackName,
+		Parameters:  make(map[string]ResolvedValue),
+		Tags:        make(map[string]ResolvedValue),
+	}
+	if configFile.Defaults != nil {
+		for key, value := range configFile.Defaults.Parameters {
+			values.Parameters[key] = ResolvedValue{Value: value, Source: ConfigFile}
+		}
+		for key, value := range configFile.Defaults.Tags {
+			values.Tags[key] = ResolvedValue{Value: value, Source: ConfigFile}
+		}
+	}
+
+	kinds := []classify.Kind{classify.KindParameters, classify.KindTags}
+	if includeGitSync {
+		kinds = append(kinds, classify.KindGitSync)
+	}
+
+	// Deployment files describe one environment, so defaults hold none
+	defaults, defaultKinds, err := kindFiles(filepath.Join(ProjectDir, DefaultsDir), nil, kinds[:2]...)
+	if err != nil {
+		return nil, err
+	}
+	if err := values.applyLayer(defaults, defaultKinds); err != nil {
+		return nil, err
+	}
+
+	chain, err := configFile.InheritanceChain(envName)
+	if err != nil {
+		return nil, err
+	}
+	scopes := []string{""}
+	if stackName != "" {
+		scopes = append(scopes, stackName)
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		for _, scope := range scopes {
+			files, fileKinds, err := scopeFileKinds(configFile, chain[i], scope, kinds...)
+			if err != nil {
+				return nil, err
+			}
+			if err := values.applyLayer(files, fileKinds); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return values, nil
+}
+
+// applyLayer applies the files of one folder in order, each as the kind
+// given for it, and records the parameters they set to different values.
+func (v *EffectiveValues) applyLayer(files []string, kinds map[string]classify.Kind) error {
+	layer := make(map[string][]ResolvedValue)
+	for _, path := range files {
+		fileParams, err := v.apply(path, kinds[path])
+		if err != nil {
+			return err
+		}
+		for key, value := range fileParams {
+			layer[key] = append(layer[key], value)
+		}
+	}
+
+	for _, key := range sortedKeys(layer) {
+		entries := layer[key]
+		for _, entry := range entries[1:] {
+			if entry.Value != entries[0].Value {
+				conflict := Conflict{Parameter: key}
+				for _, e := range entries {
+					conflict.Sources = append(conflict.Sources, e.Source)
+					conflict.Values = append(conflict.Values, e.Value)
+				}
+				v.Conflicts = append(v.Conflicts, conflict)
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// apply layers the parameters and tags of one file of the given kind over the
+// current values and returns the parameters it set.
+func (v *EffectiveValues) apply(path string, kind classify.Kind) (map[string]ResolvedValue, error) {
+	source := filepath.ToSlash(path)
+	if rel, err := filepath.Rel(ProjectDir, path); err == nil && !strings.HasPrefix(rel, "..") {
+		source = filepath.ToSlash(rel)
+	}
+
+	v.Files = append(v.Files, filepath.ToSlash(path))
+	params := make(map[string]ResolvedValue)
+	if kind == classify.KindParameters || kind == classify.KindGitSync {
+		fileValues, _, err := parameters.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range fileValues {
+			resolved := ResolvedValue{Value: value, Source: source}
+			v.Parameters[key] = resolved
+			params[key] = resolved
+		}
+	}
+	if kind == classify.KindTags || kind == classify.KindGitSync {
+		fileTags, err := readTags(path)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range fileTags {
+			v.Tags[key] = ResolvedValue{Value: value, Source: source}
+		}
+	}
+	return params, nil
+}
+
+// ParameterValues returns the effective parameter values without their sources.
+func (v *EffectiveValues) ParameterValues() map[string]string {
+	return plainValues(v.Parameters)
+}
+
+// TagValues returns the effective tag values without their sources.
+func (v *EffectiveValues) TagValues() map[string]string {
+	return plainValues(v.Tags)
+}
+
+func plainValues(resolved map[string]ResolvedValue) map[string]string {
+	values := make(map[string]string, len(resolved))
+	for key, value := range resolved {
+		values[key] = value.Value
+	}
+	return values
+}
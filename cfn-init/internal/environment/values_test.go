@@ -0,0 +1,84 @@
+package environment
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cfn-init/internal/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveValues(t *testing.T) {
+	setupTestProject(t)
+	assert.NoError(t, addEnvironment("prod", "prod-profile", ""))
+
+	configFile, err := config.ReadConfigFile(".")
+	assert.NoError(t, err)
+	configFile.Defaults = &config.Defaults{
+		Parameters: map[string]string{"LogLevel": "info", "Env": "default"},
+		Tags:       map[string]string{"owner": "platform"},
+	}
+	assert.NoError(t, config.WriteConfigFile(".", configFile))
+
+	defaultsDir := filepath.Join(ProjectDir, DefaultsDir)
+	assert.NoError(t, os.WriteFile(filepath.Join(defaultsDir, "tags.json"), []byte(`{"team": "core", "owner": "infra"}`), 0644))
+	envDir := filepath.Join(ProjectDir, EnvironmentsDir, "prod")
+	assert.NoError(t, os.WriteFile(filepath.Join(envDir, "params.json"), []byte(`{"Env": "prod"}`), 0644))
+
+	values, err := ResolveValues("prod", "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]ResolvedValue{
+		"Env":      {Value: "prod", Source: "environments/prod/params.json"},
+		"LogLevel": {Value: "info", Source: ConfigFile},
+	}, values.Parameters)
+	assert.Equal(t, map[string]ResolvedValue{
+		"owner": {Value: "infra", Source: "defaults/tags.json"},
+		"team":  {Value: "core", Source: "defaults/tags.json"},
+	}, values.Tags)
+
+	params, err := LoadParameters("prod", "")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"Env": "prod", "LogLevel": "info"}, params)
+}
+
+func TestResolveValues_EnvironmentNotFound(t *testing.T) {
+	setupTestProject(t)
+
+	_, err := ResolveValues("missing", "")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "environment 'missing' not found")
+}
+
+func TestResolveValues_ParametersFilesOrder(t *testing.T) {
+	setupTestProject(t)
+	assert.NoError(t, addEnvironment("prod", "prod-profile", ""))
+
+	envDir := filepath.Join(ProjectDir, EnvironmentsDir, "prod")
+	assert.NoError(t, os.WriteFile(filepath.Join(envDir, "a.json"), []byte(`{"Size": "large", "Env": "prod"}`), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(envDir, "b.json"), []byte(`{"Size": "small", "Env": "prod"}`), 0644))
+
+	values, err := ResolveValues("prod", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "small", values.Parameters["Size"].Value)
+	assert.Equal(t, []Conflict{{
+		Parameter: "Size",
+		Sources:   []string{"environments/prod/a.json", "environments/prod/b.json"},
+		Values:    []string{"large", "small"},
+	}}, values.Conflicts)
+
+	configFile, err := config.ReadConfigFile(".")
+	assert.NoError(t, err)
+	env := configFile.Environments["prod"]
+	env.Files = []config.TrackedFile{{Path: "b.json", Category: CategoryParameters}, {Path: "a.json", Category: CategoryParameters}}
+	configFile.Environments["prod"] = env
+	assert.NoError(t, config.WriteConfigFile(".", configFile))
+
+	values, err = ResolveValues("prod", "")
+	assert.NoError(t, err)
+	assert.Equal(t, ResolvedValue{Value: "large", Source: "environments/prod/a.json"}, values.Parameters["Size"])
+	assert.Equal(t, []string{"environments/prod/b.json", "environments/prod/a.json"}, values.Conflicts[0].Sources)
+}
@@ -4,7 +4,11 @@ package internal
 type EnvironmentConfig struct {
 	Name            string   `json:"name"`
 	AwsProfile      string   `json:"awsProfile"`
+	Region          string   `json:"region,omitempty"`
+	Extends         string   `json:"extends,omitempty"`
 	ParametersFiles []string `json:"parametersFiles,omitempty"`
 	TagsFiles       []string `json:"tagsFiles,omitempty"`
 	GitSyncFiles    []string `json:"gitSyncFiles,omitempty"`
+	Mode            string   `json:"mode,omitempty"`
+	OnConflict      string   `json:"onConflict,omitempty"`
 }
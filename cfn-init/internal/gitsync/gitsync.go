@@ -0,0 +1,209 @@
+package gitsync
+
+import (
+	"bytes"
+	"cfn-init/internal/document"
+	"cfn-init/internal/template"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Deployment file keys, matching the language server's DeploymentConfig schema.
+const (
+	KeyTemplateFilePath        = "template-file-path"
+	KeyParameters              = "parameters"
+	KeyTags                    = "tags"
+	KeyOnStackFailure          = "on-stack-failure"
+	KeyIncludeNestedStacks     = "include-nested-stacks"
+	KeyImportExistingResources = "import-existing-resources"
+)
+
+// Keys lists every key allowed in a GitSync deployment file.
+var Keys = []string{
+	KeyTemplateFilePath,
+	KeyParameters,
+	KeyTags,
+	KeyOnStackFailure,
+	KeyIncludeNestedStacks,
+	KeyImportExistingResources,
+}
+
+// OnStackFailureValues are the allowed values of on-stack-failure.
+var OnStackFailureValues = []string{"DO_NOTHING", "ROLLBACK", "DELETE"}
+
+// DeploymentConfig is a parsed GitSync deployment file.
+type DeploymentConfig struct {
+	TemplateFilePath        string            `json:"template-file-path,omitempty" yaml:"template-file-path,omitempty"`
+	Parameters              map[string]string `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	Tags                    map[string]string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	OnStackFailure          string            `json:"on-stack-failure,omitempty" yaml:"on-stack-failure,omitempty"`
+	IncludeNestedStacks     *bool             `json:"include-nested-stacks,omitempty" yaml:"include-nested-stacks,omitempty"`
+	ImportExistingResources *bool             `json:"import-existing-resources,omitempty" yaml:"import-existing-resources,omitempty"`
+}
+
+// ValidationError lists every problem found in a deployment file.
+type ValidationError struct {
+	File     string
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid GitSync deployment file %s: %s", e.File, strings.Join(e.Problems, "; "))
+}
+
+// ReadFile parses and validates a GitSync deployment file. When repoRoot is
+// not empty, template-file-path must point to an existing template relative to it.
+func ReadFile(path, repoRoot string) (*DeploymentConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	deployment, problems := Parse(data)
+	if deployment != nil && deployment.TemplateFilePath != "" && repoRoot != "" {
+		if problem := checkTemplatePath(deployment.TemplateFilePath, repoRoot); problem != "" {
+			problems = append(problems, problem)
+		}
+	}
+	if len(problems) > 0 {
+		return nil, &ValidationError{File: path, Problems: problems}
+	}
+	return deployment, nil
+}
+
+// Parse decodes deployment file content and returns the problems that make it invalid.
+// Unknown properties are dropped, as the language server's schema strips them.
+func Parse(data []byte) (*DeploymentConfig, []string) {
+	doc, err := document.Decode(data)
+	if err != nil {
+		return nil, []string{fmt.Sprintf("not valid JSON or YAML: %v", err)}
+	}
+	content, ok := doc.(map[string]any)
+	if !ok {
+		return nil, []string{"expected an object"}
+	}
+
+	var problems []string
+	deployment := &DeploymentConfig{}
+
+	for _, key := range sortedKeys(content) {
+		value := content[key]
+		switch key {
+		case KeyTemplateFilePath:
+			path, ok := value.(string)
+			if !ok {
+				problems = append(problems, fmt.Sprintf("%s must be a string", key))
+				continue
+			}
+			deployment.TemplateFilePath = path
+		case KeyParameters:
+			values, keyProblems := stringRecord(key, value)
+			problems = append(problems, keyProblems...)
+			deployment.Parameters = values
+		case KeyTags:
+			values, keyProblems := stringRecord(key, value)
+			problems = append(problems, keyProblems...)
+			deployment.Tags = values
+		case KeyOnStackFailure:
+			mode, ok := value.(string)
+			if !ok || !slices.Contains(OnStackFailureValues, mode) {
+				problems = append(problems, fmt.Sprintf("%s must be one of %s", key, strings.Join(OnStackFailureValues, ", ")))
+				continue
+			}
+			deployment.OnStackFailure = mode
+		case KeyIncludeNestedStacks, KeyImportExistingResources:
+			flag, ok := value.(bool)
+			if !ok {
+				problems = append(problems, fmt.Sprintf("%s must be a boolean", key))
+				continue
+			}
+			if key == KeyIncludeNestedStacks {
+				deployment.IncludeNestedStacks = &flag
+			} else {
+				deployment.ImportExistingResources = &flag
+			}
+		}
+	}
+
+	if !hasAnyKey(content) {
+		problems = append(problems, "at least one property must be provided")
+	}
+	return deployment, problems
+}
+
+// Marshal encodes a deployment file as JSON when path ends in .json and as YAML otherwise.
+func Marshal(deployment *DeploymentConfig, path string) ([]byte, error) {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		data, err := json.MarshalIndent(deployment, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(deployment); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func stringRecord(key string, value any) (map[string]string, []string) {
+	record, ok := value.(map[string]any)
+	if !ok {
+		return nil, []string{fmt.Sprintf("%s must be an object of string values", key)}
+	}
+
+	var problems []string
+	values := make(map[string]string, len(record))
+	for _, name := range sortedKeys(record) {
+		text, ok := record[name].(string)
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s.%s must be a string", key, name))
+			continue
+		}
+		values[name] = text
+	}
+	return values, problems
+}
+
+func checkTemplatePath(templatePath, repoRoot string) string {
+	fullPath := filepath.Join(repoRoot, filepath.FromSlash(templatePath))
+	if _, err := os.Stat(fullPath); os.IsNotExist(err) {
+		return fmt.Sprintf("%s '%s' does not exist relative to the repository root", KeyTemplateFilePath, templatePath)
+	}
+	if _, err := template.Load(fullPath); err != nil {
+		return fmt.Sprintf("%s '%s' is not a CloudFormation template", KeyTemplateFilePath, templatePath)
+	}
+	return ""
+}
+
+func hasAnyKey(content map[string]any) bool {
+	for _, key := range Keys {
+		if value, ok := content[key]; ok && value != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,100 @@
+package gitsync
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse_Valid(t *testing.T) {
+	content := `
+template-file-path: templates/app.yaml
+parameters:
+  Env: prod
+tags:
+  Team: core
+on-stack-failure: ROLLBACK
+include-nested-stacks: true
+import-existing-resources: false
+`
+	deployment, problems := Parse([]byte(content))
+
+	assert.Empty(t, problems)
+	assert.Equal(t, "templates/app.yaml", deployment.TemplateFilePath)
+	assert.Equal(t, map[string]string{"Env": "prod"}, deployment.Parameters)
+	assert.Equal(t, map[string]string{"Team": "core"}, deployment.Tags)
+	assert.Equal(t, "ROLLBACK", deployment.OnStackFailure)
+	assert.True(t, *deployment.IncludeNestedStacks)
+	assert.False(t, *deployment.ImportExistingResources)
+}
+
+func TestParse_Problems(t *testing.T) {
+	tests := []struct {
+		content  string
+		expected string
+	}{
+		{`{}`, "at least one property must be provided"},
+		{`{"parameters": {"Count": 3}}`, "parameters.Count must be a string"},
+		{`{"tags": ["a"]}`, "tags must be an object of string values"},
+		{`{"on-stack-failure": "ABANDON"}`, "on-stack-failure must be one of DO_NOTHING, ROLLBACK, DELETE"},
+		{`{"include-nested-stacks": "yes"}`, "include-nested-stacks must be a boolean"},
+		{`{"region": "us-east-1"}`, "at least one property must be provided"},
+		{`[]`, "expected an object"},
+	}
+
+	for _, test := range tests {
+		_, problems := Parse([]byte(test.content))
+		assert.Contains(t, problems, test.expected, "Content: %s", test.content)
+	}
+}
+
+func TestParse_UnknownPropertiesDropped(t *testing.T) {
+	deployment, problems := Parse([]byte(`{"template-file-path": "a.yaml", "region": "us-east-1"}`))
+
+	assert.Empty(t, problems)
+	assert.Equal(t, &DeploymentConfig{TemplateFilePath: "a.yaml"}, deployment)
+}
+
+func TestReadFile_TemplatePath(t *testing.T) {
+	root := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "app.yaml"), []byte("Resources: {}\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "params.json"), []byte(`{"Env": "prod"}`), 0644))
+
+	tests := []struct {
+		templatePath string
+		expected     string
+	}{
+		{"app.yaml", ""},
+		{"missing.yaml", "does not exist relative to the repository root"},
+		{"params.json", "is not a CloudFormation template"},
+	}
+
+	for _, test := range tests {
+		deploymentFile := filepath.Join(root, "deploy.json")
+		assert.NoError(t, os.WriteFile(deploymentFile, []byte(`{"template-file-path": "`+test.templatePath+`"}`), 0644))
+
+		_, err := ReadFile(deploymentFile, root)
+		if test.expected == "" {
+			assert.NoError(t, err)
+			continue
+		}
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), test.expected)
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	deployment := &DeploymentConfig{TemplateFilePath: "template.yaml", Parameters: map[string]string{"Env": "dev"}}
+
+	yamlData, err := Marshal(deployment, "deploy.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, "template-file-path: template.yaml\nparameters:\n  Env: dev\n", string(yamlData))
+
+	jsonData, err := Marshal(deployment, "deploy.json")
+	assert.NoError(t, err)
+	parsed, problems := Parse(jsonData)
+	assert.Empty(t, problems)
+	assert.Equal(t, deployment, parsed)
+}
@@ -0,0 +1,142 @@
+package interpolate
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParameterPrefix is the namespace of references to other parameters.
+const ParameterPrefix = "param."
+
+// Error is a reference in a value that cannot be resolved. Offset is the byte
+// offset of the reference within the value.
+type Error struct {
+	Name    string
+	Offset  int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("'%s' at offset %d: %s", e.Name, e.Offset, e.Message)
+}
+
+// Expander resolves ${...} references in values. References name a variable,
+// such as ${env.region}, or another parameter as ${param.Name}. Parameters
+// are expanded on first use, so they may reference each other in any order
+// as long as the references do not form a cycle. $${ produces a literal ${.
+type Expander struct {
+	variables  map[string]string
+	parameters map[string]string
+	expanded   map[string]string
+	failed     map[string]*Error
+	visiting   []string
+	// Lookup resolves variables missing from the variables map. It returns
+	// the reason a variable is undefined when ok is false.
+	Lookup func(variable string) (value string, ok bool, reason string)
+}
+
+// New creates an Expander for the given variables and raw parameter values.
+func New(variables, parameters map[string]string) *Expander {
+	return &Expander{
+		variables:  variables,
+		parameters: parameters,
+		expanded:   make(map[string]string),
+		failed:     make(map[string]*Error),
+	}
+}
+
+// Parameter returns the expanded value of a parameter.
+func (e *Expander) Parameter(name string) (string, error) {
+	if value, ok := e.expanded[name]; ok {
+		return value, nil
+	}
+	if err, ok := e.failed[name]; ok {
+		return "", err
+	}
+
+	e.visiting = append(e.visiting, name)
+	value, err := e.expand(name, e.parameters[name])
+	e.visiting = e.visiting[:len(e.visiting)-1]
+	if err != nil {
+		e.failed[name] = err
+		return "", err
+	}
+	e.expanded[name] = value
+	return value, nil
+}
+
+// Expand resolves the references in a value that is not a parameter itself,
+// such as a tag. Name identifies the value in errors.
+func (e *Expander) Expand(name, value string) (string, error) {
+	result, err := e.expand(name, value)
+	if err != nil {
+		return "", err
+	}
+	return result, nil
+}
+
+func (e *Expander) expand(name, value string) (string, *Error) {
+	var out strings.Builder
+	for i := 0; i < len(value); {
+		if strings.HasPrefix(value[i:], "$${") {
+			out.WriteString("${")
+			i += 3
+			continue
+		}
+		if !strings.HasPrefix(value[i:], "${") {
+			out.WriteByte(value[i])
+			i++
+			continue
+		}
+
+		end := strings.IndexByte(value[i:], '}')
+		if end < 0 {
+			return "", &Error{Name: name, Offset: i, Message: "unterminated reference"}
+		}
+		reference := strings.TrimSpace(value[i+2 : i+end])
+		if reference == "" {
+			return "", &Error{Name: name, Offset: i, Message: "empty reference"}
+		}
+
+		resolved, err := e.resolve(name, i, reference)
+		if err != nil {
+			return "", err
+		}
+		out.WriteString(resolved)
+		i += end + 1
+	}
+	return out.String(), nil
+}
+
+func (e *Expander) resolve(name string, offset int, reference string) (string, *Error) {
+	if parameter, ok := strings.CutPrefix(reference, ParameterPrefix); ok {
+		if _, exists := e.parameters[parameter]; !exists {
+			return "", &Error{Name: name, Offset: offset, Message: fmt.Sprintf("undefined parameter '%s'", parameter)}
+		}
+		for i, visiting := range e.visiting {
+			if visiting == parameter {
+				chain := append(append([]string{}, e.visiting[i:]...), parameter)
+				return "", &Error{Name: name, Offset: offset, Message: fmt.Sprintf("reference cycle: %s", strings.Join(chain, " -> "))}
+			}
+		}
+		value, err := e.Parameter(parameter)
+		if err != nil {
+			return "", err.(*Error)
+		}
+		return value, nil
+	}
+
+	if value, ok := e.variables[reference]; ok {
+		return value, nil
+	}
+	if e.Lookup != nil {
+		value, ok, reason := e.Lookup(reference)
+		if ok {
+			return value, nil
+		}
+		if reason != "" {
+			return "", &Error{Name: name, Offset: offset, Message: reason}
+		}
+	}
+	return "", &Error{Name: name, Offset: offset, Message: fmt.Sprintf("undefined variable '%s'", reference)}
+}
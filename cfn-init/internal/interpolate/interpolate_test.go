@@ -0,0 +1,69 @@
+package interpolate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpander_VariablesAndParameters(t *testing.T) {
+	expander := New(
+		map[string]string{"project.name": "shop", "env.name": "prod"},
+		map[string]string{
+			"Bucket": "${param.Prefix}-bucket",
+			"Prefix": "${project.name}-${ env.name }",
+			"Escape": "$${env.name} costs $5",
+		},
+	)
+
+	bucket, err := expander.Parameter("Bucket")
+	assert.NoError(t, err)
+	assert.Equal(t, "shop-prod-bucket", bucket)
+
+	escaped, err := expander.Parameter("Escape")
+	assert.NoError(t, err)
+	assert.Equal(t, "${env.name} costs $5", escaped)
+
+	tag, err := expander.Expand("Name", "${param.Bucket}")
+	assert.NoError(t, err)
+	assert.Equal(t, "shop-prod-bucket", tag)
+}
+
+func TestExpander_Errors(t *testing.T) {
+	expander := New(nil, map[string]string{
+		"A":       "x-${param.B}",
+		"B":       "${param.A}",
+		"Missing": "a${env.nme}",
+		"Open":    "${env.name",
+		"Unknown": "${param.Nope}",
+	})
+
+	_, err := expander.Parameter("A")
+	assert.Equal(t, &Error{Name: "B", Offset: 0, Message: "reference cycle: A -> B -> A"}, err)
+
+	_, err = expander.Parameter("Missing")
+	assert.Equal(t, &Error{Name: "Missing", Offset: 1, Message: "undefined variable 'env.nme'"}, err)
+
+	_, err = expander.Parameter("Open")
+	assert.Equal(t, &Error{Name: "Open", Offset: 0, Message: "unterminated reference"}, err)
+
+	_, err = expander.Parameter("Unknown")
+	assert.Equal(t, &Error{Name: "Unknown", Offset: 0, Message: "undefined parameter 'Nope'"}, err)
+}
+
+func TestExpander_Lookup(t *testing.T) {
+	expander := New(nil, nil)
+	expander.Lookup = func(variable string) (string, bool, string) {
+		if variable == "os.HOME" {
+			return "/home/ci", true, ""
+		}
+		return "", false, "not allowed"
+	}
+
+	value, err := expander.Expand("Home", "${os.HOME}")
+	assert.NoError(t, err)
+	assert.Equal(t, "/home/ci", value)
+
+	_, err = expander.Expand("User", "${os.USER}")
+	assert.EqualError(t, err, "'User' at offset 0: not allowed")
+}
@@ -0,0 +1,431 @@
+package jsonschema
+
+import (
+	"cfn-init/internal/document"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Schema is a JSON Schema document. Validation supports the keywords
+// environment file schemas need: type, enum, const, required, properties,
+// additionalProperties, items, minItems, maxItems, minLength, maxLength,
+// pattern, minimum, maximum, anyOf, allOf and local $ref pointers into
+// definitions or $defs. Schemas using any other keyword are rejected, so
+// that no part of a schema is silently left unchecked.
+type Schema struct {
+	root map[string]any
+}
+
+// keywords are the keywords a schema may use: the supported ones and
+// annotations that do not affect validation.
+var keywords = map[string]bool{
+	"type": true, "enum": true, "const": true, "required": true,
+	"properties": true, "additionalProperties": true, "items": true,
+	"minItems": true, "maxItems": true, "minLength": true, "maxLength": true,
+	"pattern": true, "minimum": true, "maximum": true,
+	"anyOf": true, "allOf": true, "$ref": true, "definitions": true, "$defs": true,
+	"$schema": true, "$id": true, "$comment": true, "title": true, "description": true,
+	"default": true, "examples": true, "deprecated": true, "readOnly": true, "writeOnly": true,
+}
+
+// ValidationError lists every way a file breaks its schema.
+type ValidationError struct {
+	File     string
+	Schema   string
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("file %s does not match schema %s: %s", e.File, e.Schema, strings.Join(e.Problems, "; "))
+}
+
+// ReadFile loads a JSON or YAML schema from disk.
+func ReadFile(path string) (*Schema, error) {
+	doc, err := document.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	root, ok := doc.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("schema %s must be an object", path)
+	}
+	if err := checkKeywords(root, "#"); err != nil {
+		return nil, fmt.Errorf("schema %s: %w", path, err)
+	}
+	return &Schema{root: root}, nil
+}
+
+// Parse decodes a JSON or YAML schema.
+func Parse(data []byte) (*Schema, error) {
+	doc, err := document.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	root, ok := doc.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("schema must be an object")
+	}
+	if err := checkKeywords(root, "#"); err != nil {
+		return nil, err
+	}
+	return &Schema{root: root}, nil
+}
+
+// checkKeywords rejects schemas that use keywords validation does not
+// support, including the array form of items, remote references and false
+// schemas, at any depth.
+func checkKeywords(schema any, at string) error {
+	if accept, ok := schema.(bool); ok {
+		if !accept {
+			return fmt.Errorf("unsupported false schema at %s", at)
+		}
+		return nil
+	}
+	object, ok := schema.(map[string]any)
+	if !ok {
+		return fmt.Errorf("schema at %s must be an object", at)
+	}
+
+	for _, key := range sortedKeys(object) {
+		if !keywords[key] {
+			return fmt.Errorf("unsupported keyword '%s' at %s", key, at)
+		}
+		value, path := object[key], at+"/"+key
+		switch key {
+		case "$ref":
+			if ref, _ := value.(string); !strings.HasPrefix(ref, "#") {
+				return fmt.Errorf("unsupported $ref '%v' at %s (only local references allowed)", value, at)
+			}
+		case "items":
+			if _, isList := value.([]any); isList {
+				return fmt.Errorf("unsupported list of items schemas at %s", at)
+			}
+			if err := checkKeywords(value, path); err != nil {
+				return err
+			}
+		case "additionalProperties":
+			if _, isBool := value.(bool); !isBool {
+				if err := checkKeywords(value, path); err != nil {
+					return err
+				}
+			}
+		case "properties", "definitions", "$defs":
+			subschemas, ok := value.(map[string]any)
+			if !ok {
+				return fmt.Errorf("%s at %s must be an object", key, at)
+			}
+			for _, name := range sortedKeys(subschemas) {
+				if err := checkKeywords(subschemas[name], path+"/"+name); err != nil {
+					return err
+				}
+			}
+		case "anyOf", "allOf":
+			subschemas, ok := value.([]any)
+			if !ok {
+				return fmt.Errorf("%s at %s must be a list", key, at)
+			}
+			for i, subschema := range subschemas {
+				if err := checkKeywords(subschema, fmt.Sprintf("%s/%d", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Validate checks a decoded document against the schema and describes every
+// problem, prefixed with the location of the offending value.
+func (s *Schema) Validate(doc any) []string {
+	return s.validate(s.root, doc, "$")
+}
+
+// ValidateFile checks a JSON or YAML file against the schema.
+func (s *Schema) ValidateFile(path, schemaPath string) error {
+	doc, err := document.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if problems := s.Validate(doc); len(problems) > 0 {
+		return &ValidationError{File: path, Schema: schemaPath, Problems: problems}
+	}
+	return nil
+}
+
+func (s *Schema) validate(schema map[string]any, value any, at string) []string {
+	if ref, ok := schema["$ref"].(string); ok {
+		target, err := s.resolve(ref)
+		if err != nil {
+			return []string{fmt.Sprintf("%s: %v", at, err)}
+		}
+		return s.validate(target, value, at)
+	}
+
+	if types, ok := schemaTypes(schema["type"]); ok && !matchesType(types, value) {
+		return []string{fmt.Sprintf("%s: expected %s, found %s", at, strings.Join(types, " or "), typeName(value))}
+	}
+
+	var problems []string
+	if enum, ok := schema["enum"].([]any); ok && !containsValue(enum, value) {
+		problems = append(problems, fmt.Sprintf("%s: %s is not one of %s", at, describe(value), describeList(enum)))
+	}
+	if constant, ok := schema["const"]; ok && !equalValues(constant, value) {
+		problems = append(problems, fmt.Sprintf("%s: must be %s", at, describe(constant)))
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		problems = append(problems, s.validateObject(schema, v, at)...)
+	case []any:
+		problems = append(problems, s.validateArray(schema, v, at)...)
+	case string:
+		problems = append(problems, validateString(schema, v, at)...)
+	default:
+		if number, ok := toFloat(v); ok {
+			problems = append(problems, validateNumber(schema, number, at)...)
+		}
+	}
+
+	if all, ok := schema["allOf"].([]any); ok {
+		for _, sub := range all {
+			if subSchema, ok := sub.(map[string]any); ok {
+				problems = append(problems, s.validate(subSchema, value, at)...)
+			}
+		}
+	}
+	if anyOf, ok := schema["anyOf"].([]any); ok {
+		matched := false
+		for _, sub := range anyOf {
+			if subSchema, ok := sub.(map[string]any); ok && len(s.validate(subSchema, value, at)) == 0 {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			problems = append(problems, fmt.Sprintf("%s: does not match any of the allowed schemas", at))
+		}
+	}
+	return problems
+}
+
+func (s *Schema) validateObject(schema map[string]any, object map[string]any, at string) []string {
+	var problems []string
+	if required, ok := schema["required"].([]any); ok {
+		for _, key := range required {
+			if name, ok := key.(string); ok {
+				if _, exists := object[name]; !exists {
+					problems = append(problems, fmt.Sprintf("%s: missing required property '%s'", at, name))
+				}
+			}
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]any)
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if sub, ok := properties[key].(map[string]any); ok {
+			problems = append(problems, s.validate(sub, object[key], at+"."+key)...)
+			continue
+		}
+		if _, declared := properties[key]; declared {
+			continue
+		}
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				problems = append(problems, fmt.Sprintf("%s: property '%s' is not allowed", at, key))
+			}
+		case map[string]any:
+			problems = append(problems, s.validate(additional, object[key], at+"."+key)...)
+		}
+	}
+	return problems
+}
+
+func (s *Schema) validateArray(schema map[string]any, array []any, at string) []string {
+	var problems []string
+	if minItems, ok := toFloat(schema["minItems"]); ok && float64(len(array)) < minItems {
+		problems = append(problems, fmt.Sprintf("%s: must have at least %v items", at, minItems))
+	}
+	if maxItems, ok := toFloat(schema["maxItems"]); ok && float64(len(array)) > maxItems {
+		problems = append(problems, fmt.Sprintf("%s: must have at most %v items", at, maxItems))
+	}
+	if items, ok := schema["items"].(map[string]any); ok {
+		for i, item := range array {
+			problems = append(problems, s.validate(items, item, fmt.Sprintf("%s[%d]", at, i))...)
+		}
+	}
+	return problems
+}
+
+func validateString(schema map[string]any, value, at string) []string {
+	var problems []string
+	length := float64(len([]rune(value)))
+	if minLength, ok := toFloat(schema["minLength"]); ok && length < minLength {
+		problems = append(problems, fmt.Sprintf("%s: must be at least %v characters", at, minLength))
+	}
+	if maxLength, ok := toFloat(schema["maxLength"]); ok && length > maxLength {
+		problems = append(problems, fmt.Sprintf("%s: must be at most %v characters", at, maxLength))
+	}
+	if pattern, ok := schema["pattern"].(string); ok {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: invalid pattern '%s' in schema", at, pattern))
+		} else if !re.MatchString(value) {
+			problems = append(problems, fmt.Sprintf("%s: '%s' does not match pattern '%s'", at, value, pattern))
+		}
+	}
+	return problems
+}
+
+func validateNumber(schema map[string]any, value float64, at string) []string {
+	var problems []string
+	if minimum, ok := toFloat(schema["minimum"]); ok && value < minimum {
+		problems = append(problems, fmt.Sprintf("%s: must be at least %v", at, minimum))
+	}
+	if maximum, ok := toFloat(schema["maximum"]); ok && value > maximum {
+		problems = append(problems, fmt.Sprintf("%s: must be at most %v", at, maximum))
+	}
+	return problems
+}
+
+// resolve follows a local reference such as #/definitions/statement.
+func (s *Schema) resolve(ref string) (map[string]any, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("unsupported $ref '%s' (only local references allowed)", ref)
+	}
+	var node any = s.root
+	for _, part := range strings.Split(strings.TrimPrefix(strings.TrimPrefix(ref, "#"), "/"), "/") {
+		if part == "" {
+			continue
+		}
+		part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		object, ok := node.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("$ref '%s' not found", ref)
+		}
+		if node, ok = object[part]; !ok {
+			return nil, fmt.Errorf("$ref '%s' not found", ref)
+		}
+	}
+	target, ok := node.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("$ref '%s' is not a schema", ref)
+	}
+	return target, nil
+}
+
+func schemaTypes(raw any) ([]string, bool) {
+	switch t := raw.(type) {
+	case string:
+		return []string{t}, true
+	case []any:
+		var types []string
+		for _, entry := range t {
+			if name, ok := entry.(string); ok {
+				types = append(types, name)
+			}
+		}
+		return types, len(types) > 0
+	}
+	return nil, false
+}
+
+func matchesType(types []string, value any) bool {
+	actual := typeName(value)
+	for _, t := range types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func typeName(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		if number, ok := toFloat(v); ok {
+			if number == math.Trunc(number) {
+				return "integer"
+			}
+			return "number"
+		}
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+func toFloat(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case document.Number:
+		return v.Float64()
+	}
+	return 0, false
+}
+
+func containsValue(list []any, value any) bool {
+	for _, entry := range list {
+		if equalValues(entry, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func equalValues(a, b any) bool {
+	if x, ok := toFloat(a); ok {
+		y, ok := toFloat(b)
+		return ok && x == y
+	}
+	return fmt.Sprint(a) == fmt.Sprint(b) && typeName(a) == typeName(b)
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func describe(value any) string {
+	if s, ok := value.(string); ok {
+		return "'" + s + "'"
+	}
+	return fmt.Sprint(value)
+}
+
+func describeList(values []any) string {
+	described := make([]string, len(values))
+	for i, value := range values {
+		described[i] = describe(value)
+	}
+	return strings.Join(described, ", ")
+}
@@ -0,0 +1,118 @@
+package jsonschema
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cfn-init/internal/document"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const importSchema = `{
+  "type": "array",
+  "minItems": 1,
+  "items": {"$ref": "#/definitions/resource"},
+  "definitions": {
+    "resource": {
+      "type": "object",
+      "required": ["ResourceType", "LogicalResourceId", "ResourceIdentifier"],
+      "additionalProperties": false,
+      "properties": {
+        "ResourceType": {"type": "string", "pattern": "^[A-Za-z0-9]+::[A-Za-z0-9]+::[A-Za-z0-9]+$"},
+        "LogicalResourceId": {"type": "string", "minLength": 1},
+        "ResourceIdentifier": {"type": "object", "additionalProperties": {"type": "string"}}
+      }
+    }
+  }
+}`
+
+func validate(t *testing.T, schema, content string) []string {
+	t.Helper()
+	s, err := Parse([]byte(schema))
+	assert.NoError(t, err)
+	doc, err := document.Decode([]byte(content))
+	assert.NoError(t, err)
+	return s.Validate(doc)
+}
+
+func TestValidate_Valid(t *testing.T) {
+	problems := validate(t, importSchema, `[{"ResourceType": "AWS::S3::Bucket", "LogicalResourceId": "Bucket", "ResourceIdentifier": {"BucketName": "logs"}}]`)
+
+	assert.Empty(t, problems)
+}
+
+func TestValidate_Problems(t *testing.T) {
+	problems := validate(t, importSchema, `[{"ResourceType": "S3 bucket", "ResourceIdentifier": {"BucketName": 3}, "Extra": true}]`)
+
+	assert.Equal(t, []string{
+		"$[0]: missing required property 'LogicalResourceId'",
+		"$[0]: property 'Extra' is not allowed",
+		"$[0].ResourceIdentifier.BucketName: expected string, found integer",
+		"$[0].ResourceType: 'S3 bucket' does not match pattern '^[A-Za-z0-9]+::[A-Za-z0-9]+::[A-Za-z0-9]+$'",
+	}, problems)
+}
+
+func TestValidate_Keywords(t *testing.T) {
+	schema := `
+type: object
+properties:
+  level: {enum: [low, high]}
+  count: {type: integer, minimum: 1, maximum: 3}
+  ratio: {type: number}
+  kind: {const: rule}
+  name: {anyOf: [{type: string, maxLength: 3}, {type: "null"}]}
+`
+	problems := validate(t, schema, "level: medium\ncount: 5\nratio: 0.5\nkind: other\nname: toolong\n")
+
+	assert.Equal(t, []string{
+		"$.count: must be at most 3",
+		"$.kind: must be 'rule'",
+		"$.level: 'medium' is not one of 'low', 'high'",
+		"$.name: does not match any of the allowed schemas",
+	}, problems)
+}
+
+func TestValidate_UnresolvedRef(t *testing.T) {
+	problems := validate(t, `{"$ref": "#/$defs/missing"}`, `{}`)
+
+	assert.Equal(t, []string{"$: $ref '#/$defs/missing' not found"}, problems)
+}
+
+func TestValidateFile(t *testing.T) {
+	dir := t.TempDir()
+	schemaPath := filepath.Join(dir, "schema.json")
+	assert.NoError(t, os.WriteFile(schemaPath, []byte(importSchema), 0644))
+	filePath := filepath.Join(dir, "import.json")
+	assert.NoError(t, os.WriteFile(filePath, []byte(`[]`), 0644))
+
+	s, err := ReadFile(schemaPath)
+	assert.NoError(t, err)
+	err = s.ValidateFile(filePath, "schema.json")
+
+	assert.EqualError(t, err, "file "+filePath+" does not match schema schema.json: $: must have at least 1 items")
+}
+
+func TestParse_NotAnObject(t *testing.T) {
+	_, err := Parse([]byte(`["type"]`))
+
+	assert.EqualError(t, err, "schema must be an object")
+}
+
+func TestParse_UnsupportedKeywords(t *testing.T) {
+	_, err := Parse([]byte(`{"properties": {"Name": {"oneOf": [{"type": "string"}]}}}`))
+	assert.EqualError(t, err, "unsupported keyword 'oneOf' at #/properties/Name")
+
+	_, err = Parse([]byte(`{"type": "array", "items": [{"type": "string"}]}`))
+	assert.EqualError(t, err, "unsupported list of items schemas at #")
+
+	_, err = Parse([]byte(`{"anyOf": [{"if": {"type": "string"}, "then": {"minLength": 1}}]}`))
+	assert.EqualError(t, err, "unsupported keyword 'if' at #/anyOf/0")
+
+	_, err = Parse([]byte(`{"$ref": "https://example.com/schema.json"}`))
+	assert.EqualError(t, err, "unsupported $ref 'https://example.com/schema.json' at # (only local references allowed)")
+
+	_, err = Parse([]byte(`{"$schema": "http://json-schema.org/draft-07/schema#", "title": "Tags", "definitions": {"Tag": {"type": "string", "description": "A tag"}}, "additionalProperties": {"$ref": "#/definitions/Tag"}}`))
+	assert.NoError(t, err)
+}
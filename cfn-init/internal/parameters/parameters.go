@@ -0,0 +1,170 @@
+package parameters
+
+import (
+	"cfn-init/internal/document"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Format identifies the layout of a parameters file.
+type Format string
+
+const (
+	// FormatCLI is the AWS CLI layout: [{"ParameterKey": "...", "ParameterValue": "..."}]
+	FormatCLI Format = "cli"
+	// FormatMap is a flat object of parameter names to values: {"Key": "Value"}
+	FormatMap Format = "map"
+	// FormatGitSync is a GitSync deployment file with a "parameters" object
+	FormatGitSync Format = "gitsync"
+)
+
+// ReadFile loads parameter values from a parameters file in any supported format.
+func ReadFile(path string) (map[string]string, Format, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+	values, format, err := Parse(data)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", path, err)
+	}
+	return values, format, nil
+}
+
+// Parse detects the format of parameters file content and returns its values.
+func Parse(data []byte) (map[string]string, Format, error) {
+	doc, err := document.Decode(data)
+	if err != nil {
+		return nil, "", err
+	}
+
+	switch content := doc.(type) {
+	case []any:
+		values, err := parseCLI(content)
+		return values, FormatCLI, err
+	case map[string]any:
+		if isGitSync(content) {
+			nested, _ := content["parameters"].(map[string]any)
+			values, err := parseMap(nested)
+			return values, FormatGitSync, err
+		}
+		values, err := parseMap(content)
+		return values, FormatMap, err
+	default:
+		return nil, "", fmt.Errorf("not a parameters file")
+	}
+}
+
+// MarshalCLI encodes parameter values in the AWS CLI layout, sorted by key.
+func MarshalCLI(values map[string]string) ([]byte, error) {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	type cliParameter struct {
+		ParameterKey   string `json:"ParameterKey"`
+		ParameterValue string `json:"ParameterValue"`
+	}
+	entries := make([]cliParameter, 0, len(keys))
+	for _, key := range keys {
+		entries = append(entries, cliParameter{ParameterKey: key, ParameterValue: values[key]})
+	}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// Marshal encodes parameter values in the given format, sorted by key.
+func Marshal(values map[string]string, format Format) ([]byte, error) {
+	var doc any
+	switch format {
+	case FormatCLI:
+		return MarshalCLI(values)
+	case FormatMap:
+		doc = values
+	case FormatGitSync:
+		doc = map[string]any{"parameters": values}
+	default:
+		return nil, fmt.Errorf("unsupported parameters format '%s' (only cli, map, gitsync allowed)", format)
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// isGitSync reports whether an object uses GitSync deployment file keys.
+func isGitSync(content map[string]any) bool {
+	for _, key := range []string{"template-file-path", "parameters", "tags"} {
+		if _, ok := content[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func parseCLI(entries []any) (map[string]string, error) {
+	values := make(map[string]string, len(entries))
+	for i, raw := range entries {
+		entry, ok := raw.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("entry %d is not an object", i)
+		}
+		key, ok := entry["ParameterKey"].(string)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("entry %d is missing ParameterKey", i)
+		}
+		value, ok := stringValue(entry["ParameterValue"])
+		if !ok {
+			return nil, fmt.Errorf("parameter '%s' has no string ParameterValue", key)
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+func parseMap(entries map[string]any) (map[string]string, error) {
+	values := make(map[string]string, len(entries))
+	for key, raw := range entries {
+		value, ok := stringValue(raw)
+		if !ok {
+			return nil, fmt.Errorf("parameter '%s' is not a scalar value", key)
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+func stringValue(value any) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case bool, int, int64, float64, document.Number:
+		return fmt.Sprint(v), true
+	case []any:
+		// CommaDelimitedList parameters may be written as lists
+		result := ""
+		for i, item := range v {
+			s, ok := stringValue(item)
+			if !ok {
+				return "", false
+			}
+			if i > 0 {
+				result += ","
+			}
+			result += s
+		}
+		return result, true
+	default:
+		return "", false
+	}
+}
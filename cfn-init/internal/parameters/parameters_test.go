@@ -0,0 +1,83 @@
+package parameters
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse_CLIFormat(t *testing.T) {
+	values, format, err := Parse([]byte(`[{"ParameterKey": "Env", "ParameterValue": "prod"}]`))
+
+	assert.NoError(t, err)
+	assert.Equal(t, FormatCLI, format)
+	assert.Equal(t, map[string]string{"Env": "prod"}, values)
+}
+
+func TestParse_MapFormat(t *testing.T) {
+	values, format, err := Parse([]byte("Env: prod\nCount: 3\n"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, FormatMap, format)
+	assert.Equal(t, map[string]string{"Env": "prod", "Count": "3"}, values)
+}
+
+func TestParse_ScalarsAsWritten(t *testing.T) {
+	values, _, err := Parse([]byte("ReleaseDate: 2024-01-01\nVersion: 1.10\nAccount: 123456789012345678901234\n"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"ReleaseDate": "2024-01-01", "Version": "1.10", "Account": "123456789012345678901234"}, values)
+}
+
+func TestParse_GitSyncFormat(t *testing.T) {
+	content := `{"template-file-path": "template.yaml", "parameters": {"Env": "prod"}, "tags": {"Team": "core"}}`
+	values, format, err := Parse([]byte(content))
+
+	assert.NoError(t, err)
+	assert.Equal(t, FormatGitSync, format)
+	assert.Equal(t, map[string]string{"Env": "prod"}, values)
+}
+
+func TestParse_TagsListIsRejected(t *testing.T) {
+	_, _, err := Parse([]byte(`[{"Key": "Team", "Value": "core"}]`))
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "missing ParameterKey")
+}
+
+func TestParse_ListValuesAreJoined(t *testing.T) {
+	values, _, err := Parse([]byte(`{"Subnets": ["a", "b"]}`))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "a,b", values["Subnets"])
+}
+
+func TestMarshalCLI(t *testing.T) {
+	data, err := MarshalCLI(map[string]string{"B": "2", "A": "1"})
+
+	assert.NoError(t, err)
+	values, format, err := Parse(data)
+	assert.NoError(t, err)
+	assert.Equal(t, FormatCLI, format)
+	assert.Equal(t, map[string]string{"A": "1", "B": "2"}, values)
+}
+
+func TestMarshalFormats(t *testing.T) {
+	values := map[string]string{"B": "2", "A": "1"}
+
+	for _, format := range []Format{FormatCLI, FormatMap, FormatGitSync} {
+		data, err := Marshal(values, format)
+		assert.NoError(t, err)
+
+		parsed, parsedFormat, err := Parse(data)
+		assert.NoError(t, err)
+		assert.Equal(t, format, parsedFormat)
+		assert.Equal(t, map[string]string{"A": "1", "B": "2"}, parsed)
+	}
+
+	data, _ := Marshal(values, FormatMap)
+	assert.Equal(t, "{\n  \"A\": \"1\",\n  \"B\": \"2\"\n}\n", string(data))
+
+	_, err := Marshal(values, "xml")
+	assert.EqualError(t, err, "unsupported parameters format 'xml' (only cli, map, gitsync allowed)")
+}
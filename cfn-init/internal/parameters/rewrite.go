@@ -0,0 +1,188 @@
+package parameters
+
+import (
+	"bytes"
+	"cfn-init/internal/document"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ValueFunc returns the new value of a parameter.
+type ValueFunc func(name, value string) (string, error)
+
+// Rewrite replaces the parameter values of a parameters or GitSync file in
+// place, keeping its format, key order and YAML comments. It returns the
+// number of values that changed; the file is only written when one did.
+func Rewrite(path string, fn ValueFunc) (int, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	out, changed, err := RewriteContent(data, fn)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", path, err)
+	}
+	if changed == 0 {
+		return 0, nil
+	}
+	if err := os.WriteFile(path, out, info.Mode().Perm()); err != nil {
+		return 0, fmt.Errorf("failed to write '%s': %w", path, err)
+	}
+	return changed, nil
+}
+
+// RewriteContent applies fn to every parameter value of parameters file content.
+func RewriteContent(data []byte, fn ValueFunc) ([]byte, int, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, 0, err
+	}
+	if len(root.Content) == 0 {
+		return nil, 0, fmt.Errorf("not a parameters file")
+	}
+
+	changed, err := rewriteNode(root.Content[0], fn)
+	if err != nil || changed == 0 {
+		return data, changed, err
+	}
+
+	if document.IsJSON(data) {
+		var compact bytes.Buffer
+		writeJSON(&compact, root.Content[0])
+		var out bytes.Buffer
+		if err := json.Indent(&out, compact.Bytes(), "", "  "); err != nil {
+			return nil, 0, err
+		}
+		out.WriteByte('\n')
+		return out.Bytes(), changed, nil
+	}
+
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&root); err != nil {
+		return nil, 0, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, 0, err
+	}
+	return out.Bytes(), changed, nil
+}
+
+func rewriteNode(node *yaml.Node, fn ValueFunc) (int, error) {
+	switch node.Kind {
+	case yaml.SequenceNode:
+		changed := 0
+		for _, entry := range node.Content {
+			key := mappingValue(entry, "ParameterKey")
+			value := mappingValue(entry, "ParameterValue")
+			if key == nil || value == nil || value.Kind != yaml.ScalarNode {
+				continue
+			}
+			ok, err := rewriteScalar(key.Value, value, fn)
+			if err != nil {
+				return changed, err
+			}
+			if ok {
+				changed++
+			}
+		}
+		return changed, nil
+	case yaml.MappingNode:
+		for _, key := range []string{"template-file-path", "parameters", "tags"} {
+			if mappingValue(node, key) != nil {
+				nested := mappingValue(node, "parameters")
+				if nested == nil || nested.Kind != yaml.MappingNode {
+					return 0, nil
+				}
+				return rewriteNode(nested, fn)
+			}
+		}
+		changed := 0
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			value := node.Content[i+1]
+			if value.Kind != yaml.ScalarNode {
+				continue
+			}
+			ok, err := rewriteScalar(node.Content[i].Value, value, fn)
+			if err != nil {
+				return changed, err
+			}
+			if ok {
+				changed++
+			}
+		}
+		return changed, nil
+	default:
+		return 0, fmt.Errorf("not a parameters file")
+	}
+}
+
+func rewriteScalar(name string, value *yaml.Node, fn ValueFunc) (bool, error) {
+	updated, err := fn(name, value.Value)
+	if err != nil {
+		return false, err
+	}
+	if updated == value.Value {
+		return false, nil
+	}
+	value.Value = updated
+	value.Tag = "!!str"
+	value.Style = 0
+	return true, nil
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// writeJSON writes a node parsed from JSON back as compact JSON, keeping key order.
+func writeJSON(buf *bytes.Buffer, node *yaml.Node) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		buf.WriteByte('{')
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			key, _ := json.Marshal(node.Content[i].Value)
+			buf.Write(key)
+			buf.WriteByte(':')
+			writeJSON(buf, node.Content[i+1])
+		}
+		buf.WriteByte('}')
+	case yaml.SequenceNode:
+		buf.WriteByte('[')
+		for i, item := range node.Content {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeJSON(buf, item)
+		}
+		buf.WriteByte(']')
+	default:
+		switch node.Tag {
+		case "!!int", "!!float", "!!bool", "!!null":
+			buf.WriteString(node.Value)
+		default:
+			data, _ := json.Marshal(node.Value)
+			buf.Write(data)
+		}
+	}
+}
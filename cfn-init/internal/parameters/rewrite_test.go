@@ -0,0 +1,69 @@
+package parameters
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func upperPassword(name, value string) (string, error) {
+	if name == "Password" {
+		return strings.ToUpper(value), nil
+	}
+	return value, nil
+}
+
+func TestRewriteContent_CLI(t *testing.T) {
+	data := []byte(`[{"ParameterKey": "Password", "ParameterValue": "secret"}, {"ParameterKey": "Size", "ParameterValue": 3}]`)
+
+	out, changed, err := RewriteContent(data, upperPassword)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, changed)
+	assert.Equal(t, `[
+  {
+    "ParameterKey": "Password",
+    "ParameterValue": "SECRET"
+  },
+  {
+    "ParameterKey": "Size",
+    "ParameterValue": 3
+  }
+]
+`, string(out))
+}
+
+func TestRewriteContent_YAMLKeepsComments(t *testing.T) {
+	data := []byte("# database\nPassword: secret\nEnv: dev\n")
+
+	out, changed, err := RewriteContent(data, upperPassword)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, changed)
+	assert.Equal(t, "# database\nPassword: SECRET\nEnv: dev\n", string(out))
+}
+
+func TestRewriteContent_GitSync(t *testing.T) {
+	data := []byte(`{"template-file-path": "t.yaml", "parameters": {"Password": "secret"}, "tags": {"Password": "tag"}}`)
+
+	out, changed, err := RewriteContent(data, upperPassword)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, changed)
+	values, format, err := Parse(out)
+	assert.NoError(t, err)
+	assert.Equal(t, FormatGitSync, format)
+	assert.Equal(t, map[string]string{"Password": "SECRET"}, values)
+	assert.Contains(t, string(out), `"Password": "tag"`)
+}
+
+func TestRewriteContent_Unchanged(t *testing.T) {
+	data := []byte("Env: dev\n")
+
+	out, changed, err := RewriteContent(data, upperPassword)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, changed)
+	assert.Equal(t, data, out)
+}
@@ -9,4 +9,10 @@ const (
 	
 	// ConfigFile defines permissions for created configuration files (rw-r--r--)
 	ConfigFile os.FileMode = 0644
+
+	// SecretDir defines permissions for directories holding decrypted files (rwx------)
+	SecretDir os.FileMode = 0700
+
+	// SecretFile defines permissions for key files and decrypted files (rw-------)
+	SecretFile os.FileMode = 0600
 )
@@ -0,0 +1,161 @@
+package policy
+
+import (
+	"cfn-init/internal/config"
+	"cfn-init/internal/environment"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// GuardError reports the error violations a change would introduce.
+type GuardError struct {
+	Violations []Violation
+}
+
+func (e *GuardError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		messages[i] = v.String()
+	}
+	return fmt.Sprintf("change rejected by policy: %s", strings.Join(messages, "; "))
+}
+
+// Guard runs a change to the project and checks the project's policies
+// before and after it. When the change introduces error violations, the
+// project folder is restored and a GuardError is returned. The folder is
+// also restored when the change or the check after it fails, so a change
+// that fails halfway leaves nothing behind. Violations that
+// existed before the change do not block it, so changes that fix some
+// violations but not all are allowed. The new warnings are returned.
+func Guard(change func() error) ([]Violation, error) {
+	configFile, err := config.ReadConfigFile(".")
+	if err != nil || len(configFile.Policies) == 0 {
+		return nil, change()
+	}
+
+	before, err := Evaluate(configFile, "")
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot, err := os.MkdirTemp("", "cfn-project-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot project: %w", err)
+	}
+	defer os.RemoveAll(snapshot)
+	if err := copyDir(environment.ProjectDir, snapshot); err != nil {
+		return nil, fmt.Errorf("failed to snapshot project: %w", err)
+	}
+
+	if err := change(); err != nil {
+		return nil, restoreAfter(snapshot, err)
+	}
+
+	configFile, err = config.ReadConfigFile(".")
+	if err != nil {
+		return nil, restoreAfter(snapshot, err)
+	}
+	after, err := Evaluate(configFile, "")
+	if err != nil {
+		return nil, restoreAfter(snapshot, err)
+	}
+
+	introduced := newViolations(before, after)
+	var errors, warnings []Violation
+	for _, v := range introduced {
+		if v.Severity == SeverityError {
+			errors = append(errors, v)
+		} else {
+			warnings = append(warnings, v)
+		}
+	}
+	if len(errors) > 0 {
+		if err := restoreDir(snapshot, environment.ProjectDir); err != nil {
+			return nil, fmt.Errorf("failed to restore project after policy violation: %w", err)
+		}
+		return nil, &GuardError{Violations: errors}
+	}
+	return warnings, nil
+}
+
+func newViolations(before, after []Violation) []Violation {
+	existing := make(map[Violation]bool, len(before))
+	for _, v := range before {
+		existing[v] = true
+	}
+	var introduced []Violation
+	for _, v := range after {
+		if !existing[v] {
+			introduced = append(introduced, v)
+		}
+	}
+	return introduced
+}
+
+// restoreAfter restores the project folder after a failed change and
+// returns the failure.
+func restoreAfter(snapshot string, cause error) error {
+	if err := restoreDir(snapshot, environment.ProjectDir); err != nil {
+		return fmt.Errorf("%w (failed to restore project: %v)", cause, err)
+	}
+	return cause
+}
+
+func restoreDir(snapshot, dir string) error {
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	return copyDir(snapshot, dir)
+}
+
+// copyDir copies a directory tree, keeping file modes. Symbolic links are
+// copied as links, so environment files linked to their sources stay linked.
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return os.MkdirAll(target, info.Mode().Perm())
+		}
+		if entry.Type()&fs.ModeSymlink != 0 {
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		}
+		return copyFile(path, target, info.Mode().Perm())
+	})
+}
+
+func copyFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
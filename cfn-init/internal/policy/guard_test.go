@@ -0,0 +1,91 @@
+package policy
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cfn-init/internal/config"
+	"cfn-init/internal/environment"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGuard_RollsBackNewErrors(t *testing.T) {
+	setupTestProject(t, config.Policy{Name: "regions", AllowedRegions: []string{"us-east-1", "eu-west-1"}})
+	region := "ap-south-1"
+
+	_, err := Guard(func() error {
+		return environment.UpdateEnvironment("prod", nil, nil, &region, nil)
+	})
+
+	var guardErr *GuardError
+	assert.ErrorAs(t, err, &guardErr)
+	assert.Len(t, guardErr.Violations, 1)
+	configFile, err := config.ReadConfigFile(".")
+	assert.NoError(t, err)
+	assert.Equal(t, "eu-west-1", configFile.Environments["prod"].Region)
+}
+
+func TestGuard_AllowsExistingViolations(t *testing.T) {
+	setupTestProject(t, config.Policy{Name: "tags", RequireTags: []string{"CostCenter", "Owner"}})
+
+	warnings, err := Guard(func() error {
+		path := filepath.Join(environment.ProjectDir, environment.EnvironmentsDir, "prod", "tags.json")
+		return os.WriteFile(path, []byte(`{"CostCenter": "42"}`), 0644)
+	})
+
+	assert.NoError(t, err)
+	assert.Empty(t, warnings)
+	assert.FileExists(t, filepath.Join(environment.ProjectDir, environment.EnvironmentsDir, "prod", "tags.json"))
+}
+
+func TestGuard_ReturnsNewWarnings(t *testing.T) {
+	setupTestProject(t, config.Policy{Name: "params", Severity: SeverityWarning, RequireParameters: []string{"Env"}})
+	writeEnvironmentFile(t, "prod", "params.json", `{"Env": "prod"}`)
+
+	warnings, err := Guard(func() error {
+		return environment.RemoveEnvironment("staging", false)
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, warnings)
+
+	warnings, err = Guard(func() error {
+		return os.Remove(filepath.Join(environment.ProjectDir, environment.EnvironmentsDir, "prod", "params.json"))
+	})
+	assert.NoError(t, err)
+	assert.Len(t, warnings, 1)
+}
+
+func TestGuard_RestoresFailedChange(t *testing.T) {
+	setupTestProject(t, config.Policy{Name: "regions", AllowedRegions: []string{"us-east-1", "eu-west-1"}})
+	path := filepath.Join(environment.ProjectDir, environment.EnvironmentsDir, "prod", "params.json")
+
+	_, err := Guard(func() error {
+		if err := os.WriteFile(path, []byte(`{"Env": "prod"}`), 0644); err != nil {
+			return err
+		}
+		return errors.New("change failed")
+	})
+
+	assert.EqualError(t, err, "change failed")
+	assert.NoFileExists(t, path)
+}
+
+func TestGuard_RestoresSymlinks(t *testing.T) {
+	setupTestProject(t, config.Policy{Name: "regions", AllowedRegions: []string{"us-east-1", "eu-west-1"}})
+	assert.NoError(t, os.WriteFile("shared.json", []byte(`{"Env": "prod"}`), 0644))
+	link := filepath.Join(environment.ProjectDir, environment.EnvironmentsDir, "prod", "params.json")
+	assert.NoError(t, os.Symlink(filepath.Join("..", "..", "..", "shared.json"), link))
+	region := "ap-south-1"
+
+	_, err := Guard(func() error {
+		return environment.UpdateEnvironment("prod", nil, nil, &region, nil)
+	})
+
+	assert.Error(t, err)
+	target, err := os.Readlink(link)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join("..", "..", "..", "shared.json"), target)
+}
@@ -0,0 +1,268 @@
+package policy
+
+import (
+	"cfn-init/internal/config"
+	"cfn-init/internal/encryption"
+	"cfn-init/internal/environment"
+	"cfn-init/internal/secrets"
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Policy severities. Errors fail checks and block changes, warnings are only reported.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Violation is a policy that does not hold for an environment.
+type Violation struct {
+	Policy      string
+	Severity    string
+	Environment string
+	Message     string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("[%s] %s: %s", v.Severity, v.Policy, v.Message)
+}
+
+// environmentFacts are the values policies are checked against. Tags and
+// parameters are resolved per stack, with "" for the environment itself.
+type environmentFacts struct {
+	env        config.Environment
+	tags       map[string]map[string]string
+	parameters map[string]map[string]string
+	conflicts  []environment.Conflict
+}
+
+// Validate checks that every policy in the configuration is well formed.
+func Validate(policies []config.Policy) error {
+	names := make(map[string]bool)
+	for i, p := range policies {
+		if p.Name == "" {
+			return fmt.Errorf("policy %d has no name", i+1)
+		}
+		if names[p.Name] {
+			return fmt.Errorf("policy '%s' is defined more than once", p.Name)
+		}
+		names[p.Name] = true
+
+		if p.Severity != "" && p.Severity != SeverityError && p.Severity != SeverityWarning {
+			return fmt.Errorf("policy '%s' has invalid severity '%s' (only error, warning allowed)", p.Name, p.Severity)
+		}
+		for _, pattern := range append(append([]string{}, p.Environments...), p.ForbidLiteralParameters...) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("policy '%s' has invalid pattern '%s'", p.Name, pattern)
+			}
+		}
+		if len(p.RequireTags) == 0 && len(p.RequireParameters) == 0 && len(p.ForbidLiteralParameters) == 0 &&
+			!p.DistinctProfiles && len(p.AllowedRegions) == 0 && !p.ForbidParameterConflicts {
+			return fmt.Errorf("policy '%s' has no rules", p.Name)
+		}
+	}
+	return nil
+}
+
+// Evaluate checks the project's policies against its environments, or only
+// against envName when given, and returns the violations in policy order.
+func Evaluate(configFile *config.ProjectConfig, envName string) ([]Violation, error) {
+	if err := Validate(configFile.Policies); err != nil {
+		return nil, err
+	}
+	if envName != "" {
+		if _, exists := configFile.Environments[envName]; !exists {
+			return nil, fmt.Errorf("environment '%s' not found", envName)
+		}
+	}
+
+	facts := make(map[string]*environmentFacts)
+	var violations []Violation
+	for _, p := range configFile.Policies {
+		severity := p.Severity
+		if severity == "" {
+			severity = SeverityError
+		}
+		report := func(env, format string, args ...any) {
+			violations = append(violations, Violation{
+				Policy:      p.Name,
+				Severity:    severity,
+				Environment: env,
+				Message:     fmt.Sprintf(format, args...),
+			})
+		}
+
+		envNames := matchingEnvironments(configFile, p.Environments)
+		for _, name := range envNames {
+			if _, ok := facts[name]; ok {
+				continue
+			}
+			f, err := loadFacts(configFile, name)
+			if err != nil {
+				return nil, err
+			}
+			facts[name] = f
+		}
+		for _, name := range envNames {
+			if envName == "" || name == envName {
+				checkEnvironment(p, name, facts[name], report)
+			}
+		}
+
+		if p.DistinctProfiles {
+			owners := make(map[string]string)
+			for _, name := range envNames {
+				profile := facts[name].env.Profile
+				if owner, ok := owners[profile]; ok && (envName == "" || envName == name || envName == owner) {
+					report(name, "environments '%s' and '%s' share profile '%s'", owner, name, profile)
+					continue
+				}
+				owners[profile] = name
+			}
+		}
+	}
+	return violations, nil
+}
+
+// ConflictSeverity returns the strictest severity of the policies that forbid
+// parameter conflicts in an environment, or "" when none applies.
+func ConflictSeverity(configFile *config.ProjectConfig, envName string) string {
+	severity := ""
+	for _, p := range configFile.Policies {
+		if !p.ForbidParameterConflicts || (len(p.Environments) > 0 && !matchesAny(p.Environments, envName)) {
+			continue
+		}
+		if p.Severity == "" || p.Severity == SeverityError {
+			return SeverityError
+		}
+		severity = SeverityWarning
+	}
+	return severity
+}
+
+// HasErrors reports whether any violation has error severity.
+func HasErrors(violations []Violation) bool {
+	for _, v := range violations {
+		if v.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+func checkEnvironment(p config.Policy, envName string, f *environmentFacts, report func(env, format string, args ...any)) {
+	for _, scope := range sortedKeys(f.tags) {
+		for _, key := range p.RequireTags {
+			if _, ok := f.tags[scope][key]; !ok {
+				report(envName, "environment '%s'%s is missing required tag '%s'", envName, scopeSuffix(scope), key)
+			}
+		}
+	}
+
+	for _, scope := range sortedKeys(f.parameters) {
+		params := f.parameters[scope]
+		for _, name := range p.RequireParameters {
+			if _, ok := params[name]; !ok {
+				report(envName, "environment '%s'%s is missing required parameter '%s'", envName, scopeSuffix(scope), name)
+			}
+		}
+		for _, name := range sortedKeys(params) {
+			value := params[name]
+			if !matchesAny(p.ForbidLiteralParameters, name) || strings.HasPrefix(value, secrets.DynamicReferencePrefix) || encryption.IsEncrypted(value) {
+				continue
+			}
+			report(envName, "environment '%s'%s: parameter '%s' holds a literal value; use a dynamic reference such as {{resolve:secretsmanager:...}}", envName, scopeSuffix(scope), name)
+		}
+	}
+
+	if p.ForbidParameterConflicts {
+		for _, c := range f.conflicts {
+			report(envName, "environment '%s': parameter '%s' is set differently by %s", envName, c.Parameter, strings.Join(c.Sources, ", "))
+		}
+	}
+
+	if len(p.AllowedRegions) > 0 && !slices.Contains(p.AllowedRegions, f.env.Region) {
+		region := f.env.Region
+		if region == "" {
+			region = "none"
+		}
+		report(envName, "environment '%s' region '%s' is not one of %s", envName, region, strings.Join(p.AllowedRegions, ", "))
+	}
+}
+
+// loadFacts resolves an environment's tags and parameters. With stacks in the
+// project, each stack's effective values are checked instead of the
+// environment-wide ones alone.
+func loadFacts(configFile *config.ProjectConfig, envName string) (*environmentFacts, error) {
+	env, err := configFile.ResolveEnvironment(envName)
+	if err != nil {
+		return nil, err
+	}
+	f := &environmentFacts{
+		env:        env,
+		tags:       make(map[string]map[string]string),
+		parameters: make(map[string]map[string]string),
+	}
+
+	scopes := sortedKeys(configFile.Stacks)
+	if len(scopes) == 0 {
+		scopes = []string{""}
+	}
+	seen := make(map[string]bool)
+	for _, stackName := range scopes {
+		values, err := environment.ResolveValues(envName, stackName)
+		if err != nil {
+			return nil, err
+		}
+		f.tags[stackName] = values.TagValues()
+		f.parameters[stackName] = values.ParameterValues()
+
+		// Environment folder conflicts show up in every stack scope
+		for _, c := range values.Conflicts {
+			key := c.Parameter + "\x00" + strings.Join(c.Sources, "\x00")
+			if !seen[key] {
+				seen[key] = true
+				f.conflicts = append(f.conflicts, c)
+			}
+		}
+	}
+	return f, nil
+}
+
+func matchingEnvironments(configFile *config.ProjectConfig, patterns []string) []string {
+	var names []string
+	for _, name := range sortedKeys(configFile.Environments) {
+		if len(patterns) == 0 || matchesAny(patterns, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func scopeSuffix(stackName string) string {
+	if stackName == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (stack '%s')", stackName)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
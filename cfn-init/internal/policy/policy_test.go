@@ -0,0 +1,136 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cfn-init/internal"
+	"cfn-init/internal/bootstrap"
+	"cfn-init/internal/config"
+	"cfn-init/internal/environment"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupTestProject(t *testing.T, policies ...config.Policy) {
+	tempDir := t.TempDir()
+	err := bootstrap.Init("test-project", tempDir)
+	assert.NoError(t, err)
+
+	originalDir, _ := os.Getwd()
+	err = os.Chdir(tempDir)
+	assert.NoError(t, err)
+
+	t.Cleanup(func() {
+		os.Chdir(originalDir)
+	})
+
+	err = environment.AddEnvironments([]internal.EnvironmentConfig{
+		{Name: "staging", AwsProfile: "shared", Region: "us-east-1"},
+		{Name: "prod", AwsProfile: "shared", Region: "eu-west-1"},
+	})
+	assert.NoError(t, err)
+
+	configFile, err := config.ReadConfigFile(".")
+	assert.NoError(t, err)
+	configFile.Policies = policies
+	assert.NoError(t, config.WriteConfigFile(".", configFile))
+}
+
+func writeEnvironmentFile(t *testing.T, envName, name, content string) {
+	t.Helper()
+	path := filepath.Join(environment.ProjectDir, environment.EnvironmentsDir, envName, name)
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func evaluate(t *testing.T, envName string) []Violation {
+	t.Helper()
+	configFile, err := config.ReadConfigFile(".")
+	assert.NoError(t, err)
+	violations, err := Evaluate(configFile, envName)
+	assert.NoError(t, err)
+	return violations
+}
+
+func TestEvaluate_RequireTags(t *testing.T) {
+	setupTestProject(t, config.Policy{Name: "prod-tags", Environments: []string{"prod*"}, RequireTags: []string{"CostCenter", "Owner"}})
+	writeEnvironmentFile(t, "prod", "tags.json", `{"CostCenter": "42"}`)
+
+	violations := evaluate(t, "")
+
+	assert.Equal(t, []Violation{{
+		Policy:      "prod-tags",
+		Severity:    SeverityError,
+		Environment: "prod",
+		Message:     "environment 'prod' is missing required tag 'Owner'",
+	}}, violations)
+}
+
+func TestEvaluate_DistinctProfiles(t *testing.T) {
+	setupTestProject(t, config.Policy{Name: "profiles", Severity: SeverityWarning, DistinctProfiles: true})
+
+	violations := evaluate(t, "")
+
+	assert.Len(t, violations, 1)
+	assert.Equal(t, "environments 'prod' and 'staging' share profile 'shared'", violations[0].Message)
+	assert.False(t, HasErrors(violations))
+}
+
+func TestEvaluate_ForbidLiteralParameters(t *testing.T) {
+	setupTestProject(t, config.Policy{Name: "secrets", ForbidLiteralParameters: []string{"*Password"}})
+	writeEnvironmentFile(t, "prod", "params.json", `{"DbPassword": "hunter2", "Env": "prod"}`)
+	writeEnvironmentFile(t, "staging", "params.json", `{"DbPassword": "{{resolve:secretsmanager:db:SecretString:password}}"}`)
+
+	violations := evaluate(t, "")
+
+	assert.Len(t, violations, 1)
+	assert.Equal(t, "prod", violations[0].Environment)
+	assert.Contains(t, violations[0].Message, "parameter 'DbPassword' holds a literal value")
+}
+
+func TestEvaluate_AllowedRegionsForOneEnvironment(t *testing.T) {
+	setupTestProject(t, config.Policy{Name: "regions", AllowedRegions: []string{"us-east-1"}})
+
+	assert.Empty(t, evaluate(t, "staging"))
+	assert.Len(t, evaluate(t, "prod"), 1)
+}
+
+func TestEvaluate_ForbidParameterConflicts(t *testing.T) {
+	setupTestProject(t, config.Policy{Name: "conflicts", Severity: SeverityWarning, ForbidParameterConflicts: true})
+	writeEnvironmentFile(t, "prod", "a.json", `{"Env": "prod", "Size": "large"}`)
+	writeEnvironmentFile(t, "prod", "b.json", `{"Env": "prod", "Size": "small"}`)
+
+	violations := evaluate(t, "")
+
+	assert.Equal(t, []Violation{{
+		Policy:      "conflicts",
+		Severity:    SeverityWarning,
+		Environment: "prod",
+		Message:     "environment 'prod': parameter 'Size' is set differently by environments/prod/a.json, environments/prod/b.json",
+	}}, violations)
+}
+
+func TestConflictSeverity(t *testing.T) {
+	configFile := &config.ProjectConfig{Policies: []config.Policy{
+		{Name: "all", Severity: SeverityWarning, ForbidParameterConflicts: true},
+		{Name: "prod", Environments: []string{"prod*"}, ForbidParameterConflicts: true},
+		{Name: "tags", RequireTags: []string{"Owner"}},
+	}}
+
+	assert.Equal(t, SeverityError, ConflictSeverity(configFile, "prod-eu"))
+	assert.Equal(t, SeverityWarning, ConflictSeverity(configFile, "dev"))
+	assert.Equal(t, "", ConflictSeverity(&config.ProjectConfig{}, "dev"))
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate([]config.Policy{{Name: "tags", RequireTags: []string{"Owner"}}}))
+
+	err := Validate([]config.Policy{{Name: "tags", Severity: "fatal", RequireTags: []string{"Owner"}}})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid severity 'fatal'")
+
+	err = Validate([]config.Policy{{Name: "empty"}})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "has no rules")
+}
@@ -0,0 +1,309 @@
+package render
+
+import (
+	"bytes"
+	"cfn-init/internal/template"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats supported by Marshal.
+const (
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+)
+
+var subVariable = regexp.MustCompile(`\$\{([^!}][^}]*)\}`)
+
+// sectionOrder is the conventional order of top-level template sections.
+var sectionOrder = []string{
+	"AWSTemplateFormatVersion",
+	"Description",
+	"Metadata",
+	"Transform",
+	"Parameters",
+	"Rules",
+	"Mappings",
+	"Conditions",
+	"Resources",
+	"Outputs",
+}
+
+// Render produces a copy of the template with every statically known value
+// substituted, Fn::If branches chosen and resources and outputs whose
+// condition is false removed. Values that are only known at deploy time are
+// kept as intrinsic function placeholders. Conditions that are still needed
+// are resolved the same way, and parameters that they or Rules still name
+// keep their declarations.
+func Render(tmpl *template.Template, evaluator *template.Evaluator) (map[string]any, error) {
+	body := make(map[string]any, len(tmpl.Body))
+	for key, value := range tmpl.Body {
+		body[key] = value
+	}
+
+	resources, pruned, err := renderSection(tmpl.Resources, evaluator)
+	if err != nil {
+		return nil, fmt.Errorf("resources: %w", err)
+	}
+	for _, resource := range resources {
+		removeDependencies(resource, pruned)
+	}
+	body["Resources"] = resources
+
+	if len(tmpl.Outputs) > 0 {
+		outputs, _, err := renderSection(tmpl.Outputs, evaluator)
+		if err != nil {
+			return nil, fmt.Errorf("outputs: %w", err)
+		}
+		setOrDelete(body, "Outputs", outputs)
+	}
+
+	// Resources and outputs with an unknown condition still need the Conditions section
+	deployed := []any{body["Resources"], body["Outputs"]}
+	if !containsFunction(deployed, "Condition") && !containsFunction(deployed, "Fn::If") {
+		delete(body, "Conditions")
+	} else if len(tmpl.Conditions) > 0 {
+		conditions, err := evaluator.Resolve(tmpl.Conditions)
+		if err != nil {
+			return nil, fmt.Errorf("conditions: %w", err)
+		}
+		body["Conditions"] = conditions
+	}
+	if !containsFunction(deployed, "Fn::FindInMap") {
+		delete(body, "Mappings")
+	}
+
+	parameters := unresolvedParameters(tmpl, evaluator)
+	for name := range referencedParameters(tmpl, []any{body["Conditions"], body["Rules"]}) {
+		parameters[name] = tmpl.Parameters[name]
+	}
+	setOrDelete(body, "Parameters", parameters)
+
+	return body, nil
+}
+
+// Marshal encodes a rendered template as YAML or JSON, keeping the
+// conventional top-level section order.
+func Marshal(body map[string]any, format string) ([]byte, error) {
+	keys := orderedKeys(body)
+
+	switch format {
+	case FormatJSON:
+		var buf bytes.Buffer
+		buf.WriteString("{\n")
+		for i, key := range keys {
+			value, err := json.MarshalIndent(body[key], "  ", "  ")
+			if err != nil {
+				return nil, err
+			}
+			name, _ := json.Marshal(key)
+			fmt.Fprintf(&buf, "  %s: %s", name, value)
+			if i < len(keys)-1 {
+				buf.WriteString(",")
+			}
+			buf.WriteString("\n")
+		}
+		buf.WriteString("}\n")
+		return buf.Bytes(), nil
+	case FormatYAML:
+		root := &yaml.Node{Kind: yaml.MappingNode}
+		for _, key := range keys {
+			var value yaml.Node
+			if err := value.Encode(body[key]); err != nil {
+				return nil, err
+			}
+			root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, &value)
+		}
+		var buf bytes.Buffer
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(root); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unsupported format '%s' (only yaml, json allowed)", format)
+	}
+}
+
+// renderSection resolves each entry of a Resources or Outputs section and
+// returns the names of entries removed because their condition is false.
+func renderSection(section map[string]any, evaluator *template.Evaluator) (map[string]any, map[string]bool, error) {
+	rendered := make(map[string]any, len(section))
+	pruned := make(map[string]bool)
+
+	for _, name := range template.SortedKeys(section) {
+		entry, ok := section[name].(map[string]any)
+		if !ok {
+			rendered[name] = section[name]
+			continue
+		}
+
+		if condition, ok := entry["Condition"].(string); ok {
+			result, err := evaluator.Condition(condition)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s: %w", name, err)
+			}
+			if result == template.False {
+				pruned[name] = true
+				continue
+			}
+			if result == template.True {
+				entry = withoutKey(entry, "Condition")
+			}
+		}
+
+		resolved, err := evaluator.Resolve(entry)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", name, err)
+		}
+		rendered[name] = resolved
+	}
+	return rendered, pruned, nil
+}
+
+// unresolvedParameters keeps only the parameter declarations whose values
+// were not substituted into the template, which include NoEcho and SSM
+// parameters.
+func unresolvedParameters(tmpl *template.Template, evaluator *template.Evaluator) map[string]any {
+	remaining := make(map[string]any)
+	for name, declaration := range tmpl.Parameters {
+		if _, known := evaluator.Inlined(name); !known {
+			remaining[name] = declaration
+		}
+	}
+	return remaining
+}
+
+// referencedParameters returns the template parameters that kept sections
+// still name, through Ref, an Fn::Sub variable or the rule function Fn::ValueOf.
+func referencedParameters(tmpl *template.Template, value any) map[string]bool {
+	referenced := make(map[string]bool)
+	var walk func(value any)
+	walk = func(value any) {
+		switch v := value.(type) {
+		case map[string]any:
+			for key, item := range v {
+				var first string
+				switch args := item.(type) {
+				case string:
+					first = args
+				case []any:
+					if len(args) > 0 {
+						first, _ = args[0].(string)
+					}
+				}
+				switch key {
+				case "Ref", "Fn::ValueOf":
+					referenced[first] = true
+				case "Fn::Sub":
+					for _, match := range subVariable.FindAllStringSubmatch(first, -1) {
+						referenced[match[1]] = true
+					}
+				}
+				walk(item)
+			}
+		case []any:
+			for _, item := range v {
+				walk(item)
+			}
+		}
+	}
+	walk(value)
+
+	for name := range referenced {
+		if _, declared := tmpl.Parameters[name]; !declared {
+			delete(referenced, name)
+		}
+	}
+	return referenced
+}
+
+func removeDependencies(resource any, pruned map[string]bool) {
+	entry, ok := resource.(map[string]any)
+	if !ok || len(pruned) == 0 {
+		return
+	}
+
+	switch dependsOn := entry["DependsOn"].(type) {
+	case string:
+		if pruned[dependsOn] {
+			delete(entry, "DependsOn")
+		}
+	case []any:
+		kept := make([]any, 0, len(dependsOn))
+		for _, dependency := range dependsOn {
+			if name, ok := dependency.(string); !ok || !pruned[name] {
+				kept = append(kept, dependency)
+			}
+		}
+		if len(kept) == 0 {
+			delete(entry, "DependsOn")
+		} else {
+			entry["DependsOn"] = kept
+		}
+	}
+}
+
+func containsFunction(value any, fn string) bool {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			if key == fn {
+				return true
+			}
+			if containsFunction(item, fn) {
+				return true
+			}
+		}
+	case []any:
+		for _, item := range v {
+			if containsFunction(item, fn) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func withoutKey(entry map[string]any, key string) map[string]any {
+	result := make(map[string]any, len(entry))
+	for k, v := range entry {
+		if k != key {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+func setOrDelete(body map[string]any, key string, section map[string]any) {
+	if len(section) == 0 {
+		delete(body, key)
+		return
+	}
+	body[key] = section
+}
+
+func orderedKeys(body map[string]any) []string {
+	keys := make([]string, 0, len(body))
+	seen := make(map[string]bool)
+	for _, key := range sectionOrder {
+		if _, ok := body[key]; ok {
+			keys = append(keys, key)
+			seen[key] = true
+		}
+	}
+
+	var rest []string
+	for key := range body {
+		if !seen[key] {
+			rest = append(rest, key)
+		}
+	}
+	sort.Strings(rest)
+	return append(keys, rest...)
+}
@@ -0,0 +1,212 @@
+package render
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cfn-init/internal/template"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const renderTemplate = `
+AWSTemplateFormatVersion: "2010-09-09"
+Parameters:
+  Env:
+    Type: String
+Conditions:
+  IsProd: !Equals [!Ref Env, prod]
+Resources:
+  Bucket:
+    Type: AWS::S3::Bucket
+    DependsOn: Alarm
+    Properties:
+      BucketName: !Sub "${Env}-bucket"
+  Alarm:
+    Type: AWS::CloudWatch::Alarm
+    Condition: IsProd
+Outputs:
+  AlarmName:
+    Condition: IsProd
+    Value: !Ref Alarm
+`
+
+func renderFor(t *testing.T, env string) map[string]any {
+	path := filepath.Join(t.TempDir(), "template.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(renderTemplate), 0644))
+
+	tmpl, err := template.Load(path)
+	assert.NoError(t, err)
+
+	body, err := Render(tmpl, template.NewEvaluator(tmpl, map[string]string{"Env": env}, "us-east-1"))
+	assert.NoError(t, err)
+	return body
+}
+
+func TestRender_PrunesFalseConditions(t *testing.T) {
+	body := renderFor(t, "dev")
+
+	resources := body["Resources"].(map[string]any)
+	assert.NotContains(t, resources, "Alarm")
+	assert.NotContains(t, resources["Bucket"], "DependsOn")
+	assert.NotContains(t, body, "Outputs")
+	assert.NotContains(t, body, "Conditions")
+	assert.NotContains(t, body, "Parameters")
+}
+
+func TestRender_KeepsTrueConditionsWithoutConditionKey(t *testing.T) {
+	body := renderFor(t, "prod")
+
+	resources := body["Resources"].(map[string]any)
+	assert.Equal(t, map[string]any{"Type": "AWS::CloudWatch::Alarm"}, resources["Alarm"])
+	bucket := resources["Bucket"].(map[string]any)
+	assert.Equal(t, "prod-bucket", bucket["Properties"].(map[string]any)["BucketName"])
+	assert.Equal(t, "Alarm", bucket["DependsOn"])
+}
+
+func TestRender_KeepsNoEchoAndSSMParameters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "template.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(`
+Parameters:
+  Password:
+    Type: String
+    NoEcho: true
+  ImageId:
+    Type: AWS::SSM::Parameter::Value<AWS::EC2::Image::Id>
+    Default: /aws/service/ami-amazon-linux-latest/al2023-ami-kernel-default-x86_64
+Resources:
+  Database:
+    Type: AWS::RDS::DBInstance
+    Properties:
+      MasterUserPassword: !Ref Password
+      DBName: !Sub "${Password}-db"
+  Instance:
+    Type: AWS::EC2::Instance
+    Properties:
+      ImageId: !Ref ImageId
+`), 0644))
+	tmpl, err := template.Load(path)
+	assert.NoError(t, err)
+
+	body, err := Render(tmpl, template.NewEvaluator(tmpl, map[string]string{"Password": "hunter2"}, "us-east-1"))
+
+	assert.NoError(t, err)
+	resources := body["Resources"].(map[string]any)
+	database := resources["Database"].(map[string]any)["Properties"].(map[string]any)
+	assert.Equal(t, map[string]any{"Ref": "Password"}, database["MasterUserPassword"])
+	assert.Equal(t, map[string]any{"Fn::Sub": "${Password}-db"}, database["DBName"])
+	instance := resources["Instance"].(map[string]any)["Properties"].(map[string]any)
+	assert.Equal(t, map[string]any{"Ref": "ImageId"}, instance["ImageId"])
+	assert.Equal(t, []string{"ImageId", "Password"}, template.SortedKeys(body["Parameters"].(map[string]any)))
+}
+
+func TestRender_KeepsEncryptedParameters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "template.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(`
+Parameters:
+  ApiKey:
+    Type: String
+    Default: none
+Conditions:
+  HasApiKey: !Not [!Equals [!Ref ApiKey, none]]
+Resources:
+  Secret:
+    Type: AWS::SecretsManager::Secret
+    Condition: HasApiKey
+    Properties:
+      SecretString: !Ref ApiKey
+`), 0644))
+	tmpl, err := template.Load(path)
+	assert.NoError(t, err)
+	encrypted := "ENC[AES256_GCM,data:YWJj,iv:YWJjZA==,tag:YWJjZA==,type:str]"
+
+	body, err := Render(tmpl, template.NewEvaluator(tmpl, map[string]string{"ApiKey": encrypted}, "us-east-1"))
+
+	assert.NoError(t, err)
+	secret := body["Resources"].(map[string]any)["Secret"].(map[string]any)
+	assert.Equal(t, "HasApiKey", secret["Condition"])
+	assert.Equal(t, map[string]any{"Ref": "ApiKey"}, secret["Properties"].(map[string]any)["SecretString"])
+	assert.Contains(t, body["Parameters"], "ApiKey")
+	data, err := Marshal(body, FormatYAML)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "ENC[")
+}
+
+func TestRender_ResolvesKeptConditions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "template.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(`
+Parameters:
+  Env:
+    Type: String
+Conditions:
+  IsProd: !Equals [!Ref Env, prod]
+  IsProdAccount: !And [!Condition IsProd, !Equals [!Ref "AWS::AccountId", "123456789012"]]
+Resources:
+  Alarm:
+    Type: AWS::CloudWatch::Alarm
+    Condition: IsProdAccount
+`), 0644))
+	tmpl, err := template.Load(path)
+	assert.NoError(t, err)
+
+	body, err := Render(tmpl, template.NewEvaluator(tmpl, map[string]string{"Env": "prod"}, "us-east-1"))
+
+	assert.NoError(t, err)
+	assert.NotContains(t, body, "Parameters")
+	conditions := body["Conditions"].(map[string]any)
+	assert.Equal(t, map[string]any{"Fn::Equals": []any{"prod", "prod"}}, conditions["IsProd"])
+	assert.Equal(t, "Alarm", template.SortedKeys(body["Resources"].(map[string]any))[0])
+}
+
+func TestRender_KeepsParametersOfRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "template.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(`
+Parameters:
+  Env:
+    Type: String
+  Size:
+    Type: String
+Rules:
+  ProdSize:
+    RuleCondition: !Equals [!Ref Env, prod]
+    Assertions:
+      - Assert: !Contains [[large], !Ref Size]
+Resources:
+  Bucket:
+    Type: AWS::S3::Bucket
+    Properties:
+      BucketName: !Sub "${Env}-${Size}"
+`), 0644))
+	tmpl, err := template.Load(path)
+	assert.NoError(t, err)
+
+	body, err := Render(tmpl, template.NewEvaluator(tmpl, map[string]string{"Env": "prod", "Size": "large"}, "us-east-1"))
+
+	assert.NoError(t, err)
+	assert.Contains(t, body, "Rules")
+	assert.Equal(t, []string{"Env", "Size"}, template.SortedKeys(body["Parameters"].(map[string]any)))
+}
+
+func TestMarshal_SectionOrder(t *testing.T) {
+	body := map[string]any{
+		"Resources":                map[string]any{"Bucket": map[string]any{"Type": "AWS::S3::Bucket"}},
+		"AWSTemplateFormatVersion": "2010-09-09",
+		"Description":              "test",
+	}
+
+	data, err := Marshal(body, FormatYAML)
+	assert.NoError(t, err)
+	assert.Equal(t, "AWSTemplateFormatVersion: \"2010-09-09\"\nDescription: test\nResources:\n  Bucket:\n    Type: AWS::S3::Bucket\n", string(data))
+
+	data, err = Marshal(body, FormatJSON)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "{\n  \"AWSTemplateFormatVersion\": \"2010-09-09\",\n  \"Description\": \"test\",\n")
+}
+
+func TestMarshal_UnsupportedFormat(t *testing.T) {
+	_, err := Marshal(map[string]any{}, "toml")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported format")
+}
@@ -0,0 +1,144 @@
+package secrets
+
+import (
+	"cfn-init/internal/encryption"
+	"cfn-init/internal/parameters"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Reasons a parameter value is reported as a plaintext secret.
+const (
+	ReasonNoEcho        = "parameter is NoEcho in the template"
+	ReasonSensitiveName = "parameter name looks sensitive"
+	ReasonHighEntropy   = "value looks like a generated secret"
+)
+
+// Values shorter than minEntropyLength or below minEntropy bits per
+// character are not reported as high-entropy strings.
+const (
+	minEntropyLength = 20
+	minEntropy       = 4.0
+)
+
+// DynamicReferencePrefix starts values CloudFormation resolves at deploy time.
+const DynamicReferencePrefix = "{{resolve:"
+
+var sensitiveName = regexp.MustCompile(`(?i)(password|passwd|passphrase|secret|token|api_?key|private_?key|credential)`)
+
+// awsIdentifiers match values that are long and varied but name AWS
+// resources rather than hold secrets: resource IDs such as vpc-, subnet- and
+// sg- IDs, UUIDs such as KMS key IDs, and Route 53 hosted zone IDs.
+var awsIdentifiers = []*regexp.Regexp{
+	regexp.MustCompile(`^[a-z][a-z0-9]*(-[a-z0-9]+)*-[0-9a-f]{8}([0-9a-f]{9})?$`),
+	regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`),
+	regexp.MustCompile(`^Z[0-9A-Z]{12,32}$`),
+}
+
+// Finding is a parameter whose literal value looks like a secret.
+type Finding struct {
+	File      string
+	Parameter string
+	Reasons   []string
+}
+
+// Suggestion returns the dynamic references that could replace the value.
+func (f Finding) Suggestion() string {
+	return fmt.Sprintf("{{resolve:secretsmanager:%s:SecretString}} or {{resolve:ssm-secure:/%s}}", f.Parameter, f.Parameter)
+}
+
+// ScanFile reads a parameters or GitSync file and scans its values.
+func ScanFile(path string, noEcho map[string]bool) ([]Finding, error) {
+	values, _, err := parameters.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	findings := Scan(values, noEcho)
+	for i := range findings {
+		findings[i].File = path
+	}
+	return findings, nil
+}
+
+// Scan reports the parameter values that are NoEcho, have sensitive names or
+// look like generated secrets. Dynamic references, encrypted and empty values
+// are skipped.
+func Scan(values map[string]string, noEcho map[string]bool) []Finding {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var findings []Finding
+	for _, name := range names {
+		value := values[name]
+		if value == "" || strings.HasPrefix(value, DynamicReferencePrefix) || encryption.IsEncrypted(value) {
+			continue
+		}
+
+		var reasons []string
+		if noEcho[name] {
+			reasons = append(reasons, ReasonNoEcho)
+		}
+		if sensitiveName.MatchString(name) {
+			reasons = append(reasons, ReasonSensitiveName)
+		}
+		if isHighEntropy(value) {
+			reasons = append(reasons, ReasonHighEntropy)
+		}
+		if len(reasons) > 0 {
+			findings = append(findings, Finding{Parameter: name, Reasons: reasons})
+		}
+	}
+	return findings
+}
+
+// isHighEntropy reports whether a value looks like a random token. ARNs,
+// URLs, AWS identifiers, dynamic references and values with spaces are
+// expected to be long and varied, so they are skipped, as are lists of them.
+func isHighEntropy(value string) bool {
+	if len(value) < minEntropyLength || strings.ContainsAny(value, " \t") || strings.Contains(value, DynamicReferencePrefix) {
+		return false
+	}
+	if isAWSIdentifierList(value) {
+		return false
+	}
+	return entropy(value) >= minEntropy
+}
+
+// isAWSIdentifierList reports whether every item of a comma-separated value
+// is an ARN, a URL or an AWS identifier.
+func isAWSIdentifierList(value string) bool {
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if strings.HasPrefix(item, "arn:") || strings.Contains(item, "://") {
+			continue
+		}
+		if !slices.ContainsFunc(awsIdentifiers, func(pattern *regexp.Regexp) bool { return pattern.MatchString(item) }) {
+			return false
+		}
+	}
+	return true
+}
+
+// entropy returns the Shannon entropy of a string in bits per character.
+func entropy(value string) float64 {
+	counts := make(map[rune]int)
+	total := 0
+	for _, r := range value {
+		counts[r]++
+		total++
+	}
+
+	var bits float64
+	for _, count := range counts {
+		p := float64(count) / float64(total)
+		bits -= p * math.Log2(p)
+	}
+	return bits
+}
@@ -0,0 +1,72 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cfn-init/internal/encryption"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScan(t *testing.T) {
+	findings := Scan(map[string]string{
+		"AdminUser":  "admin",
+		"DbPassword": "hunter2",
+		"ApiKey":     "{{resolve:secretsmanager:api:SecretString}}",
+		"Seed":       "q8Zr2LmX7vKp4TnW9sYb3HcJ",
+		"RoleArn":    "arn:aws:iam::123456789012:role/deploy-role-with-long-name",
+		"Empty":      "",
+	}, map[string]bool{"AdminUser": true})
+
+	assert.Equal(t, []Finding{
+		{Parameter: "AdminUser", Reasons: []string{ReasonNoEcho}},
+		{Parameter: "DbPassword", Reasons: []string{ReasonSensitiveName}},
+		{Parameter: "Seed", Reasons: []string{ReasonHighEntropy}},
+	}, findings)
+}
+
+func TestScan_LowEntropyValues(t *testing.T) {
+	findings := Scan(map[string]string{
+		"BucketName": "my-application-assets-bucket",
+		"Repeated":   "aaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+	}, nil)
+
+	assert.Empty(t, findings)
+}
+
+func TestScan_AWSIdentifiers(t *testing.T) {
+	findings := Scan(map[string]string{
+		"VpcId":         "vpc-0a1b2c3d4e5f67890",
+		"SubnetIds":     "subnet-0123456789abcdef0,subnet-0fedcba9876543210",
+		"SecurityGroup": "sg-0f1e2d3c4b5a69788",
+		"KmsKeyId":      "1f2e3d4c-5b6a-4978-8695-a4b3c2d1e0f9",
+		"HostedZoneId":  "Z0123456789ABCDEFGHIJ",
+		"TopicArn":      "arn:aws:sns:us-east-1:123456789012:alerts-7Hq2Lx9",
+		"Endpoint":      "https://x7k2m9q4.execute-api.us-east-1.amazonaws.com",
+		"DbHost":        "{{resolve:ssm:/app/db-host-7Hq2Lx9K}}",
+		"Embedded":      "prefix-{{resolve:ssm:/app/q8Zr2LmX7vKp4TnW}}",
+	}, nil)
+
+	assert.Empty(t, findings)
+}
+
+func TestScanFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "params.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`[{"ParameterKey": "GithubToken", "ParameterValue": "abc"}]`), 0644))
+
+	findings, err := ScanFile(path, nil)
+
+	assert.NoError(t, err)
+	assert.Len(t, findings, 1)
+	assert.Equal(t, path, findings[0].File)
+	assert.Equal(t, "{{resolve:secretsmanager:GithubToken:SecretString}} or {{resolve:ssm-secure:/GithubToken}}", findings[0].Suggestion())
+}
+
+func TestScan_SkipsEncryptedValues(t *testing.T) {
+	encrypted, err := encryption.Encrypt([]byte("0123456789abcdef0123456789abcdef"), "DbPassword", "hunter2")
+	assert.NoError(t, err)
+
+	assert.Empty(t, Scan(map[string]string{"DbPassword": encrypted}, nil))
+}
@@ -0,0 +1,390 @@
+package stack
+
+import (
+	"cfn-init/internal/config"
+	"cfn-init/internal/environment"
+	"cfn-init/internal/template"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Export is a named output value that a stack makes available to other stacks.
+type Export struct {
+	Stack  string
+	Output string
+	Name   string
+}
+
+// Import is a Fn::ImportValue reference from a stack, with the stack that
+// exports the value when one is known.
+type Import struct {
+	Stack    string
+	Name     string
+	Provider string
+}
+
+// Graph describes the cross-stack dependencies of a project in one environment.
+type Graph struct {
+	Environment string
+	Stacks      []string
+	// Dependencies maps each stack to the stacks it imports values from.
+	Dependencies map[string][]string
+	Exports      []Export
+	Imports      []Import
+	// Nested maps each stack to the local templates it includes as nested stacks.
+	Nested map[string][]string
+	// Dangling lists imports that no stack in the project exports.
+	Dangling []Import
+	// DuplicateExports lists exports whose name is exported by more than one stack.
+	DuplicateExports []Export
+	// MissingTemplates lists local nested stack templates that do not exist.
+	MissingTemplates []string
+}
+
+// BuildGraph scans every stack's template, including local nested stack
+// templates, for exports and imports and links the stacks that depend on
+// each other. Export and import names are resolved with the environment's
+// parameters when an environment is given.
+func BuildGraph(envName string) (*Graph, error) {
+	configFile, err := readProjectConfig()
+	if err != nil {
+		return nil, err
+	}
+	var region string
+	if envName != "" {
+		env, err := configFile.ResolveEnvironment(envName)
+		if err != nil {
+			return nil, err
+		}
+		region = env.Region
+	}
+
+	graph := &Graph{
+		Environment:  envName,
+		Dependencies: make(map[string][]string),
+		Nested:       make(map[string][]string),
+	}
+
+	for _, name := range sortedStackNames(configFile) {
+		graph.Stacks = append(graph.Stacks, name)
+		s := configFile.Stacks[name]
+
+		tmpl, err := template.Load(filepath.FromSlash(s.Template))
+		if err != nil {
+			return nil, fmt.Errorf("stack '%s': %w", name, err)
+		}
+
+		var evaluator *template.Evaluator
+		if envName != "" {
+			evaluator, err = environment.NewEvaluator(envName, name, tmpl)
+			if err != nil {
+				return nil, fmt.Errorf("stack '%s': %w", name, err)
+			}
+		} else {
+			evaluator = template.NewEvaluator(tmpl, nil, "")
+		}
+
+		scanner := &templateScanner{
+			graph:   graph,
+			stack:   name,
+			region:  region,
+			visited: make(map[string]bool),
+		}
+		if err := scanner.scan(tmpl, evaluator); err != nil {
+			return nil, fmt.Errorf("stack '%s': %w", name, err)
+		}
+	}
+
+	graph.link()
+	return graph, nil
+}
+
+// Order returns the stacks in an order where every stack comes after the
+// stacks it depends on. It fails when the dependencies contain a cycle.
+func (g *Graph) Order() ([]string, error) {
+	if cycles := g.Cycles(); len(cycles) > 0 {
+		descriptions := make([]string, len(cycles))
+		for i, cycle := range cycles {
+			descriptions[i] = strings.Join(append(cycle, cycle[0]), " -> ")
+		}
+		return nil, fmt.Errorf("dependency cycle between stacks: %s", strings.Join(descriptions, "; "))
+	}
+
+	remaining := make(map[string]int, len(g.Stacks))
+	dependents := make(map[string][]string)
+	for _, name := range g.Stacks {
+		remaining[name] = len(g.Dependencies[name])
+		for _, dependency := range g.Dependencies[name] {
+			dependents[dependency] = append(dependents[dependency], name)
+		}
+	}
+
+	var order, ready []string
+	for _, name := range g.Stacks {
+		if remaining[name] == 0 {
+			ready = append(ready, name)
+		}
+	}
+	for len(ready) > 0 {
+		sort.Strings(ready)
+		name := ready[0]
+		ready = ready[1:]
+		order = append(order, name)
+		for _, dependent := range dependents[name] {
+			remaining[dependent]--
+			if remaining[dependent] == 0 {
+				ready = append(ready, dependent)
+			}
+		}
+	}
+	return order, nil
+}
+
+// Cycles returns each group of stacks that depend on each other in a cycle.
+func (g *Graph) Cycles() [][]string {
+	const (
+		unvisited = iota
+		inProgress
+		done
+	)
+	state := make(map[string]int)
+	var path []string
+	var cycles [][]string
+
+	var visit func(name string)
+	visit = func(name string) {
+		state[name] = inProgress
+		path = append(path, name)
+		for _, dependency := range g.Dependencies[name] {
+			switch state[dependency] {
+			case unvisited:
+				visit(dependency)
+			case inProgress:
+				for i := len(path) - 1; i >= 0; i-- {
+					if path[i] == dependency {
+						cycles = append(cycles, append([]string(nil), path[i:]...))
+						break
+					}
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = done
+	}
+
+	for _, name := range g.Stacks {
+		if state[name] == unvisited {
+			visit(name)
+		}
+	}
+	return cycles
+}
+
+// link resolves each import to the stack exporting it and records the dependencies.
+func (g *Graph) link() {
+	providers := make(map[string]string)
+	for _, export := range g.Exports {
+		if existing, ok := providers[export.Name]; ok && existing != export.Stack {
+			g.DuplicateExports = append(g.DuplicateExports, export)
+			continue
+		}
+		providers[export.Name] = export.Stack
+	}
+
+	for i, imp := range g.Imports {
+		provider, ok := providers[imp.Name]
+		if !ok {
+			g.Dangling = append(g.Dangling, imp)
+			continue
+		}
+		g.Imports[i].Provider = provider
+		if provider != imp.Stack && !slices.Contains(g.Dependencies[imp.Stack], provider) {
+			g.Dependencies[imp.Stack] = append(g.Dependencies[imp.Stack], provider)
+		}
+	}
+
+	for name := range g.Dependencies {
+		sort.Strings(g.Dependencies[name])
+	}
+}
+
+// templateScanner collects the exports, imports and nested templates of one stack.
+type templateScanner struct {
+	graph   *Graph
+	stack   string
+	region  string
+	visited map[string]bool
+}
+
+func (s *templateScanner) scan(tmpl *template.Template, evaluator *template.Evaluator) error {
+	absPath, _ := filepath.Abs(tmpl.Path)
+	if s.visited[absPath] {
+		return fmt.Errorf("nested template %s includes itself", tmpl.Path)
+	}
+	s.visited[absPath] = true
+	defer delete(s.visited, absPath)
+
+	for _, outputName := range template.SortedKeys(tmpl.Outputs) {
+		deployed, err := isDeployed(evaluator, tmpl.OutputCondition(outputName))
+		if err != nil {
+			return fmt.Errorf("output '%s': %w", outputName, err)
+		}
+		if !deployed {
+			continue
+		}
+		output, _ := tmpl.Outputs[outputName].(map[string]any)
+		export, _ := output["Export"].(map[string]any)
+		if export == nil {
+			continue
+		}
+		name, err := resolveName(evaluator, export["Name"])
+		if err != nil {
+			return fmt.Errorf("output '%s': %w", outputName, err)
+		}
+		s.graph.Exports = append(s.graph.Exports, Export{Stack: s.stack, Output: outputName, Name: name})
+	}
+
+	for _, logicalID := range template.SortedKeys(tmpl.Resources) {
+		deployed, err := isDeployed(evaluator, tmpl.ResourceCondition(logicalID))
+		if err != nil {
+			return fmt.Errorf("resource '%s': %w", logicalID, err)
+		}
+		if !deployed {
+			continue
+		}
+		for _, raw := range findImports(tmpl.Resources[logicalID]) {
+			name, err := resolveName(evaluator, raw)
+			if err != nil {
+				return fmt.Errorf("resource '%s': %w", logicalID, err)
+			}
+			s.graph.Imports = append(s.graph.Imports, Import{Stack: s.stack, Name: name})
+		}
+
+		if tmpl.ResourceType(logicalID) == "AWS::CloudFormation::Stack" {
+			if err := s.scanNested(tmpl, logicalID, evaluator); err != nil {
+				return err
+			}
+		}
+	}
+	for _, outputName := range template.SortedKeys(tmpl.Outputs) {
+		if deployed, _ := isDeployed(evaluator, tmpl.OutputCondition(outputName)); !deployed {
+			continue
+		}
+		for _, raw := range findImports(tmpl.Outputs[outputName]) {
+			name, err := resolveName(evaluator, raw)
+			if err != nil {
+				return fmt.Errorf("output '%s': %w", outputName, err)
+			}
+			s.graph.Imports = append(s.graph.Imports, Import{Stack: s.stack, Name: name})
+		}
+	}
+	return nil
+}
+
+func (s *templateScanner) scanNested(parent *template.Template, logicalID string, evaluator *template.Evaluator) error {
+	resource, _ := parent.Resources[logicalID].(map[string]any)
+	properties, _ := resource["Properties"].(map[string]any)
+	templateURL, ok := properties["TemplateURL"].(string)
+	if !ok || isRemoteURL(templateURL) {
+		return nil
+	}
+
+	nestedPath := filepath.Join(filepath.Dir(parent.Path), filepath.FromSlash(templateURL))
+	s.graph.Nested[s.stack] = append(s.graph.Nested[s.stack], filepath.ToSlash(nestedPath))
+	if _, err := os.Stat(nestedPath); os.IsNotExist(err) {
+		s.graph.MissingTemplates = append(s.graph.MissingTemplates, filepath.ToSlash(nestedPath))
+		return nil
+	}
+
+	nested, err := template.Load(nestedPath)
+	if err != nil {
+		return err
+	}
+
+	// Nested stack parameters come from the parent's Parameters property
+	params := make(map[string]string)
+	if rawParams, ok := properties["Parameters"].(map[string]any); ok {
+		for name, raw := range rawParams {
+			value, err := evaluator.Resolve(raw)
+			if err != nil {
+				return fmt.Errorf("resource '%s': %w", logicalID, err)
+			}
+			if text, ok := value.(string); ok {
+				params[name] = text
+			}
+		}
+	}
+	return s.scan(nested, template.NewEvaluator(nested, params, s.region))
+}
+
+// isDeployed reports whether an entry with the given condition may exist.
+// Entries whose condition cannot be decided statically are kept.
+func isDeployed(evaluator *template.Evaluator, condition string) (bool, error) {
+	if condition == "" {
+		return true, nil
+	}
+	result, err := evaluator.Condition(condition)
+	if err != nil {
+		return false, err
+	}
+	return result != template.False, nil
+}
+
+// findImports returns the arguments of every Fn::ImportValue within a value.
+func findImports(value any) []any {
+	var found []any
+	switch v := value.(type) {
+	case map[string]any:
+		if args, ok := v["Fn::ImportValue"]; ok && len(v) == 1 {
+			return append(found, args)
+		}
+		for _, key := range template.SortedKeys(v) {
+			found = append(found, findImports(v[key])...)
+		}
+	case []any:
+		for _, item := range v {
+			found = append(found, findImports(item)...)
+		}
+	}
+	return found
+}
+
+// resolveName resolves an export or import name, falling back to a canonical
+// JSON form when the name is only known at deploy time.
+func resolveName(evaluator *template.Evaluator, raw any) (string, error) {
+	value, err := evaluator.Resolve(raw)
+	if err != nil {
+		return "", err
+	}
+	if text, ok := value.(string); ok {
+		return text, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func isRemoteURL(url string) bool {
+	for _, prefix := range []string{"http://", "https://", "s3://"} {
+		if strings.HasPrefix(strings.ToLower(url), prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedStackNames(configFile *config.ProjectConfig) []string {
+	names := make([]string, 0, len(configFile.Stacks))
+	for name := range configFile.Stacks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,126 @@
+package stack
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const networkTemplate = `
+Resources:
+  Vpc:
+    Type: AWS::EC2::VPC
+Outputs:
+  VpcId:
+    Value: !Ref Vpc
+    Export:
+      Name: !Sub "${AWS::StackName}-VpcId"
+`
+
+const appTemplate = `
+Parameters:
+  NetworkStack:
+    Type: String
+    Default: test-project-network-dev
+Resources:
+  Queue:
+    Type: AWS::SQS::Queue
+  Data:
+    Type: AWS::CloudFormation::Stack
+    Properties:
+      TemplateURL: nested/data.yaml
+      Parameters:
+        NetworkStack: !Ref NetworkStack
+`
+
+const nestedDataTemplate = `
+Parameters:
+  NetworkStack:
+    Type: String
+Resources:
+  Table:
+    Type: AWS::DynamoDB::Table
+    Properties:
+      VpcId: !ImportValue
+        Fn::Sub: "${NetworkStack}-VpcId"
+      Other: !ImportValue external-export
+`
+
+func writeTemplate(t *testing.T, path, content string) {
+	t.Helper()
+	assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func setupGraphProject(t *testing.T) {
+	setupTestProject(t)
+	writeTemplate(t, "network.yaml", networkTemplate)
+	writeTemplate(t, "app/app.yaml", appTemplate)
+	writeTemplate(t, "app/nested/data.yaml", nestedDataTemplate)
+
+	assert.NoError(t, addTestEnvironment(t, "dev"))
+	assert.NoError(t, Add("network", "network.yaml", ""))
+	assert.NoError(t, Add("app", "app/app.yaml", ""))
+}
+
+func TestBuildGraph_ResolvesImportsThroughNestedStacks(t *testing.T) {
+	setupGraphProject(t)
+
+	graph, err := BuildGraph("dev")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Export{{Stack: "network", Output: "VpcId", Name: "test-project-network-dev-VpcId"}}, graph.Exports)
+	assert.Equal(t, map[string][]string{"app": {"network"}}, graph.Dependencies)
+	assert.Equal(t, []string{"app/nested/data.yaml"}, graph.Nested["app"])
+	assert.Equal(t, []Import{{Stack: "app", Name: "external-export"}}, graph.Dangling)
+
+	order, err := graph.Order()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"network", "app"}, order)
+}
+
+func TestBuildGraph_WithoutEnvironmentLeavesNamesUnresolved(t *testing.T) {
+	setupGraphProject(t)
+
+	graph, err := BuildGraph("")
+
+	assert.NoError(t, err)
+	assert.Equal(t, `{"Fn::Sub":"${AWS::StackName}-VpcId"}`, graph.Exports[0].Name)
+	assert.Empty(t, graph.Dependencies)
+}
+
+func TestBuildGraph_MissingNestedTemplate(t *testing.T) {
+	setupGraphProject(t)
+	assert.NoError(t, os.Remove("app/nested/data.yaml"))
+
+	graph, err := BuildGraph("dev")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"app/nested/data.yaml"}, graph.MissingTemplates)
+}
+
+func TestGraphOrder_Cycle(t *testing.T) {
+	graph := &Graph{
+		Stacks:       []string{"a", "b", "c"},
+		Dependencies: map[string][]string{"a": {"b"}, "b": {"a"}},
+	}
+
+	_, err := graph.Order()
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "a -> b -> a")
+}
+
+func TestGraphOrder_Deterministic(t *testing.T) {
+	graph := &Graph{
+		Stacks:       []string{"app", "data", "network"},
+		Dependencies: map[string][]string{"app": {"data", "network"}, "data": {"network"}},
+	}
+
+	order, err := graph.Order()
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"network", "data", "app"}, order)
+}
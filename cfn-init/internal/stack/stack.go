@@ -0,0 +1,256 @@
+package stack
+
+import (
+	"cfn-init/internal/config"
+	"cfn-init/internal/environment"
+	"cfn-init/internal/permissions"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+var (
+	validStackName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9-]*$`)
+	patternToken   = regexp.MustCompile(`\{([^}]*)\}`)
+)
+
+// Add registers a new stack with its template and stack-name pattern.
+func Add(name, templatePath, stackNamePattern string) error {
+	configFile, err := readProjectConfig()
+	if err != nil {
+		return err
+	}
+
+	if !validStackName.MatchString(name) {
+		return fmt.Errorf("invalid stack name '%s' (letters, digits and hyphens only, starting with a letter)", name)
+	}
+	if _, exists := configFile.Stacks[name]; exists {
+		return fmt.Errorf("stack '%s' already exists", name)
+	}
+	if templatePath == "" {
+		return fmt.Errorf("template path is required for stack '%s'", name)
+	}
+	if _, err := os.Stat(templatePath); os.IsNotExist(err) {
+		return fmt.Errorf("template not found: %s", templatePath)
+	}
+	if stackNamePattern != "" {
+		if err := validatePattern(stackNamePattern); err != nil {
+			return err
+		}
+	}
+
+	if configFile.Stacks == nil {
+		configFile.Stacks = make(map[string]config.Stack)
+	}
+	configFile.Stacks[name] = config.Stack{
+		Name:             name,
+		Template:         filepath.ToSlash(templatePath),
+		StackNamePattern: stackNamePattern,
+	}
+	return config.WriteConfigFile(".", configFile)
+}
+
+// List returns all stacks sorted by name.
+func List() ([]config.Stack, error) {
+	configFile, err := readProjectConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	stacks := make([]config.Stack, 0, len(configFile.Stacks))
+	for _, s := range configFile.Stacks {
+		stacks = append(stacks, s)
+	}
+	sort.Slice(stacks, func(i, j int) bool { return stacks[i].Name < stacks[j].Name })
+	return stacks, nil
+}
+
+// Get returns the named stack.
+func Get(name string) (*config.Stack, error) {
+	configFile, err := readProjectConfig()
+	if err != nil {
+		return nil, err
+	}
+	s, exists := configFile.Stacks[name]
+	if !exists {
+		return nil, fmt.Errorf("stack '%s' not found", name)
+	}
+	return &s, nil
+}
+
+// Remove deletes a stack and its environment-scoped files.
+func Remove(name string) error {
+	configFile, err := readProjectConfig()
+	if err != nil {
+		return err
+	}
+	if _, exists := configFile.Stacks[name]; !exists {
+		return fmt.Errorf("stack '%s' not found", name)
+	}
+
+	for envName, env := range configFile.Environments {
+		if err := os.RemoveAll(environment.StackPath(envName, name)); err != nil {
+			return fmt.Errorf("failed to remove stack files for environment '%s': %w", envName, err)
+		}
+		if strings.HasPrefix(env.SourceOfTruth, name+"/") {
+			env.SourceOfTruth = ""
+		}
+		env.Files = slices.DeleteFunc(env.Files, func(file config.TrackedFile) bool {
+			return strings.HasPrefix(file.Path, name+"/")
+		})
+		configFile.Environments[envName] = env
+	}
+
+	delete(configFile.Stacks, name)
+	return config.WriteConfigFile(".", configFile)
+}
+
+// Migrate moves the files stored directly in each environment folder into
+// that environment's folder for the given stack, converting a flat layout
+// into a per-stack one. It returns the moved files per environment. Nothing
+// is moved when a file already exists in a stack folder, and files already
+// moved are moved back when a later step fails.
+func Migrate(name string) (map[string][]string, error) {
+	configFile, err := readProjectConfig()
+	if err != nil {
+		return nil, err
+	}
+	if _, exists := configFile.Stacks[name]; !exists {
+		return nil, fmt.Errorf("stack '%s' not found", name)
+	}
+
+	// Every target is checked before the first file moves, so a collision
+	// leaves the environments as they were
+	type move struct {
+		envName, envDir, stackDir, fileName string
+	}
+	var moves []move
+	for _, envName := range sortedEnvironments(configFile) {
+		envDir := environment.StackPath(envName, "")
+		entries, err := os.ReadDir(envDir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read environment directory: %w", err)
+		}
+
+		stackDir := environment.StackPath(envName, name)
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			if _, err := os.Lstat(filepath.Join(stackDir, entry.Name())); err == nil {
+				return nil, fmt.Errorf("cannot migrate %s: file already exists in %s", entry.Name(), stackDir)
+			}
+			moves = append(moves, move{envName, envDir, stackDir, entry.Name()})
+		}
+	}
+
+	moved := make(map[string][]string)
+	environments := maps.Clone(configFile.Environments)
+	var done []move
+	rollback := func() {
+		for i := len(done) - 1; i >= 0; i-- {
+			m := done[i]
+			moveFile(environments[m.envName], m.stackDir, m.envDir, m.fileName)
+		}
+	}
+	for _, m := range moves {
+		if err := os.MkdirAll(m.stackDir, permissions.ProjectDir); err != nil {
+			rollback()
+			return nil, fmt.Errorf("failed to create stack directory: %w", err)
+		}
+		if err := moveFile(environments[m.envName], m.envDir, m.stackDir, m.fileName); err != nil {
+			rollback()
+			return nil, fmt.Errorf("failed to move %s: %w", m.fileName, err)
+		}
+		done = append(done, m)
+		moved[m.envName] = append(moved[m.envName], m.fileName)
+	}
+
+	// Keep the recorded file paths pointing at the moved files
+	for envName, env := range configFile.Environments {
+		if env.SourceOfTruth != "" && !strings.Contains(env.SourceOfTruth, "/") {
+			env.SourceOfTruth = name + "/" + env.SourceOfTruth
+		}
+		files := slices.Clone(env.Files)
+		for i, file := range files {
+			if !strings.Contains(file.Path, "/") {
+				files[i].Path = name + "/" + file.Path
+			}
+		}
+		env.Files = files
+		configFile.Environments[envName] = env
+	}
+	if err := config.WriteConfigFile(".", configFile); err != nil {
+		rollback()
+		return nil, err
+	}
+	return moved, nil
+}
+
+func sortedEnvironments(configFile *config.ProjectConfig) []string {
+	names := make([]string, 0, len(configFile.Environments))
+	for name := range configFile.Environments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// moveFile moves a file of an environment folder into a stack folder.
+// Symbolic links are re-created rather than renamed, since their relative
+// targets would point one folder too shallow from the stack folder: links
+// added in symlink mode point again at their recorded source, and other
+// links at the file their target resolved to.
+func moveFile(env config.Environment, envDir, stackDir, fileName string) error {
+	from, to := filepath.Join(envDir, fileName), filepath.Join(stackDir, fileName)
+	info, err := os.Lstat(from)
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		return os.Rename(from, to)
+	}
+
+	var target string
+	index := slices.IndexFunc(env.Files, func(file config.TrackedFile) bool { return file.Path == fileName })
+	if index >= 0 && env.Files[index].Mode == environment.ModeSymlink && env.Files[index].Source != "" {
+		target = filepath.FromSlash(env.Files[index].Source)
+	} else {
+		if target, err = os.Readlink(from); err != nil {
+			return err
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(envDir, target)
+		}
+	}
+	if err := environment.LinkFile(target, to); err != nil {
+		return err
+	}
+	return os.Remove(from)
+}
+
+func validatePattern(pattern string) error {
+	for _, match := range patternToken.FindAllStringSubmatch(pattern, -1) {
+		switch match[1] {
+		case "project", "stack", "env":
+		default:
+			return fmt.Errorf("unknown placeholder '{%s}' in stack name pattern (only {project}, {stack}, {env} allowed)", match[1])
+		}
+	}
+	return nil
+}
+
+func readProjectConfig() (*config.ProjectConfig, error) {
+	if _, err := os.Stat(filepath.Join(environment.ProjectDir, environment.ConfigFile)); os.IsNotExist(err) {
+		return nil, fmt.Errorf("project directory not found")
+	}
+	return config.ReadConfigFile(".")
+}
@@ -0,0 +1,180 @@
+package stack
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cfn-init/internal"
+	"cfn-init/internal/bootstrap"
+	"cfn-init/internal/config"
+	"cfn-init/internal/environment"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupTestProject(t *testing.T) string {
+	tempDir := t.TempDir()
+	err := bootstrap.Init("test-project", tempDir)
+	assert.NoError(t, err)
+
+	originalDir, _ := os.Getwd()
+	err = os.Chdir(tempDir)
+	assert.NoError(t, err)
+
+	t.Cleanup(func() {
+		os.Chdir(originalDir)
+	})
+
+	err = os.WriteFile("network.yaml", []byte("Resources: {}\n"), 0644)
+	assert.NoError(t, err)
+
+	return filepath.Join(tempDir, "cfn-project")
+}
+
+func TestAdd_Success(t *testing.T) {
+	setupTestProject(t)
+
+	err := Add("network", "network.yaml", "{project}-{env}-net")
+	assert.NoError(t, err)
+
+	s, err := Get("network")
+	assert.NoError(t, err)
+	assert.Equal(t, "network.yaml", s.Template)
+	assert.Equal(t, "{project}-{env}-net", s.StackNamePattern)
+}
+
+func TestAdd_Validation(t *testing.T) {
+	setupTestProject(t)
+	assert.NoError(t, Add("network", "network.yaml", ""))
+
+	tests := []struct {
+		name, template, pattern, expected string
+	}{
+		{"network", "network.yaml", "", "already exists"},
+		{"1network", "network.yaml", "", "invalid stack name"},
+		{"data", "", "", "template path is required"},
+		{"data", "missing.yaml", "", "template not found"},
+		{"data", "network.yaml", "{region}-data", "unknown placeholder"},
+	}
+
+	for _, test := range tests {
+		err := Add(test.name, test.template, test.pattern)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), test.expected)
+	}
+}
+
+func TestList_Sorted(t *testing.T) {
+	setupTestProject(t)
+	assert.NoError(t, Add("network", "network.yaml", ""))
+	assert.NoError(t, Add("app", "network.yaml", ""))
+
+	stacks, err := List()
+
+	assert.NoError(t, err)
+	assert.Len(t, stacks, 2)
+	assert.Equal(t, "app", stacks[0].Name)
+	assert.Equal(t, "network", stacks[1].Name)
+}
+
+func TestRemove_DeletesScopedFiles(t *testing.T) {
+	projectDir := setupTestProject(t)
+	assert.NoError(t, Add("network", "network.yaml", ""))
+	assert.NoError(t, addTestEnvironment(t, "dev"))
+
+	stackDir := filepath.Join(projectDir, "environments", "dev", "network")
+	assert.NoError(t, os.MkdirAll(stackDir, 0755))
+
+	err := Remove("network")
+
+	assert.NoError(t, err)
+	assert.NoDirExists(t, stackDir)
+	_, err = Get("network")
+	assert.Error(t, err)
+}
+
+func TestMigrate_MovesFlatFiles(t *testing.T) {
+	projectDir := setupTestProject(t)
+	assert.NoError(t, Add("network", "network.yaml", ""))
+	assert.NoError(t, addTestEnvironment(t, "dev"))
+
+	envDir := filepath.Join(projectDir, "environments", "dev")
+	assert.NoError(t, os.WriteFile(filepath.Join(envDir, "params.json"), []byte("{}"), 0644))
+
+	moved, err := Migrate("network")
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]string{"dev": {"params.json"}}, moved)
+	assert.FileExists(t, filepath.Join(envDir, "network", "params.json"))
+	assert.NoFileExists(t, filepath.Join(envDir, "params.json"))
+}
+
+func TestMigrateAndRemove_UpdateRecordedFiles(t *testing.T) {
+	projectDir := setupTestProject(t)
+	assert.NoError(t, Add("network", "network.yaml", ""))
+	assert.NoError(t, addTestEnvironment(t, "dev"))
+
+	envDir := filepath.Join(projectDir, "environments", "dev")
+	assert.NoError(t, os.WriteFile(filepath.Join(envDir, "params.json"), []byte("{}"), 0644))
+	configFile, err := config.ReadConfigFile(".")
+	assert.NoError(t, err)
+	env := configFile.Environments["dev"]
+	env.Files = []config.TrackedFile{{Path: "params.json", Category: "parameters", Source: "shared/params.json"}}
+	configFile.Environments["dev"] = env
+	assert.NoError(t, config.WriteConfigFile(".", configFile))
+
+	_, err = Migrate("network")
+	assert.NoError(t, err)
+	configFile, err = config.ReadConfigFile(".")
+	assert.NoError(t, err)
+	assert.Equal(t, "network/params.json", configFile.Environments["dev"].Files[0].Path)
+
+	assert.NoError(t, Remove("network"))
+	configFile, err = config.ReadConfigFile(".")
+	assert.NoError(t, err)
+	assert.Empty(t, configFile.Environments["dev"].Files)
+}
+
+func TestMigrate_CollisionMovesNothing(t *testing.T) {
+	projectDir := setupTestProject(t)
+	assert.NoError(t, Add("network", "network.yaml", ""))
+	assert.NoError(t, addTestEnvironment(t, "dev"))
+	assert.NoError(t, addTestEnvironment(t, "prod"))
+	devDir := filepath.Join(projectDir, "environments", "dev")
+	prodDir := filepath.Join(projectDir, "environments", "prod")
+	assert.NoError(t, os.WriteFile(filepath.Join(devDir, "params.json"), []byte("{}"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(prodDir, "params.json"), []byte("{}"), 0644))
+	assert.NoError(t, os.MkdirAll(filepath.Join(prodDir, "network"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(prodDir, "network", "params.json"), []byte("{}"), 0644))
+
+	_, err := Migrate("network")
+
+	assert.EqualError(t, err, "cannot migrate params.json: file already exists in "+filepath.Join("cfn-project", "environments", "prod", "network"))
+	assert.FileExists(t, filepath.Join(devDir, "params.json"))
+	assert.NoDirExists(t, filepath.Join(devDir, "network"))
+}
+
+func TestMigrate_RelinksSymlinks(t *testing.T) {
+	projectDir := setupTestProject(t)
+	assert.NoError(t, Add("network", "network.yaml", ""))
+	assert.NoError(t, addTestEnvironment(t, "dev"))
+	assert.NoError(t, os.WriteFile("linked.json", []byte(`{"Env": "dev"}`), 0644))
+	_, err := environment.AddFilesWithOptions("dev", []string{"linked.json"}, nil, nil, environment.FileOptions{Mode: environment.ModeSymlink})
+	assert.NoError(t, err)
+
+	_, err = Migrate("network")
+
+	assert.NoError(t, err)
+	content, err := os.ReadFile(filepath.Join(projectDir, "environments", "dev", "network", "linked.json"))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"Env": "dev"}`, string(content))
+	values, err := environment.LoadParameters("dev", "network")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"Env": "dev"}, values)
+}
+
+func addTestEnvironment(t *testing.T, name string) error {
+	t.Helper()
+	return environment.AddEnvironments([]internal.EnvironmentConfig{{Name: name, AwsProfile: name + "-profile"}})
+}
@@ -0,0 +1,230 @@
+package stackpolicy
+
+import (
+	"cfn-init/internal/document"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ResourcePrefix starts every resource of a stack policy other than "*".
+const ResourcePrefix = "LogicalResourceId/"
+
+// Actions a stack policy statement may allow or deny.
+var Actions = []string{"Update:*", "Update:Modify", "Update:Replace", "Update:Delete"}
+
+// conditionOperators are the operators a statement's Condition may use, all
+// of them on the ResourceType key.
+var conditionOperators = []string{"StringEquals", "StringLike", "StringNotEquals", "StringNotLike"}
+
+var statementKeys = []string{"Effect", "Action", "NotAction", "Principal", "Resource", "NotResource", "Condition"}
+
+// Statement is one rule of a stack policy.
+type Statement struct {
+	Effect string
+	// Resources are the statement's Resource or NotResource entries.
+	Resources []string
+}
+
+// Policy is a parsed stack policy.
+type Policy struct {
+	Statements []Statement
+}
+
+// ValidationError lists every problem found in a stack policy file.
+type ValidationError struct {
+	File     string
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid stack policy %s: %s", e.File, strings.Join(e.Problems, "; "))
+}
+
+// ReadFile parses and validates a stack policy file.
+func ReadFile(file string) (*Policy, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	policy, problems := Parse(data)
+	if len(problems) > 0 {
+		return nil, &ValidationError{File: file, Problems: problems}
+	}
+	return policy, nil
+}
+
+// Parse decodes a stack policy and checks its structure: a Statement list
+// whose entries have an Allow or Deny Effect, Update actions, the "*"
+// Principal and resources given as "*" or LogicalResourceId/<id>.
+func Parse(data []byte) (*Policy, []string) {
+	doc, err := document.Decode(data)
+	if err != nil {
+		return nil, []string{err.Error()}
+	}
+	content, ok := doc.(map[string]any)
+	if !ok {
+		return nil, []string{"policy must be an object with a Statement list"}
+	}
+
+	var problems []string
+	for _, key := range sortedKeys(content) {
+		if key != "Statement" {
+			problems = append(problems, fmt.Sprintf("unknown key '%s'", key))
+		}
+	}
+	entries, ok := content["Statement"].([]any)
+	if !ok || len(entries) == 0 {
+		return nil, append(problems, "Statement must be a non-empty list")
+	}
+
+	policy := &Policy{}
+	for i, entry := range entries {
+		statement, statementProblems := parseStatement(entry)
+		for _, problem := range statementProblems {
+			problems = append(problems, fmt.Sprintf("statement %d: %s", i+1, problem))
+		}
+		policy.Statements = append(policy.Statements, statement)
+	}
+	return policy, problems
+}
+
+func parseStatement(entry any) (Statement, []string) {
+	object, ok := entry.(map[string]any)
+	if !ok {
+		return Statement{}, []string{"must be an object"}
+	}
+
+	var problems []string
+	for _, key := range sortedKeys(object) {
+		if !slices.Contains(statementKeys, key) {
+			problems = append(problems, fmt.Sprintf("unknown key '%s'", key))
+		}
+	}
+
+	statement := Statement{}
+	statement.Effect, _ = object["Effect"].(string)
+	if statement.Effect != "Allow" && statement.Effect != "Deny" {
+		problems = append(problems, "Effect must be Allow or Deny")
+	}
+
+	actions, actionProblems := oneOf(object, "Action", "NotAction")
+	problems = append(problems, actionProblems...)
+	for _, action := range actions {
+		if !slices.Contains(Actions, action) {
+			problems = append(problems, fmt.Sprintf("action '%s' is not one of %s", action, strings.Join(Actions, ", ")))
+		}
+	}
+
+	if principal, _ := object["Principal"].(string); principal != "*" {
+		problems = append(problems, `Principal must be "*"`)
+	}
+
+	resources, resourceProblems := oneOf(object, "Resource", "NotResource")
+	problems = append(problems, resourceProblems...)
+	for _, resource := range resources {
+		if resource != "*" && (!strings.HasPrefix(resource, ResourcePrefix) || resource == ResourcePrefix) {
+			problems = append(problems, fmt.Sprintf("resource '%s' must be \"*\" or %s<logical ID>", resource, ResourcePrefix))
+		}
+	}
+	statement.Resources = resources
+
+	if condition, exists := object["Condition"]; exists {
+		problems = append(problems, checkCondition(condition)...)
+	}
+	return statement, problems
+}
+
+// oneOf returns the strings of exactly one of two keys, each of which may
+// hold a string or a list of strings.
+func oneOf(object map[string]any, key, notKey string) ([]string, []string) {
+	value, hasKey := object[key]
+	notValue, hasNotKey := object[notKey]
+	switch {
+	case hasKey && hasNotKey:
+		return nil, []string{fmt.Sprintf("only one of %s and %s is allowed", key, notKey)}
+	case hasNotKey:
+		key, value = notKey, notValue
+	case !hasKey:
+		return nil, []string{fmt.Sprintf("%s or %s is required", key, notKey)}
+	}
+
+	switch v := value.(type) {
+	case string:
+		return []string{v}, nil
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, entry := range v {
+			s, ok := entry.(string)
+			if !ok {
+				return nil, []string{fmt.Sprintf("%s must be a string or a list of strings", key)}
+			}
+			values = append(values, s)
+		}
+		if len(values) == 0 {
+			return nil, []string{fmt.Sprintf("%s must not be empty", key)}
+		}
+		return values, nil
+	}
+	return nil, []string{fmt.Sprintf("%s must be a string or a list of strings", key)}
+}
+
+func checkCondition(condition any) []string {
+	operators, ok := condition.(map[string]any)
+	if !ok {
+		return []string{"Condition must be an object"}
+	}
+	var problems []string
+	for _, operator := range sortedKeys(operators) {
+		if !slices.Contains(conditionOperators, operator) {
+			problems = append(problems, fmt.Sprintf("condition operator '%s' is not one of %s", operator, strings.Join(conditionOperators, ", ")))
+			continue
+		}
+		keys, ok := operators[operator].(map[string]any)
+		if !ok {
+			problems = append(problems, fmt.Sprintf("condition %s must be an object", operator))
+			continue
+		}
+		for _, key := range sortedKeys(keys) {
+			if key != "ResourceType" {
+				problems = append(problems, fmt.Sprintf("condition key '%s' is not ResourceType", key))
+			}
+		}
+	}
+	return problems
+}
+
+// CheckResources reports statement resources that match none of the given
+// logical IDs. Resources may use * wildcards.
+func (p *Policy) CheckResources(logicalIDs []string) []string {
+	var problems []string
+	for i, statement := range p.Statements {
+		for _, resource := range statement.Resources {
+			pattern, ok := strings.CutPrefix(resource, ResourcePrefix)
+			if !ok || slices.ContainsFunc(logicalIDs, func(id string) bool {
+				matched, _ := path.Match(pattern, id)
+				return matched
+			}) {
+				continue
+			}
+			if strings.Contains(pattern, "*") {
+				problems = append(problems, fmt.Sprintf("statement %d: resource '%s' matches no resource in the template", i+1, resource))
+			} else {
+				problems = append(problems, fmt.Sprintf("statement %d: resource '%s' is not in the template", i+1, resource))
+			}
+		}
+	}
+	return problems
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,88 @@
+package stackpolicy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const protectDatabase = `{
+  "Statement": [
+    {"Effect": "Allow", "Action": "Update:*", "Principal": "*", "Resource": "*"},
+    {"Effect": "Deny", "Action": ["Update:Replace", "Update:Delete"], "Principal": "*", "Resource": "LogicalResourceId/Database"},
+    {"Effect": "Deny", "Action": "Update:*", "Principal": "*", "NotResource": "LogicalResourceId/App*",
+     "Condition": {"StringEquals": {"ResourceType": ["AWS::RDS::DBInstance"]}}}
+  ]
+}`
+
+func TestParse(t *testing.T) {
+	policy, problems := Parse([]byte(protectDatabase))
+
+	assert.Empty(t, problems)
+	assert.Equal(t, []Statement{
+		{Effect: "Allow", Resources: []string{"*"}},
+		{Effect: "Deny", Resources: []string{"LogicalResourceId/Database"}},
+		{Effect: "Deny", Resources: []string{"LogicalResourceId/App*"}},
+	}, policy.Statements)
+}
+
+func TestParse_Problems(t *testing.T) {
+	_, problems := Parse([]byte(`
+Version: "1"
+Statement:
+  - Effect: Permit
+    Action: [Update:Replace, Delete]
+    Principal: {AWS: arn:aws:iam::123456789012:root}
+    Resource: Database
+    NotResource: "*"
+  - Effect: Deny
+    Principal: "*"
+    Resource: LogicalResourceId/
+    Condition: {NumericEquals: {Size: 1}, StringLike: {Tag: x}}
+    Sid: protect
+`))
+
+	assert.Equal(t, []string{
+		"unknown key 'Version'",
+		"statement 1: Effect must be Allow or Deny",
+		"statement 1: action 'Delete' is not one of Update:*, Update:Modify, Update:Replace, Update:Delete",
+		`statement 1: Principal must be "*"`,
+		"statement 1: only one of Resource and NotResource is allowed",
+		"statement 2: unknown key 'Sid'",
+		"statement 2: Action or NotAction is required",
+		`statement 2: resource 'LogicalResourceId/' must be "*" or LogicalResourceId/<logical ID>`,
+		"statement 2: condition operator 'NumericEquals' is not one of StringEquals, StringLike, StringNotEquals, StringNotLike",
+		"statement 2: condition key 'Tag' is not ResourceType",
+	}, problems)
+}
+
+func TestParse_NoStatements(t *testing.T) {
+	_, problems := Parse([]byte(`{"Statement": []}`))
+
+	assert.Equal(t, []string{"Statement must be a non-empty list"}, problems)
+}
+
+func TestCheckResources(t *testing.T) {
+	policy, problems := Parse([]byte(`{"Statement": [
+    {"Effect": "Deny", "Action": "Update:Replace", "Principal": "*", "Resource": ["LogicalResourceId/Database", "LogicalResourceId/Cache"]},
+    {"Effect": "Deny", "Action": "Update:Delete", "Principal": "*", "Resource": ["LogicalResourceId/Db*", "LogicalResourceId/Queue*", "*"]}
+  ]}`))
+	assert.Empty(t, problems)
+
+	assert.Equal(t, []string{
+		"statement 1: resource 'LogicalResourceId/Cache' is not in the template",
+		"statement 2: resource 'LogicalResourceId/Queue*' matches no resource in the template",
+	}, policy.CheckResources([]string{"Database", "DbSubnetGroup", "Bucket"}))
+}
+
+func TestReadFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "stack-policy.json")
+	assert.NoError(t, os.WriteFile(file, []byte(`{"Statement": [{"Effect": "Allow"}]}`), 0644))
+
+	_, err := ReadFile(file)
+
+	assert.EqualError(t, err, "invalid stack policy "+file+": statement 1: Action or NotAction is required; "+
+		`statement 1: Principal must be "*"; statement 1: Resource or NotResource is required`)
+}
@@ -0,0 +1,235 @@
+package tags
+
+import (
+	"bytes"
+	"cfn-init/internal/document"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CloudFormation limits on stack tags.
+const (
+	MaxTags        = 50
+	MaxKeyLength   = 128
+	MaxValueLength = 256
+	ReservedPrefix = "aws:"
+)
+
+// allowedChars matches the characters AWS allows in tag keys and values.
+var allowedChars = regexp.MustCompile(`^[\p{L}\p{Z}\p{N}_.:/=+\-@]*$`)
+
+// ValidationError lists every tag rule a tags file breaks.
+type ValidationError struct {
+	File     string
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid tags file %s: %s", e.File, strings.Join(e.Problems, "; "))
+}
+
+// Tag is a single resource tag.
+type Tag struct {
+	Key   string `json:"Key"`
+	Value string `json:"Value"`
+}
+
+// ReadFile loads the tags from a tags file in list or map form.
+func ReadFile(path string) ([]Tag, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return tags, nil
+}
+
+// Parse reads tags written either as [{"Key": "...", "Value": "..."}] or as
+// a {"Key": "Value"} map. Tags keep their file order in list form and are
+// sorted by key in map form, where repeated keys are kept for Validate to report.
+func Parse(data []byte) ([]Tag, error) {
+	doc, err := document.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	switch content := doc.(type) {
+	case []any:
+		tags := make([]Tag, 0, len(content))
+		for i, raw := range content {
+			entry, ok := raw.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("entry %d is not an object", i)
+			}
+			key, ok := entry["Key"].(string)
+			if !ok {
+				return nil, fmt.Errorf("entry %d is missing a string Key", i)
+			}
+			value, ok := scalarString(entry["Value"])
+			if !ok {
+				return nil, fmt.Errorf("tag '%s' has no string Value", key)
+			}
+			tags = append(tags, Tag{Key: key, Value: value})
+		}
+		return tags, nil
+	case map[string]any:
+		// Decoding keeps only the last of repeated keys, so the keys are
+		// read from the source to let Validate report the repeats
+		keys, err := mapKeys(data)
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(keys)
+		tags := make([]Tag, 0, len(keys))
+		for _, key := range keys {
+			value, ok := scalarString(content[key])
+			if !ok {
+				return nil, fmt.Errorf("tag '%s' is not a scalar value", key)
+			}
+			tags = append(tags, Tag{Key: key, Value: value})
+		}
+		return tags, nil
+	default:
+		return nil, fmt.Errorf("not a tags file")
+	}
+}
+
+// ValidateFile parses a tags file and checks its tags against the AWS tag rules.
+func ValidateFile(path string) error {
+	tags, err := ReadFile(path)
+	if err != nil {
+		return &ValidationError{File: path, Problems: []string{err.Error()}}
+	}
+	if problems := Validate(tags); len(problems) > 0 {
+		return &ValidationError{File: path, Problems: problems}
+	}
+	return nil
+}
+
+// Validate checks tags against the CloudFormation tag rules: at most 50 tags,
+// keys of 1 to 128 and values of up to 256 characters from the allowed set,
+// no reserved aws: prefix, and no repeated keys, including keys that differ
+// only in letter case.
+func Validate(tags []Tag) []string {
+	var problems []string
+	if len(tags) > MaxTags {
+		problems = append(problems, fmt.Sprintf("%d tags exceed the limit of %d", len(tags), MaxTags))
+	}
+
+	seen := make(map[string]string)
+	for _, tag := range tags {
+		switch length := utf8.RuneCountInString(tag.Key); {
+		case length == 0:
+			problems = append(problems, "tag key must not be empty")
+			continue
+		case length > MaxKeyLength:
+			problems = append(problems, fmt.Sprintf("tag '%s': key is %d characters, the limit is %d", tag.Key, length, MaxKeyLength))
+		}
+		if length := utf8.RuneCountInString(tag.Value); length > MaxValueLength {
+			problems = append(problems, fmt.Sprintf("tag '%s': value is %d characters, the limit is %d", tag.Key, length, MaxValueLength))
+		}
+		if !allowedChars.MatchString(tag.Key) {
+			problems = append(problems, fmt.Sprintf("tag '%s': key contains characters other than letters, numbers, spaces and _ . : / = + - @", tag.Key))
+		}
+		if !allowedChars.MatchString(tag.Value) {
+			problems = append(problems, fmt.Sprintf("tag '%s': value contains characters other than letters, numbers, spaces and _ . : / = + - @", tag.Key))
+		}
+		if strings.HasPrefix(strings.ToLower(tag.Key), ReservedPrefix) {
+			problems = append(problems, fmt.Sprintf("tag '%s': keys must not start with the reserved '%s' prefix", tag.Key, ReservedPrefix))
+		}
+
+		folded := strings.ToLower(tag.Key)
+		if previous, ok := seen[folded]; ok {
+			problems = append(problems, fmt.Sprintf("tag '%s': duplicates key '%s'", tag.Key, previous))
+			continue
+		}
+		seen[folded] = tag.Key
+	}
+	return problems
+}
+
+// ToMap converts tags to a key/value map. Later tags override earlier ones.
+func ToMap(tags []Tag) map[string]string {
+	values := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		values[tag.Key] = tag.Value
+	}
+	return values
+}
+
+// FromMap converts a key/value map to tags sorted by key.
+func FromMap(values map[string]string) []Tag {
+	tags := make([]Tag, 0, len(values))
+	for key, value := range values {
+		tags = append(tags, Tag{Key: key, Value: value})
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Key < tags[j].Key })
+	return tags
+}
+
+// Marshal encodes tags in the AWS CLI [{"Key": "...", "Value": "..."}] layout.
+func Marshal(tags []Tag) ([]byte, error) {
+	data, err := json.MarshalIndent(tags, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// mapKeys returns the keys of a top-level JSON or YAML mapping in file
+// order, including keys that are repeated.
+func mapKeys(data []byte) ([]string, error) {
+	var keys []string
+	if document.IsJSON(data) {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+		for decoder.More() {
+			token, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			var value json.RawMessage
+			if err := decoder.Decode(&value); err != nil {
+				return nil, err
+			}
+			keys = append(keys, token.(string))
+		}
+		return keys, nil
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	if len(root.Content) == 0 || root.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("not a tags file")
+	}
+	mapping := root.Content[0]
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		keys = append(keys, mapping.Content[i].Value)
+	}
+	return keys, nil
+}
+
+func scalarString(value any) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case bool, int, int64, float64, document.Number:
+		return fmt.Sprint(v), true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,107 @@
+package tags
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse_List(t *testing.T) {
+	tags, err := Parse([]byte(`[{"Key": "team", "Value": "platform"}, {"Key": "cost", "Value": 42}]`))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Tag{{Key: "team", Value: "platform"}, {Key: "cost", Value: "42"}}, tags)
+}
+
+func TestParse_Map(t *testing.T) {
+	tags, err := Parse([]byte("team: platform\napp: web\n"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Tag{{Key: "app", Value: "web"}, {Key: "team", Value: "platform"}}, tags)
+}
+
+func TestParse_ScalarsAsWritten(t *testing.T) {
+	tags, err := Parse([]byte("expires: 2024-12-31\nversion: 1.10\n"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Tag{{Key: "expires", Value: "2024-12-31"}, {Key: "version", Value: "1.10"}}, tags)
+}
+
+func TestParse_MissingKey(t *testing.T) {
+	_, err := Parse([]byte(`[{"Value": "platform"}]`))
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "missing a string Key")
+}
+
+func TestToMap(t *testing.T) {
+	values := ToMap([]Tag{{Key: "a", Value: "1"}, {Key: "a", Value: "2"}})
+
+	assert.Equal(t, map[string]string{"a": "2"}, values)
+}
+
+func TestMarshal(t *testing.T) {
+	data, err := Marshal(FromMap(map[string]string{"team": "platform", "app": "web"}))
+
+	assert.NoError(t, err)
+	tags, err := Parse(data)
+	assert.NoError(t, err)
+	assert.Equal(t, []Tag{{Key: "app", Value: "web"}, {Key: "team", Value: "platform"}}, tags)
+}
+
+func TestValidate(t *testing.T) {
+	problems := Validate([]Tag{
+		{Key: "team", Value: "platform"},
+		{Key: "Team", Value: "data"},
+		{Key: "aws:owner", Value: "me"},
+		{Key: "cost#center", Value: "42"},
+		{Key: strings.Repeat("k", 129), Value: strings.Repeat("v", 257)},
+	})
+
+	assert.Equal(t, []string{
+		"tag 'Team': duplicates key 'team'",
+		"tag 'aws:owner': keys must not start with the reserved 'aws:' prefix",
+		"tag 'cost#center': key contains characters other than letters, numbers, spaces and _ . : / = + - @",
+		"tag '" + strings.Repeat("k", 129) + "': key is 129 characters, the limit is 128",
+		"tag '" + strings.Repeat("k", 129) + "': value is 257 characters, the limit is 256",
+	}, problems)
+}
+
+func TestParse_RepeatedKeys(t *testing.T) {
+	for _, content := range []string{`{"Team": "platform", "App": "web", "Team": "data"}`, "Team: platform\nApp: web\nTeam: data\n"} {
+		tags, err := Parse([]byte(content))
+		assert.NoError(t, err)
+
+		assert.Equal(t, []string{"tag 'Team': duplicates key 'Team'"}, Validate(tags), content)
+	}
+}
+
+func TestValidate_TooManyTags(t *testing.T) {
+	var tags []Tag
+	for i := 0; i <= MaxTags; i++ {
+		tags = append(tags, Tag{Key: fmt.Sprintf("key%d", i), Value: "v"})
+	}
+
+	problems := Validate(tags)
+
+	assert.Equal(t, []string{"51 tags exceed the limit of 50"}, problems)
+}
+
+func TestValidate_UnicodeLetters(t *testing.T) {
+	assert.Empty(t, Validate([]Tag{{Key: "équipe", Value: "plate forme/1"}}))
+}
+
+func TestValidateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tags.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte("aws:team: platform\n"), 0644))
+
+	err := ValidateFile(path)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), path)
+	assert.Contains(t, err.Error(), "tag 'aws:team'")
+}
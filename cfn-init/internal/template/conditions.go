@@ -0,0 +1,244 @@
+package template
+
+import (
+	"cfn-init/internal/document"
+	"cfn-init/internal/encryption"
+	"fmt"
+	"strings"
+)
+
+// Truth is the three-valued result of statically evaluating a condition.
+type Truth int
+
+const (
+	// Unknown means the condition depends on values only known at deploy time.
+	Unknown Truth = iota
+	False
+	True
+)
+
+func (t Truth) String() string {
+	switch t {
+	case True:
+		return "true"
+	case False:
+		return "false"
+	default:
+		return "unknown"
+	}
+}
+
+func truthOf(b bool) Truth {
+	if b {
+		return True
+	}
+	return False
+}
+
+// Evaluator statically evaluates template conditions against known parameter values.
+type Evaluator struct {
+	template *Template
+	values   map[string]string
+	noEcho   map[string]bool
+	// comparing is set while Fn::Equals operands are resolved, which may
+	// use NoEcho values since they never reach the output
+	comparing  bool
+	conditions map[string]Truth
+	visiting   map[string]bool
+}
+
+// NewEvaluator creates an evaluator for a template. Parameter values override
+// template defaults, and the region, when set, provides the region-derived pseudo parameters.
+// SSM parameter types stay unknown, since their values are only the names
+// of the Parameter Store entries to look up, and so do encrypted values,
+// which must neither be compared nor substituted as ciphertext.
+func NewEvaluator(t *Template, parameters map[string]string, region string) *Evaluator {
+	values := t.ParameterDefaults()
+	for name, value := range parameters {
+		values[name] = value
+		if encryption.IsEncrypted(value) {
+			delete(values, name)
+		}
+	}
+	for name := range t.SSMParameters() {
+		delete(values, name)
+	}
+	for name, value := range pseudoParameters(region) {
+		values[name] = value
+	}
+
+	return &Evaluator{
+		template:   t,
+		values:     values,
+		noEcho:     t.NoEchoParameters(),
+		conditions: make(map[string]Truth),
+		visiting:   make(map[string]bool),
+	}
+}
+
+// Value returns the known value of a parameter or pseudo parameter.
+func (e *Evaluator) Value(name string) (string, bool) {
+	value, ok := e.values[name]
+	return value, ok
+}
+
+// Inlined returns the value Resolve substitutes for a parameter: its known
+// value, unless the parameter is NoEcho and must stay out of resolved output.
+// Conditions compare NoEcho parameters by their value.
+func (e *Evaluator) Inlined(name string) (string, bool) {
+	if e.noEcho[name] && !e.comparing {
+		return "", false
+	}
+	return e.Value(name)
+}
+
+// Condition evaluates the named condition from the template's Conditions section.
+func (e *Evaluator) Condition(name string) (Truth, error) {
+	if result, ok := e.conditions[name]; ok {
+		return result, nil
+	}
+	definition, ok := e.template.Conditions[name]
+	if !ok {
+		return Unknown, fmt.Errorf("condition '%s' is not defined", name)
+	}
+	if e.visiting[name] {
+		return Unknown, fmt.Errorf("condition '%s' references itself", name)
+	}
+
+	e.visiting[name] = true
+	result, err := e.evaluate(definition)
+	delete(e.visiting, name)
+	if err != nil {
+		return Unknown, fmt.Errorf("condition '%s': %w", name, err)
+	}
+
+	e.conditions[name] = result
+	return result, nil
+}
+
+func (e *Evaluator) evaluate(expr any) (Truth, error) {
+	fn, args, ok := intrinsic(expr)
+	if !ok {
+		return Unknown, fmt.Errorf("expected a condition function, got %v", expr)
+	}
+
+	switch fn {
+	case "Condition":
+		name, ok := args.(string)
+		if !ok {
+			return Unknown, fmt.Errorf("Condition expects a condition name")
+		}
+		return e.Condition(name)
+	case "Fn::Not":
+		operands, err := conditionOperands(fn, args, 1, 1)
+		if err != nil {
+			return Unknown, err
+		}
+		result, err := e.evaluate(operands[0])
+		if err != nil || result == Unknown {
+			return Unknown, err
+		}
+		return truthOf(result == False), nil
+	case "Fn::And", "Fn::Or":
+		operands, err := conditionOperands(fn, args, 2, 10)
+		if err != nil {
+			return Unknown, err
+		}
+		// The short-circuit value decides the result on its own
+		decisive := truthOf(fn == "Fn::Or")
+		result := truthOf(fn == "Fn::And")
+		for _, operand := range operands {
+			value, err := e.evaluate(operand)
+			if err != nil {
+				return Unknown, err
+			}
+			if value == decisive {
+				return decisive, nil
+			}
+			if value == Unknown {
+				result = Unknown
+			}
+		}
+		return result, nil
+	case "Fn::Equals":
+		operands, err := conditionOperands(fn, args, 2, 2)
+		if err != nil {
+			return Unknown, err
+		}
+		comparing := e.comparing
+		e.comparing = true
+		defer func() { e.comparing = comparing }()
+		left, err := e.resolve(operands[0])
+		if err != nil {
+			return Unknown, err
+		}
+		right, err := e.resolve(operands[1])
+		if err != nil {
+			return Unknown, err
+		}
+		leftText, leftKnown := scalarString(left)
+		rightText, rightKnown := scalarString(right)
+		if !leftKnown || !rightKnown {
+			return Unknown, nil
+		}
+		return truthOf(leftText == rightText), nil
+	default:
+		return Unknown, fmt.Errorf("%s is not allowed in a condition", fn)
+	}
+}
+
+func conditionOperands(fn string, args any, minArgs, maxArgs int) ([]any, error) {
+	operands, ok := args.([]any)
+	if !ok || len(operands) < minArgs || len(operands) > maxArgs {
+		if minArgs == maxArgs {
+			return nil, fmt.Errorf("%s expects %d arguments", fn, minArgs)
+		}
+		return nil, fmt.Errorf("%s expects between %d and %d arguments", fn, minArgs, maxArgs)
+	}
+	return operands, nil
+}
+
+// intrinsic reports whether value is a single-key intrinsic function object.
+func intrinsic(value any) (string, any, bool) {
+	object, ok := value.(map[string]any)
+	if !ok || len(object) != 1 {
+		return "", nil, false
+	}
+	for key, args := range object {
+		if key == "Ref" || key == "Condition" || strings.HasPrefix(key, "Fn::") {
+			return key, args, true
+		}
+	}
+	return "", nil, false
+}
+
+func scalarString(value any) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case bool, int, int64, float64, document.Number:
+		return fmt.Sprint(v), true
+	default:
+		return "", false
+	}
+}
+
+func pseudoParameters(region string) map[string]string {
+	if region == "" {
+		return nil
+	}
+
+	partition, urlSuffix := "aws", "amazonaws.com"
+	switch {
+	case strings.HasPrefix(region, "cn-"):
+		partition, urlSuffix = "aws-cn", "amazonaws.com.cn"
+	case strings.HasPrefix(region, "us-gov-"):
+		partition = "aws-us-gov"
+	}
+
+	return map[string]string{
+		"AWS::Region":    region,
+		"AWS::Partition": partition,
+		"AWS::URLSuffix": urlSuffix,
+	}
+}
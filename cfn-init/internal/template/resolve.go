@@ -0,0 +1,346 @@
+package template
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// noValue marks a value resolved from AWS::NoValue, which removes the enclosing property.
+type noValue struct{}
+
+var subVariable = regexp.MustCompile(`\$\{([^}]*)\}`)
+
+// Resolve statically evaluates the intrinsic functions in a template value.
+// Functions whose result is only known at deploy time, such as Fn::GetAtt or
+// a Ref to a resource, are kept in place with their arguments resolved.
+func (e *Evaluator) Resolve(value any) (any, error) {
+	resolved, err := e.resolve(value)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := resolved.(noValue); ok {
+		return nil, nil
+	}
+	return resolved, nil
+}
+
+func (e *Evaluator) resolve(value any) (any, error) {
+	if fn, args, ok := intrinsic(value); ok && fn != "Condition" {
+		return e.resolveFunction(fn, args)
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for key, item := range v {
+			resolved, err := e.resolve(item)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			if _, ok := resolved.(noValue); !ok {
+				result[key] = resolved
+			}
+		}
+		return result, nil
+	case []any:
+		result := make([]any, 0, len(v))
+		for _, item := range v {
+			resolved, err := e.resolve(item)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := resolved.(noValue); !ok {
+				result = append(result, resolved)
+			}
+		}
+		return result, nil
+	default:
+		return value, nil
+	}
+}
+
+func (e *Evaluator) resolveFunction(fn string, args any) (any, error) {
+	switch fn {
+	case "Ref":
+		return e.resolveRef(args)
+	case "Fn::If":
+		return e.resolveIf(args)
+	case "Fn::FindInMap":
+		return e.resolveFindInMap(args)
+	case "Fn::Join":
+		return e.resolveJoin(args)
+	case "Fn::Sub":
+		return e.resolveSub(args)
+	case "Fn::Select":
+		return e.resolveSelect(args)
+	case "Fn::Split":
+		return e.resolveSplit(args)
+	}
+
+	resolvedArgs, err := e.resolve(args)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{fn: resolvedArgs}, nil
+}
+
+func (e *Evaluator) resolveRef(args any) (any, error) {
+	name, ok := args.(string)
+	if !ok {
+		return nil, fmt.Errorf("Ref expects a name")
+	}
+	if name == "AWS::NoValue" {
+		return noValue{}, nil
+	}
+	value, known := e.Inlined(name)
+	if !known {
+		return map[string]any{"Ref": name}, nil
+	}
+	if e.isListParameter(name) {
+		return splitList(value), nil
+	}
+	return value, nil
+}
+
+func (e *Evaluator) resolveIf(args any) (any, error) {
+	operands, ok := args.([]any)
+	if !ok || len(operands) != 3 {
+		return nil, fmt.Errorf("Fn::If expects 3 arguments")
+	}
+	name, ok := operands[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("Fn::If expects a condition name")
+	}
+
+	result, err := e.Condition(name)
+	if err != nil {
+		return nil, err
+	}
+	switch result {
+	case True:
+		return e.resolve(operands[1])
+	case False:
+		return e.resolve(operands[2])
+	}
+
+	whenTrue, err := e.resolve(operands[1])
+	if err != nil {
+		return nil, err
+	}
+	whenFalse, err := e.resolve(operands[2])
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"Fn::If": []any{name, placeholder(whenTrue), placeholder(whenFalse)}}, nil
+}
+
+func (e *Evaluator) resolveFindInMap(args any) (any, error) {
+	operands, ok := args.([]any)
+	if !ok || len(operands) < 3 {
+		return nil, fmt.Errorf("Fn::FindInMap expects 3 arguments")
+	}
+
+	keys := make([]string, 3)
+	resolved := make([]any, len(operands))
+	known := true
+	for i, operand := range operands {
+		value, err := e.resolve(operand)
+		if err != nil {
+			return nil, err
+		}
+		resolved[i] = value
+		if i < 3 {
+			s, ok := scalarString(value)
+			keys[i] = s
+			known = known && ok
+		}
+	}
+	if !known {
+		return map[string]any{"Fn::FindInMap": resolved}, nil
+	}
+
+	mapping, ok := e.template.Mappings[keys[0]].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("mapping '%s' is not defined", keys[0])
+	}
+	topLevel, ok := mapping[keys[1]].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("mapping '%s' has no key '%s'", keys[0], keys[1])
+	}
+	value, ok := topLevel[keys[2]]
+	if !ok {
+		return nil, fmt.Errorf("mapping '%s' has no key '%s.%s'", keys[0], keys[1], keys[2])
+	}
+	return value, nil
+}
+
+func (e *Evaluator) resolveJoin(args any) (any, error) {
+	operands, ok := args.([]any)
+	if !ok || len(operands) != 2 {
+		return nil, fmt.Errorf("Fn::Join expects 2 arguments")
+	}
+	delimiter, ok := operands[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("Fn::Join expects a string delimiter")
+	}
+
+	resolved, err := e.resolve(operands[1])
+	if err != nil {
+		return nil, err
+	}
+	items, ok := resolved.([]any)
+	if !ok {
+		return map[string]any{"Fn::Join": []any{delimiter, resolved}}, nil
+	}
+
+	parts := make([]string, 0, len(items))
+	for _, item := range items {
+		s, ok := scalarString(item)
+		if !ok {
+			return map[string]any{"Fn::Join": []any{delimiter, items}}, nil
+		}
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, delimiter), nil
+}
+
+func (e *Evaluator) resolveSub(args any) (any, error) {
+	var text string
+	variables := map[string]any{}
+
+	switch v := args.(type) {
+	case string:
+		text = v
+	case []any:
+		if len(v) != 2 {
+			return nil, fmt.Errorf("Fn::Sub expects a string and a variable map")
+		}
+		s, ok := v[0].(string)
+		vars, isMap := v[1].(map[string]any)
+		if !ok || !isMap {
+			return nil, fmt.Errorf("Fn::Sub expects a string and a variable map")
+		}
+		text = s
+		for name, raw := range vars {
+			resolved, err := e.resolve(raw)
+			if err != nil {
+				return nil, err
+			}
+			variables[name] = resolved
+		}
+	default:
+		return nil, fmt.Errorf("Fn::Sub expects a string")
+	}
+
+	unresolved := map[string]any{}
+	substituted := subVariable.ReplaceAllStringFunc(text, func(match string) string {
+		name := match[2 : len(match)-1]
+		if strings.HasPrefix(name, "!") {
+			return match
+		}
+		if value, ok := variables[name]; ok {
+			if s, ok := scalarString(value); ok {
+				return s
+			}
+			unresolved[name] = value
+			return match
+		}
+		if !strings.Contains(name, ".") {
+			if value, ok := e.Inlined(name); ok && !e.isListParameter(name) {
+				return value
+			}
+		}
+		return match
+	})
+
+	if !subVariable.MatchString(strings.ReplaceAll(substituted, "${!", "")) {
+		return strings.ReplaceAll(substituted, "${!", "${"), nil
+	}
+	if len(unresolved) > 0 {
+		return map[string]any{"Fn::Sub": []any{substituted, unresolved}}, nil
+	}
+	return map[string]any{"Fn::Sub": substituted}, nil
+}
+
+func (e *Evaluator) resolveSelect(args any) (any, error) {
+	operands, ok := args.([]any)
+	if !ok || len(operands) != 2 {
+		return nil, fmt.Errorf("Fn::Select expects 2 arguments")
+	}
+
+	index, err := e.resolve(operands[0])
+	if err != nil {
+		return nil, err
+	}
+	list, err := e.resolve(operands[1])
+	if err != nil {
+		return nil, err
+	}
+
+	indexText, indexKnown := scalarString(index)
+	items, listKnown := list.([]any)
+	if !indexKnown || !listKnown {
+		return map[string]any{"Fn::Select": []any{index, list}}, nil
+	}
+	i, err := strconv.Atoi(indexText)
+	if err != nil || i < 0 || i >= len(items) {
+		return nil, fmt.Errorf("Fn::Select index %s is out of range", indexText)
+	}
+	return items[i], nil
+}
+
+func (e *Evaluator) resolveSplit(args any) (any, error) {
+	operands, ok := args.([]any)
+	if !ok || len(operands) != 2 {
+		return nil, fmt.Errorf("Fn::Split expects 2 arguments")
+	}
+	delimiter, ok := operands[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("Fn::Split expects a string delimiter")
+	}
+
+	source, err := e.resolve(operands[1])
+	if err != nil {
+		return nil, err
+	}
+	text, ok := source.(string)
+	if !ok {
+		return map[string]any{"Fn::Split": []any{delimiter, source}}, nil
+	}
+
+	parts := strings.Split(text, delimiter)
+	result := make([]any, len(parts))
+	for i, part := range parts {
+		result[i] = part
+	}
+	return result, nil
+}
+
+// isListParameter reports whether Ref to the named parameter yields a list.
+func (e *Evaluator) isListParameter(name string) bool {
+	param, ok := e.template.Parameters[name].(map[string]any)
+	if !ok {
+		return false
+	}
+	paramType, _ := param["Type"].(string)
+	return paramType == "CommaDelimitedList" || strings.HasPrefix(paramType, "List<")
+}
+
+func splitList(value string) []any {
+	parts := strings.Split(value, ",")
+	result := make([]any, len(parts))
+	for i, part := range parts {
+		result[i] = strings.TrimSpace(part)
+	}
+	return result
+}
+
+// placeholder turns an AWS::NoValue result back into its Ref so it can be kept in output.
+func placeholder(value any) any {
+	if _, ok := value.(noValue); ok {
+		return map[string]any{"Ref": "AWS::NoValue"}
+	}
+	return value
+}
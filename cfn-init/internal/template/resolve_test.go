@@ -0,0 +1,100 @@
+package template
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const resolveTemplate = `
+Parameters:
+  Env:
+    Type: String
+  Subnets:
+    Type: CommaDelimitedList
+Mappings:
+  RegionMap:
+    us-east-1:
+      Ami: ami-123
+Conditions:
+  IsProd: !Equals [!Ref Env, prod]
+  Unknown: !Equals [!Ref "AWS::AccountId", "123"]
+Resources:
+  Bucket:
+    Type: AWS::S3::Bucket
+`
+
+func newResolveEvaluator(t *testing.T) *Evaluator {
+	tmpl := loadTestTemplate(t, resolveTemplate)
+	return NewEvaluator(tmpl, map[string]string{"Env": "prod", "Subnets": "a, b"}, "us-east-1")
+}
+
+func TestResolve_KnownValues(t *testing.T) {
+	evaluator := newResolveEvaluator(t)
+
+	tests := []struct {
+		input    any
+		expected any
+	}{
+		{map[string]any{"Ref": "Env"}, "prod"},
+		{map[string]any{"Ref": "Subnets"}, []any{"a", "b"}},
+		{map[string]any{"Fn::FindInMap": []any{"RegionMap", map[string]any{"Ref": "AWS::Region"}, "Ami"}}, "ami-123"},
+		{map[string]any{"Fn::Join": []any{"-", []any{map[string]any{"Ref": "Env"}, "data"}}}, "prod-data"},
+		{map[string]any{"Fn::Sub": "${Env}-${AWS::Region}-${!Literal}"}, "prod-us-east-1-${Literal}"},
+		{map[string]any{"Fn::Sub": []any{"${Name}-x", map[string]any{"Name": map[string]any{"Ref": "Env"}}}}, "prod-x"},
+		{map[string]any{"Fn::Select": []any{"1", map[string]any{"Ref": "Subnets"}}}, "b"},
+		{map[string]any{"Fn::Split": []any{",", "x,y"}}, []any{"x", "y"}},
+		{map[string]any{"Fn::If": []any{"IsProd", "big", "small"}}, "big"},
+	}
+
+	for _, test := range tests {
+		result, err := evaluator.Resolve(test.input)
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, result, "Input: %v", test.input)
+	}
+}
+
+func TestResolve_KeepsDeployTimePlaceholders(t *testing.T) {
+	evaluator := newResolveEvaluator(t)
+
+	tests := []struct {
+		input    any
+		expected any
+	}{
+		{map[string]any{"Ref": "Bucket"}, map[string]any{"Ref": "Bucket"}},
+		{map[string]any{"Fn::GetAtt": []any{"Bucket", "Arn"}}, map[string]any{"Fn::GetAtt": []any{"Bucket", "Arn"}}},
+		{map[string]any{"Fn::Sub": "${Env}-${AWS::AccountId}"}, map[string]any{"Fn::Sub": "prod-${AWS::AccountId}"}},
+		{
+			map[string]any{"Fn::If": []any{"Unknown", map[string]any{"Ref": "Env"}, "b"}},
+			map[string]any{"Fn::If": []any{"Unknown", "prod", "b"}},
+		},
+	}
+
+	for _, test := range tests {
+		result, err := evaluator.Resolve(test.input)
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, result, "Input: %v", test.input)
+	}
+}
+
+func TestResolve_NoValueRemovesProperty(t *testing.T) {
+	evaluator := newResolveEvaluator(t)
+
+	input := map[string]any{
+		"Kept":    "value",
+		"Removed": map[string]any{"Fn::If": []any{"IsProd", map[string]any{"Ref": "AWS::NoValue"}, "x"}},
+	}
+	result, err := evaluator.Resolve(input)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"Kept": "value"}, result)
+}
+
+func TestResolve_MissingMappingKey(t *testing.T) {
+	evaluator := newResolveEvaluator(t)
+
+	_, err := evaluator.Resolve(map[string]any{"Fn::FindInMap": []any{"RegionMap", "eu-west-1", "Ami"}})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "has no key 'eu-west-1'")
+}
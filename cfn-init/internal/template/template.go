@@ -0,0 +1,131 @@
+package template
+
+import (
+	"cfn-init/internal/document"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Template is a parsed CloudFormation template with its top-level sections split out.
+type Template struct {
+	Path       string
+	Body       map[string]any
+	Parameters map[string]any
+	Mappings   map[string]any
+	Conditions map[string]any
+	Resources  map[string]any
+	Outputs    map[string]any
+}
+
+// Load reads and parses a CloudFormation template from a JSON or YAML file.
+func Load(path string) (*Template, error) {
+	doc, err := document.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	body, ok := doc.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("template %s is not a JSON or YAML object", path)
+	}
+	if _, ok := body["Resources"]; !ok {
+		return nil, fmt.Errorf("template %s has no Resources section", path)
+	}
+
+	return &Template{
+		Path:       path,
+		Body:       body,
+		Parameters: section(body, "Parameters"),
+		Mappings:   section(body, "Mappings"),
+		Conditions: section(body, "Conditions"),
+		Resources:  section(body, "Resources"),
+		Outputs:    section(body, "Outputs"),
+	}, nil
+}
+
+// ParameterDefaults returns the Default value of each template parameter that declares one.
+func (t *Template) ParameterDefaults() map[string]string {
+	defaults := make(map[string]string)
+	for name, raw := range t.Parameters {
+		param, ok := raw.(map[string]any)
+		if !ok {
+			continue
+		}
+		if value, ok := param["Default"]; ok {
+			if s, ok := scalarString(value); ok {
+				defaults[name] = s
+			}
+		}
+	}
+	return defaults
+}
+
+// NoEchoParameters returns the names of the parameters marked NoEcho.
+func (t *Template) NoEchoParameters() map[string]bool {
+	names := make(map[string]bool)
+	for name, raw := range t.Parameters {
+		param, ok := raw.(map[string]any)
+		if !ok {
+			continue
+		}
+		if value, ok := scalarString(param["NoEcho"]); ok && strings.EqualFold(value, "true") {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// SSMParameters returns the names of the parameters of an
+// AWS::SSM::Parameter::Value type, whose values are Parameter Store names that
+// CloudFormation looks up at deploy time.
+func (t *Template) SSMParameters() map[string]bool {
+	names := make(map[string]bool)
+	for name, raw := range t.Parameters {
+		param, ok := raw.(map[string]any)
+		if !ok {
+			continue
+		}
+		if paramType, _ := param["Type"].(string); strings.HasPrefix(paramType, "AWS::SSM::Parameter::Value<") {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// ResourceType returns the Type of the named resource, or an empty string.
+func (t *Template) ResourceType(logicalID string) string {
+	resource, _ := t.Resources[logicalID].(map[string]any)
+	resourceType, _ := resource["Type"].(string)
+	return resourceType
+}
+
+// ResourceCondition returns the Condition attached to the named resource, or an empty string.
+func (t *Template) ResourceCondition(logicalID string) string {
+	resource, _ := t.Resources[logicalID].(map[string]any)
+	condition, _ := resource["Condition"].(string)
+	return condition
+}
+
+// OutputCondition returns the Condition attached to the named output, or an empty string.
+func (t *Template) OutputCondition(name string) string {
+	output, _ := t.Outputs[name].(map[string]any)
+	condition, _ := output["Condition"].(string)
+	return condition
+}
+
+// SortedKeys returns the keys of a template section in lexical order.
+func SortedKeys(section map[string]any) []string {
+	keys := make([]string, 0, len(section))
+	for key := range section {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func section(body map[string]any, name string) map[string]any {
+	if value, ok := body[name].(map[string]any); ok {
+		return value
+	}
+	return make(map[string]any)
+}
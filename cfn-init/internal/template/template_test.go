@@ -0,0 +1,146 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const conditionsTemplate = `
+Parameters:
+  Env:
+    Type: String
+    Default: dev
+  AlarmsEnabled:
+    Type: String
+Conditions:
+  IsProd: !Equals [!Ref Env, prod]
+  IsUsEast1: !Equals [!Ref "AWS::Region", us-east-1]
+  HasAlarms: !Equals [!Ref AlarmsEnabled, "true"]
+  ProdAlarms: !And [!Condition IsProd, !Condition HasAlarms]
+  ProdOrAlarms: !Or [!Condition IsProd, !Condition HasAlarms]
+  NotProd: !Not [!Condition IsProd]
+  Loop: !Not [!Condition Loop]
+Resources:
+  Bucket:
+    Type: AWS::S3::Bucket
+  Alarm:
+    Type: AWS::CloudWatch::Alarm
+    Condition: IsProd
+Outputs:
+  AlarmName:
+    Condition: IsProd
+    Value: !Ref Alarm
+`
+
+func loadTestTemplate(t *testing.T, content string) *Template {
+	path := filepath.Join(t.TempDir(), "template.yaml")
+	err := os.WriteFile(path, []byte(content), 0644)
+	assert.NoError(t, err)
+
+	tmpl, err := Load(path)
+	assert.NoError(t, err)
+	return tmpl
+}
+
+func TestLoad_Sections(t *testing.T) {
+	tmpl := loadTestTemplate(t, conditionsTemplate)
+
+	assert.Len(t, tmpl.Resources, 2)
+	assert.Equal(t, "AWS::CloudWatch::Alarm", tmpl.ResourceType("Alarm"))
+	assert.Equal(t, "IsProd", tmpl.ResourceCondition("Alarm"))
+	assert.Equal(t, "IsProd", tmpl.OutputCondition("AlarmName"))
+	assert.Equal(t, map[string]string{"Env": "dev"}, tmpl.ParameterDefaults())
+}
+
+func TestLoad_MissingResources(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "template.json")
+	err := os.WriteFile(path, []byte(`{"Parameters": {}}`), 0644)
+	assert.NoError(t, err)
+
+	_, err = Load(path)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no Resources section")
+}
+
+func TestCondition_Evaluation(t *testing.T) {
+	tmpl := loadTestTemplate(t, conditionsTemplate)
+
+	evaluator := NewEvaluator(tmpl, map[string]string{"Env": "prod"}, "us-east-1")
+
+	tests := map[string]Truth{
+		"IsProd":       True,
+		"IsUsEast1":    True,
+		"NotProd":      False,
+		"HasAlarms":    Unknown,
+		"ProdAlarms":   Unknown,
+		"ProdOrAlarms": True,
+	}
+	for name, expected := range tests {
+		result, err := evaluator.Condition(name)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, result, "Condition: %s", name)
+	}
+}
+
+func TestCondition_UsesDefaults(t *testing.T) {
+	tmpl := loadTestTemplate(t, conditionsTemplate)
+
+	evaluator := NewEvaluator(tmpl, map[string]string{"AlarmsEnabled": "true"}, "")
+
+	result, err := evaluator.Condition("ProdAlarms")
+	assert.NoError(t, err)
+	assert.Equal(t, False, result)
+
+	result, err = evaluator.Condition("IsUsEast1")
+	assert.NoError(t, err)
+	assert.Equal(t, Unknown, result)
+}
+
+func TestCondition_NoEchoParameter(t *testing.T) {
+	tmpl := loadTestTemplate(t, `
+Parameters:
+  Password:
+    Type: String
+    NoEcho: true
+    Default: ""
+Conditions:
+  HasPassword: !Not [!Equals [!Ref Password, ""]]
+Resources:
+  Secret:
+    Type: AWS::SecretsManager::Secret
+    Condition: HasPassword
+    Properties:
+      SecretString: !Ref Password
+`)
+	evaluator := NewEvaluator(tmpl, map[string]string{"Password": "hunter2"}, "")
+
+	result, err := evaluator.Condition("HasPassword")
+	assert.NoError(t, err)
+	assert.Equal(t, True, result)
+	resolved, err := evaluator.Resolve(tmpl.Resources["Secret"])
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"Ref": "Password"}, resolved.(map[string]any)["Properties"].(map[string]any)["SecretString"])
+
+	result, err = NewEvaluator(tmpl, nil, "").Condition("HasPassword")
+	assert.NoError(t, err)
+	assert.Equal(t, False, result)
+}
+
+func TestCondition_Cycle(t *testing.T) {
+	tmpl := loadTestTemplate(t, conditionsTemplate)
+
+	_, err := NewEvaluator(tmpl, nil, "").Condition("Loop")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "references itself")
+}
+
+func TestCondition_Undefined(t *testing.T) {
+	tmpl := loadTestTemplate(t, conditionsTemplate)
+
+	_, err := NewEvaluator(tmpl, nil, "").Condition("Missing")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not defined")
+}
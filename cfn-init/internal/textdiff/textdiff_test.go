@@ -0,0 +1,42 @@
+package textdiff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnified(t *testing.T) {
+	a := "{\n  \"Env\": \"dev\",\n  \"Size\": \"small\"\n}\n"
+	b := "{\n  \"Env\": \"dev\",\n  \"Size\": \"large\"\n}\n"
+
+	assert.Equal(t, `--- a/params.json
++++ b/params.json
+@@ -1,4 +1,4 @@
+ {
+   "Env": "dev",
+-  "Size": "small"
++  "Size": "large"
+ }
+`, Unified("a/params.json", "b/params.json", a, b))
+}
+
+func TestUnified_SeparateHunks(t *testing.T) {
+	var lines []string
+	for i := 0; i < 20; i++ {
+		lines = append(lines, string(rune('a'+i)))
+	}
+	a := strings.Join(lines, "\n") + "\n"
+	lines[1], lines[18] = "B", "S"
+	b := strings.Join(lines, "\n") + "\n"
+
+	diff := Unified("old", "new", a, b)
+
+	assert.Contains(t, diff, "@@ -1,5 +1,5 @@\n a\n-b\n+B\n c\n d\n e\n")
+	assert.Contains(t, diff, "@@ -16,5 +16,5 @@\n p\n q\n r\n-s\n+S\n t\n")
+}
+
+func TestUnified_Equal(t *testing.T) {
+	assert.Equal(t, "", Unified("a", "b", "same\n", "same\n"))
+}